@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+)
+
+// runHashKey implements the hash-key subcommand, which prints the key_hash
+// to configure an API key by in security.api_keys. Without a key, a new one
+// is generated; "-" reads the key from stdin so it stays out of the shell
+// history:
+//
+//	verus-gateway hash-key
+//	echo -n "$KEY" | verus-gateway hash-key -
+func runHashKey(args []string) int {
+	fs := flag.NewFlagSet("hash-key", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: verus-gateway hash-key [key | -]")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	key := fs.Arg(0)
+	switch key {
+	case "":
+		generated, err := auth.GenerateKey()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hash-key: %v\n", err)
+			return 1
+		}
+		key = generated
+		fmt.Printf("key:      %s\n", key)
+	case "-":
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintf(os.Stderr, "hash-key: failed to read key: %v\n", err)
+			return 1
+		}
+		key = strings.TrimRight(line, "\r\n")
+	}
+
+	fmt.Printf("key_hash: %s\n", auth.HashKey(key))
+	return 0
+}
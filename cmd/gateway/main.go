@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -11,13 +12,17 @@ import (
 
 	"github.com/rs/zerolog"
 
+	"github.com/devdudeio/verus-gateway/internal/auth"
 	"github.com/devdudeio/verus-gateway/internal/cache"
 	"github.com/devdudeio/verus-gateway/internal/chain"
 	"github.com/devdudeio/verus-gateway/internal/config"
 	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/events"
 	"github.com/devdudeio/verus-gateway/internal/http/server"
 	"github.com/devdudeio/verus-gateway/internal/observability/logger"
 	"github.com/devdudeio/verus-gateway/internal/observability/metrics"
+	"github.com/devdudeio/verus-gateway/internal/share"
+	"github.com/devdudeio/verus-gateway/internal/vault"
 )
 
 var (
@@ -28,6 +33,14 @@ var (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "notify" {
+		os.Exit(runNotify(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "hash-key" {
+		os.Exit(runHashKey(os.Args[2:]))
+	}
+
 	// Parse command line flags
 	var (
 		configPath  = flag.String("config", "", "path to configuration file")
@@ -85,9 +98,16 @@ func main() {
 	appMetrics := metrics.New("verus_gateway")
 	appLogger.Info().Msg("Metrics initialized successfully")
 
+	// Initialize event bus (GET /events)
+	var bus *events.Bus
+	if cfg.Events.Enabled {
+		bus = events.NewBus(cfg.Events.BufferSize)
+		appLogger.Info().Msg("Event stream enabled")
+	}
+
 	// Initialize cache
 	appLogger.Info().Msg("Initializing cache...")
-	cache, err := initializeCache(cfg)
+	cache, err := initializeCache(cfg, bus)
 	if err != nil {
 		appLogger.Fatal().Err(err).Msg("Failed to initialize cache")
 	}
@@ -96,7 +116,17 @@ func main() {
 			_ = cache.Close()
 		}
 	}()
-	appLogger.Info().Str("type", cfg.Cache.Type).Msg("Cache initialized successfully")
+	appLogger.Info().Str("type", cfg.Cache.Type).Bool("encrypted", cfg.Cache.Encryption.Enabled).Msg("Cache initialized successfully")
+
+	// Remove entries from before encrypted files were bound to a viewing key
+	// digest
+	if purger, ok := cache.(domain.LegacyPurger); ok {
+		if removed, err := purger.PurgeLegacy(context.Background()); err != nil {
+			appLogger.Warn().Err(err).Msg("Failed to purge legacy cache entries")
+		} else if removed > 0 {
+			appLogger.Info().Int("removed", removed).Msg("Purged legacy cache entries")
+		}
+	}
 
 	// Initialize chain manager
 	appLogger.Info().Msg("Initializing chain manager...")
@@ -105,11 +135,38 @@ func main() {
 		appLogger.Fatal().Err(err).Msg("Failed to initialize chain manager")
 	}
 	defer func() { _ = chainManager.Close() }()
+	chainManager.SetLogger(&appLogger)
 	appLogger.Info().Msg("Chain manager initialized successfully")
 
+	// Initialize share links
+	shares, err := initializeShares(cfg)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Failed to initialize share links")
+	}
+	if shares != nil {
+		appLogger.Info().Bool("persistent", cfg.Sharing.StateFile != "").Msg("Share links enabled")
+	}
+
+	// Initialize viewing key vault
+	keyVault, err := initializeVault(cfg)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Failed to open viewing key vault")
+	}
+	if keyVault != nil {
+		appLogger.Info().Int("keys", len(keyVault.List())).Int("clients", len(cfg.Vault.Clients)).Msg("Viewing key vault enabled")
+	}
+
+	tokens, err := initializeTokens(cfg)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("Failed to initialize JWT authentication")
+	}
+	if tokens != nil {
+		appLogger.Info().Str("issuer", cfg.Security.JWT.Issuer).Msg("JWT authentication enabled")
+	}
+
 	// Initialize HTTP server
 	appLogger.Info().Msg("Initializing HTTP server...")
-	httpServer := initializeHTTPServer(cfg, chainManager, cache, &appLogger, appMetrics)
+	httpServer := initializeHTTPServer(cfg, chainManager, cache, bus, shares, keyVault, tokens, &appLogger, appMetrics)
 	appLogger.Info().Msg("HTTP server initialized successfully")
 
 	appLogger.Info().Msg("Verus Gateway initialized successfully")
@@ -117,11 +174,20 @@ func main() {
 		Str("host", cfg.Server.Host).
 		Int("port", cfg.Server.Port).
 		Msg("Starting server")
+	if internal := cfg.Server.Internal; internal.Enabled {
+		appLogger.Info().
+			Str("host", internal.Host).
+			Int("port", internal.Port).
+			Msg("Serving metrics, admin and profiling endpoints on the internal listener")
+	}
 
 	// Setup graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	defer stop()
 
+	// Track chain tips for reorg detection until shutdown
+	chainManager.WatchBlocks(ctx)
+
 	// Start HTTP server in a goroutine
 	serverErr := make(chan error, 1)
 	go func() {
@@ -142,17 +208,14 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer shutdownCancel()
 
-	// Shutdown HTTP server
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		appLogger.Error().Err(err).Msg("Error during server shutdown")
-	}
-
-	// Wait for shutdown to complete or timeout
-	<-shutdownCtx.Done()
-
-	if shutdownCtx.Err() == context.DeadlineExceeded {
+	// Shutdown all listeners; returns once in-flight requests have finished
+	err = httpServer.Shutdown(shutdownCtx)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
 		appLogger.Warn().Msg("Shutdown timeout exceeded, forcing exit")
-	} else {
+	case err != nil:
+		appLogger.Error().Err(err).Msg("Error during server shutdown")
+	default:
 		appLogger.Info().Msg("Server stopped gracefully")
 	}
 
@@ -164,8 +227,24 @@ func init() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 }
 
-// initializeCache initializes the cache based on configuration
-func initializeCache(cfg *config.Config) (domain.Cache, error) {
+// initializeCache initializes the cache based on configuration, wrapped in
+// an encryption layer if enabled
+func initializeCache(cfg *config.Config, bus *events.Bus) (domain.Cache, error) {
+	backend, err := initializeCacheBackend(cfg, bus)
+	if err != nil || backend == nil || !cfg.Cache.Encryption.Enabled {
+		return backend, err
+	}
+
+	ring, err := cache.LoadKeyRing(cfg.Cache.Encryption.KeyFile, cfg.Cache.Encryption.Keys)
+	if err != nil {
+		_ = backend.Close()
+		return nil, err
+	}
+	return cache.NewEncryptedCache(backend, ring), nil
+}
+
+// initializeCacheBackend initializes the configured cache backend
+func initializeCacheBackend(cfg *config.Config, bus *events.Bus) (domain.Cache, error) {
 	switch cfg.Cache.Type {
 	case "filesystem":
 		return cache.NewFilesystemCache(cache.FilesystemCacheConfig{
@@ -173,6 +252,13 @@ func initializeCache(cfg *config.Config) (domain.Cache, error) {
 			MaxSize:         cfg.Cache.MaxSize,
 			TTL:             cfg.Cache.TTL,
 			CleanupInterval: cfg.Cache.CleanupInterval,
+			OnEvict: func(reason string, items int, bytes int64) {
+				bus.Publish(events.TopicCacheEvict, "", map[string]interface{}{
+					"reason": reason,
+					"items":  items,
+					"bytes":  bytes,
+				})
+			},
 		})
 	case "redis":
 		return cache.NewRedisCache(cache.RedisCacheConfig{
@@ -192,13 +278,56 @@ func initializeCache(cfg *config.Config) (domain.Cache, error) {
 	}
 }
 
+// initializeShares creates the share link manager if share links are enabled
+func initializeShares(cfg *config.Config) (*share.Manager, error) {
+	if !cfg.Sharing.Enabled {
+		return nil, nil
+	}
+	return share.NewManager(share.Config{
+		Secret:     []byte(cfg.Sharing.Secret),
+		DefaultTTL: cfg.Sharing.DefaultTTL,
+		MaxTTL:     cfg.Sharing.MaxTTL,
+		StateFile:  cfg.Sharing.StateFile,
+	})
+}
+
+// initializeVault opens the viewing key vault if it is enabled
+func initializeVault(cfg *config.Config) (*vault.Vault, error) {
+	if !cfg.Vault.Enabled {
+		return nil, nil
+	}
+	return vault.Open(vault.Config{
+		Secret: []byte(cfg.Vault.Secret),
+		File:   cfg.Vault.File,
+	})
+}
+
+// initializeTokens creates the JWT verifier if JWT authentication is enabled
+func initializeTokens(cfg *config.Config) (*auth.JWTVerifier, error) {
+	jwt := cfg.Security.JWT
+	if !jwt.Enabled {
+		return nil, nil
+	}
+	return auth.NewJWTVerifier(auth.JWTConfig{
+		Issuer:     jwt.Issuer,
+		Audiences:  jwt.Audiences,
+		JWKSFile:   jwt.JWKSFile,
+		JWKSURL:    jwt.JWKSURL,
+		CacheTTL:   jwt.JWKSCacheTTL,
+		ScopeClaim: jwt.ScopeClaim,
+		ScopeMap:   jwt.ScopeMap,
+		NameClaim:  jwt.NameClaim,
+		Leeway:     jwt.Leeway,
+	})
+}
+
 // initializeChainManager initializes the chain manager
 func initializeChainManager(cfg *config.Config) (*chain.Manager, error) {
 	return chain.NewManager(cfg)
 }
 
 // initializeHTTPServer initializes the HTTP server
-func initializeHTTPServer(cfg *config.Config, chainManager *chain.Manager, cache domain.Cache, logger *zerolog.Logger, m *metrics.Metrics) *server.Server {
+func initializeHTTPServer(cfg *config.Config, chainManager *chain.Manager, cache domain.Cache, bus *events.Bus, shares *share.Manager, keyVault *vault.Vault, tokens *auth.JWTVerifier, logger *zerolog.Logger, m *metrics.Metrics) *server.Server {
 	return server.New(server.Config{
 		ChainManager: chainManager,
 		Cache:        cache,
@@ -206,5 +335,9 @@ func initializeHTTPServer(cfg *config.Config, chainManager *chain.Manager, cache
 		Version:      Version,
 		Logger:       logger,
 		Metrics:      m,
+		Events:       bus,
+		Shares:       shares,
+		Vault:        keyVault,
+		Tokens:       tokens,
 	})
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/config"
+)
+
+// runNotify implements the notify subcommand, which tells a running gateway
+// that a chain has a new block. It is meant to be the node's blocknotify
+// script:
+//
+//	verusd -blocknotify="/usr/local/bin/verus-gateway notify -config /etc/verus-gateway/config.yaml vrsc %s"
+func runNotify(args []string) int {
+	fs := flag.NewFlagSet("notify", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to configuration file")
+	baseURL := fs.String("url", "", "gateway base URL (default: from the configuration)")
+	token := fs.String("token", "", "blocknotify token (default: security.blocknotify_token)")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	insecure := fs.Bool("insecure", false, "skip TLS certificate verification (e.g. for a certificate not issued for the loopback address)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: verus-gateway notify [flags] <chain> [blockhash]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return 2
+	}
+	chainID, hash := fs.Arg(0), fs.Arg(1)
+
+	// The URL and token come from the gateway's own configuration unless
+	// given on the command line
+	if *baseURL == "" || *token == "" {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "notify: %v\n", err)
+			return 1
+		}
+		if *baseURL == "" {
+			// /admin is only served by the internal listener
+			internal := cfg.Server.Internal
+			if !internal.Enabled {
+				fmt.Fprintln(os.Stderr, "notify: server.internal is disabled; pass -url")
+				return 1
+			}
+			host := internal.Host
+			if host == "" || host == "0.0.0.0" || host == "::" {
+				host = "127.0.0.1"
+			}
+			*baseURL = "http://" + net.JoinHostPort(host, strconv.Itoa(internal.Port))
+		}
+		if *token == "" {
+			*token = cfg.Security.BlockNotifyToken
+		}
+	}
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "notify: no blocknotify token configured")
+		return 1
+	}
+
+	body, _ := json.Marshal(map[string]string{"hash": hash})
+	endpoint := strings.TrimRight(*baseURL, "/") + "/admin/chains/" + url.PathEscape(chainID) + "/blocknotify"
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notify: %v\n", err)
+		return 1
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.DefaultClient
+	if *insecure {
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notify: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		fmt.Fprintf(os.Stderr, "notify: %s: %s\n", resp.Status, strings.TrimSpace(string(message)))
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,183 @@
+// Package auth identifies API keys and the scopes they grant. Keys are
+// configured by their SHA-256 hash, so the configuration never holds them.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Scopes grant access to groups of routes
+const (
+	ScopeFilesRead    = "files:read"    // Public files, metadata and listings
+	ScopeFilesPrivate = "files:private" // Decrypting with a caller-supplied viewing key
+	ScopeAdminCache   = "admin:cache"   // /admin/cache
+	ScopeAdminChains  = "admin:chains"  // /admin/chains (block notifications)
+	ScopeAdminDebug   = "admin:debug"   // /debug/pprof on the internal and admin listeners
+	ScopeMetrics      = "metrics"       // /metrics
+	ScopeSharesWrite  = "shares:write"  // Issuing and revoking share links
+	ScopeAdminVault   = "admin:vault"   // /admin/vault
+	ScopeEventsRead   = "events:read"   // /events
+)
+
+// Scopes lists all known scopes
+var Scopes = []string{ScopeFilesRead, ScopeFilesPrivate, ScopeAdminCache, ScopeAdminChains, ScopeAdminDebug, ScopeMetrics, ScopeSharesWrite, ScopeAdminVault, ScopeEventsRead}
+
+// hashPrefix starts every key hash and names its algorithm
+const hashPrefix = "sha256:"
+
+// AnonymousName identifies requests without a recognized API key
+const AnonymousName = "anonymous"
+
+// CertNamePrefix starts the principal name of client certificate callers
+const CertNamePrefix = "cert:"
+
+// ValidScope reports whether scope is known
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HashKey returns the hash an API key is configured by
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// GenerateKey returns a new random API key
+func GenerateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return "vgk_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// parseHash decodes a key hash
+func parseHash(hash string) ([]byte, error) {
+	if !strings.HasPrefix(hash, hashPrefix) {
+		return nil, fmt.Errorf("key hash must start with %q", hashPrefix)
+	}
+	sum, err := hex.DecodeString(strings.TrimPrefix(hash, hashPrefix))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("key hash must be %s followed by 64 hex characters", hashPrefix)
+	}
+	return sum, nil
+}
+
+// ValidHash checks a configured key hash
+func ValidHash(hash string) error {
+	_, err := parseHash(hash)
+	return err
+}
+
+// Key is a configured API key
+type Key struct {
+	Name   string
+	Hash   string   // HashKey of the key
+	Scopes []string // Scopes the key grants
+	Chains []string // Chains the key may access (empty = all)
+}
+
+// Principal is the caller a request is made by
+type Principal struct {
+	Name   string
+	Scopes []string
+	Chains []string // Empty = all
+}
+
+// Anonymous returns the principal for requests without an API key
+func Anonymous(scopes []string) *Principal {
+	return &Principal{Name: AnonymousName, Scopes: scopes}
+}
+
+// Has reports whether the principal was granted scope
+func (p *Principal) Has(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsChain reports whether the principal may access chainID
+func (p *Principal) AllowsChain(chainID string) bool {
+	if len(p.Chains) == 0 || chainID == "" {
+		return true
+	}
+	for _, c := range p.Chains {
+		if strings.EqualFold(c, chainID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Anonymous reports whether the principal is the anonymous caller
+func (p *Principal) Anonymous() bool {
+	return p.Name == AnonymousName
+}
+
+// keyEntry is a key with its decoded hash
+type keyEntry struct {
+	hash      []byte
+	principal Principal
+}
+
+// Keyring identifies API keys
+type Keyring struct {
+	keys []keyEntry
+}
+
+// NewKeyring creates a keyring from configured keys
+func NewKeyring(keys []Key) (*Keyring, error) {
+	ring := &Keyring{}
+	for _, k := range keys {
+		hash, err := parseHash(k.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("api key %s: %w", k.Name, err)
+		}
+		for _, scope := range k.Scopes {
+			if !ValidScope(scope) {
+				return nil, fmt.Errorf("api key %s: unknown scope %q", k.Name, scope)
+			}
+		}
+		ring.keys = append(ring.keys, keyEntry{
+			hash:      hash,
+			principal: Principal{Name: k.Name, Scopes: k.Scopes, Chains: k.Chains},
+		})
+	}
+	return ring, nil
+}
+
+// Len returns the number of keys
+func (r *Keyring) Len() int {
+	return len(r.keys)
+}
+
+// Lookup returns the principal a presented key belongs to
+func (r *Keyring) Lookup(key string) (*Principal, bool) {
+	if key == "" {
+		return nil, false
+	}
+	sum := sha256.Sum256([]byte(key))
+
+	var found *Principal
+	for i := range r.keys {
+		// Compare every hash in constant time so the match position doesn't leak
+		if subtle.ConstantTimeCompare(sum[:], r.keys[i].hash) == 1 && found == nil {
+			found = &r.keys[i].principal
+		}
+	}
+	return found, found != nil
+}
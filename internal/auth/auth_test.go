@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyring_Lookup(t *testing.T) {
+	ring, err := NewKeyring([]Key{
+		{Name: "ops", Hash: HashKey("ops-key"), Scopes: []string{ScopeAdminCache, ScopeMetrics}},
+		{Name: "reader", Hash: HashKey("reader-key"), Scopes: []string{ScopeFilesRead}, Chains: []string{"vrsc"}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	tests := []struct {
+		key      string
+		wantName string
+	}{
+		{"ops-key", "ops"},
+		{"reader-key", "reader"},
+		{"unknown", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		principal, ok := ring.Lookup(tt.key)
+		if tt.wantName == "" {
+			if ok {
+				t.Errorf("Lookup(%q) = %s, want no match", tt.key, principal.Name)
+			}
+			continue
+		}
+		if !ok || principal.Name != tt.wantName {
+			t.Errorf("Lookup(%q) = %v, %v, want %s", tt.key, principal, ok, tt.wantName)
+		}
+	}
+}
+
+func TestNewKeyring_Invalid(t *testing.T) {
+	tests := map[string]Key{
+		"plaintext":     {Name: "a", Hash: "ops-key", Scopes: []string{ScopeMetrics}},
+		"short hash":    {Name: "a", Hash: "sha256:abcd", Scopes: []string{ScopeMetrics}},
+		"unknown scope": {Name: "a", Hash: HashKey("k"), Scopes: []string{"admin:all"}},
+	}
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewKeyring([]Key{key}); err == nil {
+				t.Error("NewKeyring() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	p := &Principal{Name: "reader", Scopes: []string{ScopeFilesRead}, Chains: []string{"vrsc"}}
+
+	if !p.Has(ScopeFilesRead) || p.Has(ScopeFilesPrivate) {
+		t.Error("Has() does not match the granted scopes")
+	}
+	if !p.AllowsChain("VRSC") || p.AllowsChain("vrsctest") {
+		t.Error("AllowsChain() does not match the allowlist")
+	}
+	if !p.AllowsChain("") {
+		t.Error("AllowsChain() refused a route without a chain")
+	}
+	if p.Anonymous() || !Anonymous(nil).Anonymous() {
+		t.Error("Anonymous() mismatch")
+	}
+	if all := (&Principal{}); !all.AllowsChain("vrsctest") {
+		t.Error("AllowsChain() without an allowlist refused a chain")
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	a, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateKey()
+	if a == b || !strings.HasPrefix(a, "vgk_") || len(a) < 40 {
+		t.Errorf("GenerateKey() = %q, %q", a, b)
+	}
+	if err := ValidHash(HashKey(a)); err != nil {
+		t.Errorf("ValidHash(HashKey()) error = %v", err)
+	}
+}
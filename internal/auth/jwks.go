@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Key set refresh limits
+const (
+	DefaultJWKSCacheTTL = 5 * time.Minute
+	jwksMinRefresh      = 30 * time.Second // Between refetches for unknown key IDs
+	jwksFetchTimeout    = 10 * time.Second
+	jwksMaxSize         = 1 << 20
+)
+
+// jwk is a signing key from a JWKS
+type jwk struct {
+	alg string // Algorithm the key is restricted to (empty = any matching its type)
+	pub crypto.PublicKey
+}
+
+// jwkJSON is a key as it appears in a JWKS document
+type jwkJSON struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet caches the keys of a JWKS file or URL
+type keySet struct {
+	mu       sync.Mutex
+	load     func(ctx context.Context) ([]byte, error)
+	ttl      time.Duration
+	keys     map[string]*jwk
+	fetched  time.Time     // Last load attempt, successful or not
+	err      error         // Error of the last load attempt
+	inflight chan struct{} // Closed when the running load finishes
+	now      func() time.Time
+}
+
+// fileSource reads a JWKS from a local file
+func fileSource(path string) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// urlSource fetches a JWKS over HTTP
+func urlSource(url string, client *http.Client) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+	}
+}
+
+// key returns the key with the given ID, refetching the set when it is
+// older than the TTL or doesn't know the ID. Refetches for unknown IDs, and
+// after a failed load, happen at most every jwksMinRefresh; in between, the
+// last load error is returned. Concurrent requests share one fetch, which
+// runs without the lock held; requests for a known key don't wait for it.
+func (s *keySet) key(ctx context.Context, kid string) (*jwk, error) {
+	s.mu.Lock()
+	loaded := false
+	for {
+		age := s.now().Sub(s.fetched)
+		k, known := s.lookup(kid)
+		stale := !loaded && (s.fetched.IsZero() || age > s.ttl || (!known && age > jwksMinRefresh))
+
+		switch {
+		case known && (!stale || s.inflight != nil):
+			// On failure keep using the previous keys until the source recovers
+			s.mu.Unlock()
+			return k, nil
+
+		case !stale:
+			err := s.err
+			if s.keys != nil || err == nil {
+				err = fmt.Errorf("unknown signing key %q", kid)
+			}
+			s.mu.Unlock()
+			return nil, err
+
+		case s.inflight != nil:
+			done := s.inflight
+			s.mu.Unlock()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			s.mu.Lock()
+
+		default:
+			s.refresh(ctx)
+		}
+		loaded = true
+	}
+}
+
+// refresh reloads the key set. It is called with the lock held and releases
+// it while loading; inflight lets other requests wait for the result.
+func (s *keySet) refresh(ctx context.Context) {
+	// Count failures too, so an unreachable source isn't hammered
+	s.fetched = s.now()
+	done := make(chan struct{})
+	s.inflight = done
+	s.mu.Unlock()
+
+	// Requests share the load, so one caller going away mustn't cancel it
+	keys, err := s.fetch(context.WithoutCancel(ctx))
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+	}
+	s.err = err
+	s.inflight = nil
+	close(done)
+}
+
+// lookup finds a key by ID; tokens without one match a single-key set
+func (s *keySet) lookup(kid string) (*jwk, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+	k, ok := s.keys[kid]
+	return k, ok
+}
+
+// fetch loads and parses the key set
+func (s *keySet) fetch(ctx context.Context) (map[string]*jwk, error) {
+	data, err := s.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// parseJWKS parses the signing keys of a JWKS document. Keys of other
+// types or for encryption are skipped.
+func parseJWKS(data []byte) (map[string]*jwk, error) {
+	var doc struct {
+		Keys []jwkJSON `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]*jwk)
+	for _, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		pub, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", raw.Kid, err)
+		}
+		if pub != nil {
+			keys[raw.Kid] = &jwk{alg: raw.Alg, pub: pub}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no supported signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes the key; unsupported types return nil
+func (k *jwkJSON) publicKey() (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on P-256")
+		}
+		return pub, nil
+
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+// decodeBigInt decodes a base64url-encoded unsigned integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
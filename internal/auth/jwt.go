@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported JWT signing algorithms
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// JWTNamePrefix starts the principal name of token callers, keeping them
+// apart from API key names in logs and vault client lookups
+const JWTNamePrefix = "jwt:"
+
+// DefaultJWTLeeway is the clock skew tolerated for exp and nbf
+const DefaultJWTLeeway = 30 * time.Second
+
+// ErrInvalidJWT is wrapped by every token validation error
+var ErrInvalidJWT = errors.New("invalid token")
+
+// JWTConfig configures bearer token validation
+type JWTConfig struct {
+	Issuer    string   // Required iss claim
+	Audiences []string // aud must contain one of these
+	JWKSFile  string   // Local JWKS file
+	JWKSURL   string   // JWKS URL (used when JWKSFile is empty)
+	CacheTTL  time.Duration
+
+	// ScopeClaim holds the caller's scopes as a space-separated string or
+	// an array (default "scope")
+	ScopeClaim string
+
+	// ScopeMap translates claim values into gateway scopes, matched
+	// case-insensitively (empty = claim values are gateway scopes)
+	ScopeMap map[string][]string
+
+	// NameClaim identifies the caller in logs (default "sub")
+	NameClaim string
+
+	Leeway     time.Duration
+	HTTPClient *http.Client // Fetches JWKSURL (default: http.DefaultClient)
+}
+
+// JWTVerifier validates signed bearer tokens and maps their claims to a
+// principal
+type JWTVerifier struct {
+	cfg      JWTConfig
+	scopeMap map[string][]string
+	keys     *keySet
+	now      func() time.Time
+}
+
+// NewJWTVerifier creates a verifier. A JWKS file is loaded immediately so
+// that configuration errors surface at startup; a JWKS URL is fetched on
+// first use.
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if cfg.Issuer == "" || len(cfg.Audiences) == 0 {
+		return nil, fmt.Errorf("jwt requires an issuer and at least one audience")
+	}
+	if cfg.ScopeClaim == "" {
+		cfg.ScopeClaim = "scope"
+	}
+	if cfg.NameClaim == "" {
+		cfg.NameClaim = "sub"
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultJWKSCacheTTL
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = DefaultJWTLeeway
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	scopeMap := make(map[string][]string, len(cfg.ScopeMap))
+	for value, scopes := range cfg.ScopeMap {
+		for _, scope := range scopes {
+			if !ValidScope(scope) {
+				return nil, fmt.Errorf("jwt scope_map %s: unknown scope %q", value, scope)
+			}
+		}
+		scopeMap[strings.ToLower(value)] = scopes
+	}
+
+	v := &JWTVerifier{cfg: cfg, scopeMap: scopeMap, now: time.Now}
+	v.keys = &keySet{ttl: cfg.CacheTTL, now: func() time.Time { return v.now() }}
+	switch {
+	case cfg.JWKSFile != "":
+		v.keys.load = fileSource(cfg.JWKSFile)
+		keys, err := v.keys.fetch(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		v.keys.keys, v.keys.fetched = keys, v.now()
+	case cfg.JWKSURL != "":
+		v.keys.load = urlSource(cfg.JWKSURL, cfg.HTTPClient)
+	default:
+		return nil, fmt.Errorf("jwt requires a jwks_file or jwks_url")
+	}
+	return v, nil
+}
+
+// LooksLikeJWT reports whether a bearer credential has the shape of a
+// JWT, as opposed to an API key
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify checks a token's signature and claims and returns its principal
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	claims, err := v.verifySignature(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+	}
+
+	name, _ := claims[v.cfg.NameClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidJWT, v.cfg.NameClaim)
+	}
+	return &Principal{Name: JWTNamePrefix + name, Scopes: v.scopes(claims[v.cfg.ScopeClaim])}, nil
+}
+
+// verifySignature checks the token's signature and decodes its claims
+func (v *JWTVerifier) verifySignature(ctx context.Context, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		Typ string `json:"typ"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header")
+	}
+	if header.Typ != "" && !strings.EqualFold(header.Typ, "JWT") && !strings.EqualFold(header.Typ, "at+jwt") {
+		return nil, fmt.Errorf("unsupported token type %q", header.Typ)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("algorithm %q does not match the signing key", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	if !verifyJWS(header.Alg, key.pub, parts[0]+"."+parts[1], digest[:], sig) {
+		return nil, fmt.Errorf("signature verification failed")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed claims")
+	}
+	return claims, nil
+}
+
+// verifyJWS checks a signature with the key type the algorithm requires,
+// so a token can't pick an algorithm its key wasn't issued for
+func verifyJWS(alg string, pub crypto.PublicKey, signingInput string, digest, sig []byte) bool {
+	switch alg {
+	case AlgRS256:
+		k, ok := pub.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig) == nil
+	case AlgES256:
+		k, ok := pub.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k, digest, r, s)
+	case AlgEdDSA:
+		k, ok := pub.(ed25519.PublicKey)
+		return ok && ed25519.Verify(k, []byte(signingInput), sig)
+	}
+	return false
+}
+
+// validateClaims checks iss, aud, exp and nbf
+func (v *JWTVerifier) validateClaims(claims map[string]any) error {
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !v.audienceAllowed(claims["aud"]) {
+		return fmt.Errorf("audience not accepted")
+	}
+
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("missing exp claim")
+	}
+	if now.After(unixTime(exp).Add(v.cfg.Leeway)) {
+		return fmt.Errorf("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.cfg.Leeway).Before(unixTime(nbf)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	return nil
+}
+
+// audienceAllowed reports whether aud (a string or an array) contains a
+// configured audience
+func (v *JWTVerifier) audienceAllowed(aud any) bool {
+	for _, a := range claimStrings(aud) {
+		for _, allowed := range v.cfg.Audiences {
+			if a == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopes maps the scope claim to gateway scopes; unknown values are ignored
+func (v *JWTVerifier) scopes(claim any) []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	add := func(scope string) {
+		if ValidScope(scope) && !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	for _, value := range claimStrings(claim) {
+		if len(v.scopeMap) == 0 {
+			add(value)
+			continue
+		}
+		for _, scope := range v.scopeMap[strings.ToLower(value)] {
+			add(scope)
+		}
+	}
+	return scopes
+}
+
+// claimStrings reads a claim that is a space-separated string or an array
+// of strings
+func claimStrings(claim any) []string {
+	switch c := claim.(type) {
+	case string:
+		return strings.Fields(c)
+	case []any:
+		values := make([]string, 0, len(c))
+		for _, item := range c {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a NumericDate claim
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSigner signs tokens with one key and publishes it as a JWK
+type testSigner struct {
+	kid  string
+	alg  string
+	priv crypto.Signer
+}
+
+func newTestSigners(t *testing.T) []*testSigner {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*testSigner{
+		{kid: "rsa", alg: AlgRS256, priv: rsaKey},
+		{kid: "ec", alg: AlgES256, priv: ecKey},
+		{kid: "ed", alg: AlgEdDSA, priv: edKey},
+	}
+}
+
+func (s *testSigner) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := s.priv.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": s.kid, "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": s.kid, "crv": "P-256", "x": b64(k.X.FillBytes(make([]byte, 32))), "y": b64(k.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": s.kid, "crv": "Ed25519", "x": b64(k)}
+	}
+	return nil
+}
+
+func (s *testSigner) sign(t *testing.T, alg string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": s.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	var err error
+	switch k := s.priv.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(input))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwksDocument(signers ...*testSigner) []byte {
+	keys := make([]map[string]string, 0, len(signers))
+	for _, s := range signers {
+		keys = append(keys, s.jwk())
+	}
+	doc, _ := json.Marshal(map[string]any{"keys": keys})
+	return doc
+}
+
+func validClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":   "https://idp.example.com",
+		"aud":   []string{"other", "verus-gateway"},
+		"sub":   "svc-indexer",
+		"exp":   now.Add(time.Hour).Unix(),
+		"nbf":   now.Add(-time.Minute).Unix(),
+		"scope": "files:read metrics openid",
+	}
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	signers := newTestSigners(t)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, jwksDocument(signers...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewJWTVerifier(JWTConfig{
+		Issuer:    "https://idp.example.com",
+		Audiences: []string{"verus-gateway"},
+		JWKSFile:  file,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() error = %v", err)
+	}
+
+	for _, s := range signers {
+		t.Run(s.alg, func(t *testing.T) {
+			principal, err := v.Verify(context.Background(), s.sign(t, s.alg, validClaims()))
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if principal.Name != "jwt:svc-indexer" {
+				t.Errorf("Name = %q", principal.Name)
+			}
+			if strings.Join(principal.Scopes, " ") != "files:read metrics" {
+				t.Errorf("Scopes = %v, want [files:read metrics]", principal.Scopes)
+			}
+		})
+	}
+
+	rsaSigner, edSigner := signers[0], signers[2]
+	tests := []struct {
+		name   string
+		token  func() string
+		reason string
+	}{
+		{"expired", func() string {
+			c := validClaims()
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+			return rsaSigner.sign(t, AlgRS256, c)
+		}, "expired"},
+		{"missing exp", func() string {
+			c := validClaims()
+			delete(c, "exp")
+			return rsaSigner.sign(t, AlgRS256, c)
+		}, "exp"},
+		{"not yet valid", func() string {
+			c := validClaims()
+			c["nbf"] = time.Now().Add(time.Hour).Unix()
+			return rsaSigner.sign(t, AlgRS256, c)
+		}, "not valid yet"},
+		{"wrong issuer", func() string {
+			c := validClaims()
+			c["iss"] = "https://evil.example.com"
+			return rsaSigner.sign(t, AlgRS256, c)
+		}, "issuer"},
+		{"wrong audience", func() string {
+			c := validClaims()
+			c["aud"] = "other"
+			return rsaSigner.sign(t, AlgRS256, c)
+		}, "audience"},
+		{"missing subject", func() string {
+			c := validClaims()
+			delete(c, "sub")
+			return rsaSigner.sign(t, AlgRS256, c)
+		}, "sub"},
+		{"algorithm not matching key", func() string {
+			return edSigner.sign(t, AlgRS256, validClaims())
+		}, "signature"},
+		{"alg none", func() string {
+			tok := rsaSigner.sign(t, AlgRS256, validClaims())
+			parts := strings.Split(tok, ".")
+			header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`))
+			return header + "." + parts[1] + "."
+		}, "signature"},
+		{"tampered claims", func() string {
+			tok := rsaSigner.sign(t, AlgRS256, validClaims())
+			c := validClaims()
+			c["scope"] = "admin:cache"
+			payload, _ := json.Marshal(c)
+			parts := strings.Split(tok, ".")
+			return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+		}, "signature"},
+		{"unknown key", func() string {
+			other := &testSigner{kid: "gone", priv: rsaSigner.priv}
+			return other.sign(t, AlgRS256, validClaims())
+		}, "unknown signing key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token())
+			if !errors.Is(err, ErrInvalidJWT) || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_JWKSURL(t *testing.T) {
+	signers := newTestSigners(t)
+	rsaSigner, ecSigner := signers[0], signers[1]
+
+	// The identity provider starts out publishing only the RSA key
+	var published atomic.Value
+	published.Store(jwksDocument(rsaSigner))
+	var fetches atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(published.Load().([]byte))
+	}))
+	defer idp.Close()
+
+	v, err := NewJWTVerifier(JWTConfig{
+		Issuer:     "https://idp.example.com",
+		Audiences:  []string{"verus-gateway"},
+		JWKSURL:    idp.URL,
+		ScopeClaim: "roles",
+		ScopeMap:   map[string][]string{"Gateway-Admin": {ScopeAdminCache, ScopeAdminChains}},
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() error = %v", err)
+	}
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	claims := validClaims()
+	claims["roles"] = []string{"gateway-admin", "files:read"}
+	principal, err := v.Verify(context.Background(), rsaSigner.sign(t, AlgRS256, claims))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if strings.Join(principal.Scopes, " ") != "admin:cache admin:chains" {
+		t.Errorf("Scopes = %v, want mapped admin scopes only", principal.Scopes)
+	}
+
+	// Cached keys are reused
+	if _, err := v.Verify(context.Background(), rsaSigner.sign(t, AlgRS256, claims)); err != nil {
+		t.Fatal(err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+
+	// A rotated-in key is fetched once the refresh interval has passed
+	published.Store(jwksDocument(rsaSigner, ecSigner))
+	if _, err := v.Verify(context.Background(), ecSigner.sign(t, AlgES256, claims)); err == nil {
+		t.Error("Verify() accepted an unknown key before the refresh interval")
+	}
+	now = now.Add(time.Minute)
+	if _, err := v.Verify(context.Background(), ecSigner.sign(t, AlgES256, claims)); err != nil {
+		t.Errorf("Verify() after rotation error = %v", err)
+	}
+
+	// Keys stay usable while the identity provider is down
+	idp.Close()
+	now = now.Add(time.Hour)
+	claims["exp"] = now.Add(time.Hour).Unix()
+	if _, err := v.Verify(context.Background(), rsaSigner.sign(t, AlgRS256, claims)); err != nil {
+		t.Errorf("Verify() with unreachable JWKS error = %v", err)
+	}
+}
+
+func TestJWTVerifier_JWKSURLUnavailable(t *testing.T) {
+	signer := newTestSigners(t)[0]
+
+	// The identity provider is down when the first token arrives
+	var up atomic.Bool
+	var fetches atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if !up.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(jwksDocument(signer))
+	}))
+	defer idp.Close()
+
+	v, err := NewJWTVerifier(JWTConfig{
+		Issuer:    "https://idp.example.com",
+		Audiences: []string{"verus-gateway"},
+		JWKSURL:   idp.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() error = %v", err)
+	}
+	now := time.Now()
+	v.now = func() time.Time { return now }
+	token := signer.sign(t, AlgRS256, validClaims())
+
+	// Failures are cached until the refresh interval has passed
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(context.Background(), token); err == nil || !strings.Contains(err.Error(), "JWKS") {
+			t.Errorf("Verify() error = %v, want the JWKS load error", err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+
+	up.Store(true)
+	now = now.Add(time.Minute)
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Errorf("Verify() after recovery error = %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("fetches = %d, want 2", n)
+	}
+}
+
+func TestNewJWTVerifier_Invalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	os.WriteFile(file, []byte(`{"keys":[{"kty":"RSA","kid":"small","n":"AQAB","e":"AQAB"}]}`), 0o600)
+
+	tests := map[string]JWTConfig{
+		"no issuer":     {Audiences: []string{"a"}, JWKSURL: "https://idp.example.com/jwks"},
+		"no audience":   {Issuer: "i", JWKSURL: "https://idp.example.com/jwks"},
+		"no key source": {Issuer: "i", Audiences: []string{"a"}},
+		"missing file":  {Issuer: "i", Audiences: []string{"a"}, JWKSFile: "/nonexistent/jwks.json"},
+		"weak RSA key":  {Issuer: "i", Audiences: []string{"a"}, JWKSFile: file},
+		"unknown scope": {Issuer: "i", Audiences: []string{"a"}, JWKSURL: "https://idp.example.com/jwks", ScopeMap: map[string][]string{"admin": {"admin:all"}}},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewJWTVerifier(cfg); err == nil {
+				t.Error("NewJWTVerifier() succeeded, want error")
+			}
+		})
+	}
+}
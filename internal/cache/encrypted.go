@@ -0,0 +1,295 @@
+package cache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// envelopeMagic prefixes every sealed entry. The header that follows is the
+// key ID length (1 byte), the key ID and the GCM nonce.
+var envelopeMagic = []byte("VGE1")
+
+// errUnsealable is returned for entries the key ring can't decrypt
+var errUnsealable = errors.New("cache entry cannot be decrypted")
+
+// KeyRing holds the AES-256 keys used to seal cache entries. New entries are
+// sealed with the primary key; the others only decrypt entries written
+// before a rotation.
+type KeyRing struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// LoadKeyRing builds a key ring from a key file and/or an inline key list
+// (typically set through VERUS_GATEWAY_CACHE_ENCRYPTION_KEYS). Keys from the
+// file come first, so its first key is the primary one if both are given.
+func LoadKeyRing(keyFile, keys string) (*KeyRing, error) {
+	var data string
+	if keyFile != "" {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache key file: %w", err)
+		}
+		data = string(content)
+	}
+	if keys != "" {
+		data += "\n" + keys
+	}
+	return ParseKeyRing(data)
+}
+
+// ParseKeyRing parses keys in the form "id:base64key", separated by newlines
+// or commas. Each key must decode to 32 bytes. The first key is the primary
+// one. Blank lines and lines starting with # are ignored.
+func ParseKeyRing(data string) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]cipher.AEAD)}
+
+	for _, line := range strings.FieldsFunc(data, func(r rune) bool { return r == '\n' || r == ',' }) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(line, ":")
+		if !ok || id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("invalid cache key entry: expected id:base64key")
+		}
+		if _, exists := ring.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate cache key id %q", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("cache key %q is not valid base64: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("cache key %q must be 32 bytes, got %d", id, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("cache key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("cache key %q: %w", id, err)
+		}
+
+		ring.keys[id] = aead
+		if ring.primary == "" {
+			ring.primary = id
+		}
+	}
+
+	if ring.primary == "" {
+		return nil, fmt.Errorf("no cache encryption keys configured")
+	}
+
+	return ring, nil
+}
+
+// Primary returns the ID of the key new entries are sealed with
+func (k *KeyRing) Primary() string {
+	return k.primary
+}
+
+// seal encrypts plaintext with the primary key, binding it to aad
+func (k *KeyRing) seal(plaintext, aad []byte) ([]byte, error) {
+	aead := k.keys[k.primary]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := make([]byte, 0, len(envelopeMagic)+1+len(k.primary)+len(nonce))
+	header = append(header, envelopeMagic...)
+	header = append(header, byte(len(k.primary)))
+	header = append(header, k.primary...)
+	header = append(header, nonce...)
+
+	return aead.Seal(header, nonce, plaintext, aad), nil
+}
+
+// open decrypts an envelope produced by seal with whichever key it names
+func (k *KeyRing) open(envelope, aad []byte) ([]byte, error) {
+	if len(envelope) < len(envelopeMagic)+1 || string(envelope[:len(envelopeMagic)]) != string(envelopeMagic) {
+		return nil, errUnsealable
+	}
+	rest := envelope[len(envelopeMagic):]
+
+	idLen := int(rest[0])
+	rest = rest[1:]
+	if len(rest) < idLen {
+		return nil, errUnsealable
+	}
+	aead, ok := k.keys[string(rest[:idLen])]
+	if !ok {
+		return nil, errUnsealable
+	}
+	rest = rest[idLen:]
+
+	if len(rest) < aead.NonceSize() {
+		return nil, errUnsealable
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, errUnsealable
+	}
+	return plaintext, nil
+}
+
+// sealedHeader holds the fields of a file stored inside the envelope
+// alongside its content, so nothing about the file is readable at rest
+type sealedHeader struct {
+	ContentEncoding string               `json:"content_encoding,omitempty"`
+	Metadata        *domain.FileMetadata `json:"metadata,omitempty"`
+}
+
+// EncryptedCache wraps a cache and seals entries with AES-256-GCM before
+// they reach it. The cache key is authenticated with each entry, so entries
+// can't be swapped between keys. Entries the key ring can't decrypt (unknown
+// key ID, tampering, or written before encryption was enabled) are treated as
+// misses and removed.
+type EncryptedCache struct {
+	inner domain.Cache
+	ring  *KeyRing
+
+	// unsealable counts inner hits reported to callers as misses
+	unsealable atomic.Int64
+}
+
+// NewEncryptedCache wraps inner with an encryption layer using ring
+func NewEncryptedCache(inner domain.Cache, ring *KeyRing) *EncryptedCache {
+	return &EncryptedCache{inner: inner, ring: ring}
+}
+
+// Get retrieves and decrypts a file from cache
+func (c *EncryptedCache) Get(ctx context.Context, key string) (*domain.File, error) {
+	sealed, err := c.inner.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := c.unseal(key, sealed)
+	if err != nil {
+		c.unsealable.Add(1)
+		_ = c.inner.Delete(ctx, key)
+		return nil, domain.ErrCacheMiss
+	}
+
+	return file, nil
+}
+
+// Set encrypts and stores a file in cache
+func (c *EncryptedCache) Set(ctx context.Context, key string, file *domain.File, ttl time.Duration) error {
+	header, err := json.Marshal(sealedHeader{
+		ContentEncoding: file.ContentEncoding,
+		Metadata:        file.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	plaintext := make([]byte, 4, 4+len(header)+len(file.Content))
+	binary.BigEndian.PutUint32(plaintext, uint32(len(header)))
+	plaintext = append(plaintext, header...)
+	plaintext = append(plaintext, file.Content...)
+
+	envelope, err := c.ring.seal(plaintext, []byte(key))
+	if err != nil {
+		return err
+	}
+
+	return c.inner.Set(ctx, key, &domain.File{
+		TXID:    file.TXID,
+		ChainID: file.ChainID,
+		Content: envelope,
+	}, ttl)
+}
+
+// unseal reverses Set
+func (c *EncryptedCache) unseal(key string, sealed *domain.File) (*domain.File, error) {
+	plaintext, err := c.ring.open(sealed.Content, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plaintext) < 4 {
+		return nil, errUnsealable
+	}
+	headerLen := binary.BigEndian.Uint32(plaintext)
+	if uint64(len(plaintext)-4) < uint64(headerLen) {
+		return nil, errUnsealable
+	}
+
+	var header sealedHeader
+	if err := json.Unmarshal(plaintext[4:4+headerLen], &header); err != nil {
+		return nil, errUnsealable
+	}
+
+	return &domain.File{
+		TXID:            sealed.TXID,
+		ChainID:         sealed.ChainID,
+		Content:         plaintext[4+headerLen:],
+		ContentEncoding: header.ContentEncoding,
+		Metadata:        header.Metadata,
+		RetrievedAt:     sealed.RetrievedAt,
+	}, nil
+}
+
+// Delete removes a file from cache
+func (c *EncryptedCache) Delete(ctx context.Context, key string) error {
+	return c.inner.Delete(ctx, key)
+}
+
+// Clear removes all files from cache
+func (c *EncryptedCache) Clear(ctx context.Context) error {
+	return c.inner.Clear(ctx)
+}
+
+// Stats returns the inner cache statistics, counting entries that could not
+// be decrypted as misses
+func (c *EncryptedCache) Stats(ctx context.Context) (*domain.CacheStats, error) {
+	stats, err := c.inner.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unsealable := c.unsealable.Load()
+	stats.Hits -= unsealable
+	stats.Misses += unsealable
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	return stats, nil
+}
+
+// PurgeLegacy passes through to the inner cache if it supports purging
+func (c *EncryptedCache) PurgeLegacy(ctx context.Context) (int, error) {
+	if purger, ok := c.inner.(domain.LegacyPurger); ok {
+		return purger.PurgeLegacy(ctx)
+	}
+	return 0, nil
+}
+
+// Close closes the inner cache
+func (c *EncryptedCache) Close() error {
+	return c.inner.Close()
+}
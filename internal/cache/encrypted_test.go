@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func newTestEncryptedCache(t *testing.T, dir, keys string) *EncryptedCache {
+	t.Helper()
+
+	ring, err := ParseKeyRing(keys)
+	if err != nil {
+		t.Fatalf("ParseKeyRing() error = %v", err)
+	}
+	inner, err := NewFilesystemCache(FilesystemCacheConfig{
+		BaseDir: dir,
+		MaxSize: 1024 * 1024,
+		TTL:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { inner.Close() })
+
+	return NewEncryptedCache(inner, ring)
+}
+
+func TestEncryptedCache_SetAndGet(t *testing.T) {
+	dir := t.TempDir()
+	cache := newTestEncryptedCache(t, dir, "k1:"+testKey(1))
+	ctx := context.Background()
+
+	file := &domain.File{
+		Content:         []byte("private plaintext"),
+		ContentEncoding: "gzip",
+		Metadata:        &domain.FileMetadata{Filename: "secret.txt", ContentType: "text/plain", Encrypted: true},
+	}
+	if err := cache.Set(ctx, "vrsc:txid:evk=0123", file, time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, err := cache.Get(ctx, "vrsc:txid:evk=0123")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(got.Content) != "private plaintext" || got.ContentEncoding != "gzip" {
+		t.Errorf("Get() = %q (%s), want original content", got.Content, got.ContentEncoding)
+	}
+	if got.Metadata == nil || got.Metadata.Filename != "secret.txt" || !got.Metadata.Encrypted {
+		t.Errorf("Get() metadata = %+v, want original metadata", got.Metadata)
+	}
+
+	// Nothing readable reaches the disk
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		data, _ := os.ReadFile(path)
+		if bytes.Contains(data, []byte("private plaintext")) || bytes.Contains(data, []byte("secret.txt")) {
+			t.Errorf("%s contains plaintext", path)
+		}
+		return nil
+	})
+}
+
+func TestEncryptedCache_KeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	old := newTestEncryptedCache(t, dir, "k1:"+testKey(1))
+	if err := old.Set(ctx, "vrsc:a", &domain.File{Content: []byte("written with k1")}, time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// k2 becomes primary, k1 is kept for decryption
+	rotated := newTestEncryptedCache(t, dir, "k2:"+testKey(2)+",k1:"+testKey(1))
+	if got, err := rotated.Get(ctx, "vrsc:a"); err != nil || string(got.Content) != "written with k1" {
+		t.Fatalf("Get() after rotation = %v, %v; want entry decrypted with k1", got, err)
+	}
+	if err := rotated.Set(ctx, "vrsc:b", &domain.File{Content: []byte("written with k2")}, time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// A ring without k2 can't read the new entry
+	if _, err := old.Get(ctx, "vrsc:b"); err != domain.ErrCacheMiss {
+		t.Errorf("Get() with retired ring error = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestEncryptedCache_UndecryptableIsMiss(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	cache := newTestEncryptedCache(t, dir, "k1:"+testKey(1))
+
+	// Plaintext entry written before encryption was enabled
+	cache.inner.Set(ctx, "vrsc:plain", &domain.File{Content: []byte("legacy")}, time.Hour)
+
+	// Entry moved to another key fails authentication
+	cache.Set(ctx, "vrsc:a", &domain.File{Content: []byte("data")}, time.Hour)
+	sealed, _ := cache.inner.Get(ctx, "vrsc:a")
+	cache.inner.Set(ctx, "vrsc:b", sealed, time.Hour)
+
+	// Entry sealed with an unknown key
+	other := newTestEncryptedCache(t, t.TempDir(), "k9:"+testKey(9))
+	other.Set(ctx, "vrsc:c", &domain.File{Content: []byte("data")}, time.Hour)
+	foreign, _ := other.inner.Get(ctx, "vrsc:c")
+	cache.inner.Set(ctx, "vrsc:c", foreign, time.Hour)
+
+	for _, key := range []string{"vrsc:plain", "vrsc:b", "vrsc:c"} {
+		if _, err := cache.Get(ctx, key); err != domain.ErrCacheMiss {
+			t.Errorf("Get(%q) error = %v, want ErrCacheMiss", key, err)
+		}
+		if _, err := cache.inner.Get(ctx, key); err != domain.ErrCacheMiss {
+			t.Errorf("undecryptable entry %q was not removed", key)
+		}
+	}
+
+	stats, _ := cache.Stats(ctx)
+	if stats.Misses < 3 {
+		t.Errorf("Misses = %d, want undecryptable entries counted as misses", stats.Misses)
+	}
+}
+
+func TestParseKeyRing(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		primary string
+		wantErr string
+	}{
+		{"single key", "k1:" + testKey(1), "k1", ""},
+		{"file with comments", "# current\nk2:" + testKey(2) + "\n\n# retired\nk1:" + testKey(1) + "\n", "k2", ""},
+		{"comma separated", "k2:" + testKey(2) + ", k1:" + testKey(1), "k2", ""},
+		{"empty", "  \n# nothing\n", "", "no cache encryption keys"},
+		{"missing id", testKey(1), "", "expected id:base64key"},
+		{"bad base64", "k1:not-base64!", "", "not valid base64"},
+		{"short key", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "", "must be 32 bytes"},
+		{"duplicate id", "k1:" + testKey(1) + ",k1:" + testKey(2), "", "duplicate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := ParseKeyRing(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseKeyRing() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKeyRing() error = %v", err)
+			}
+			if ring.Primary() != tt.primary {
+				t.Errorf("Primary() = %q, want %q", ring.Primary(), tt.primary)
+			}
+		})
+	}
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "cache.keys")
+	if err := os.WriteFile(keyFile, []byte("k2:"+testKey(2)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ring, err := LoadKeyRing(keyFile, "k1:"+testKey(1))
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+	if ring.Primary() != "k2" || len(ring.keys) != 2 {
+		t.Errorf("LoadKeyRing() primary = %q with %d keys, want k2 with 2", ring.Primary(), len(ring.keys))
+	}
+
+	if _, err := LoadKeyRing(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("LoadKeyRing() expected error for missing key file")
+	}
+}
@@ -21,6 +21,7 @@ type FilesystemCache struct {
 	maxSize         int64
 	ttl             time.Duration
 	cleanupInterval time.Duration
+	onEvict         func(reason string, items int, bytes int64)
 
 	// Metrics
 	hits   atomic.Uint64
@@ -40,6 +41,20 @@ type FilesystemCacheConfig struct {
 	MaxSize         int64
 	TTL             time.Duration
 	CleanupInterval time.Duration
+
+	// OnEvict, if set, is called after entries are evicted to make room
+	// ("size") or removed by the cleanup loop ("expired"). It runs with the
+	// cache locked and must not block or call back into the cache.
+	OnEvict func(reason string, items int, bytes int64)
+}
+
+// metaEntry is the structure stored in the .meta file next to the content.
+// FileMetadata is embedded so entries written before ContentEncoding existed
+// still decode. Key is empty for entries written before keys were recorded.
+type metaEntry struct {
+	*domain.FileMetadata
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	Key             string `json:"key,omitempty"`
 }
 
 // NewFilesystemCache creates a new filesystem cache
@@ -65,6 +80,7 @@ func NewFilesystemCache(cfg FilesystemCacheConfig) (*FilesystemCache, error) {
 		maxSize:         cfg.MaxSize,
 		ttl:             cfg.TTL,
 		cleanupInterval: cfg.CleanupInterval,
+		onEvict:         cfg.OnEvict,
 		stopCleanup:     make(chan struct{}),
 	}
 
@@ -121,19 +137,20 @@ func (c *FilesystemCache) Get(ctx context.Context, key string) (*domain.File, er
 	}
 
 	// Read metadata
-	var metadata domain.FileMetadata
+	meta := metaEntry{FileMetadata: &domain.FileMetadata{}}
 	metaBytes, err := os.ReadFile(metaPath)
 	if err == nil {
 		// Metadata is optional, ignore errors
-		_ = json.Unmarshal(metaBytes, &metadata)
+		_ = json.Unmarshal(metaBytes, &meta)
 	}
 
 	c.hits.Add(1)
 
 	file := &domain.File{
-		Content:     content,
-		Metadata:    &metadata,
-		RetrievedAt: time.Now(),
+		Content:         content,
+		ContentEncoding: meta.ContentEncoding,
+		Metadata:        meta.FileMetadata,
+		RetrievedAt:     time.Now(),
 	}
 
 	return file, nil
@@ -173,12 +190,14 @@ func (c *FilesystemCache) Set(ctx context.Context, key string, file *domain.File
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
 
-	// Write metadata
-	if file.Metadata != nil {
-		metaBytes, err := json.Marshal(file.Metadata)
-		if err == nil {
-			_ = os.WriteFile(metaPath, metaBytes, 0644)
-		}
+	// Write metadata, always recording the key so entries can be identified
+	metaBytes, err := json.Marshal(metaEntry{
+		FileMetadata:    file.Metadata,
+		ContentEncoding: file.ContentEncoding,
+		Key:             key,
+	})
+	if err == nil {
+		_ = os.WriteFile(metaPath, metaBytes, 0644)
 	}
 
 	// Update metrics
@@ -242,6 +261,52 @@ func (c *FilesystemCache) Clear(ctx context.Context) error {
 	return nil
 }
 
+// PurgeLegacy removes entries written before cache keys were recorded in
+// the metadata. Filenames are key hashes and older versions never marked
+// decrypted entries as encrypted, so legacy encrypted entries can't be told
+// apart from public ones; every entry without a recorded key is removed.
+// Public content is fetched again on the next request.
+func (c *FilesystemCache) PurgeLegacy(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var removed int
+	err := filepath.Walk(c.baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if filepath.Ext(path) != ".bin" {
+			return nil
+		}
+
+		metaPath := path[:len(path)-4] + ".meta"
+		meta := metaEntry{FileMetadata: &domain.FileMetadata{}}
+		if metaBytes, err := os.ReadFile(metaPath); err == nil {
+			if json.Unmarshal(metaBytes, &meta) == nil && meta.Key != "" {
+				return nil
+			}
+		}
+
+		_ = os.Remove(path)
+		_ = os.Remove(metaPath)
+
+		removed++
+		c.size.Add(-info.Size())
+		c.items.Add(-1)
+
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to purge legacy entries: %w", err)
+	}
+
+	return removed, nil
+}
+
 // Stats returns cache statistics
 func (c *FilesystemCache) Stats(ctx context.Context) (*domain.CacheStats, error) {
 	hits := c.hits.Load()
@@ -345,6 +410,7 @@ func (c *FilesystemCache) evictOldest(neededSize int64) error {
 
 	// Evict until we have enough space
 	var freedSize int64
+	var evicted int
 	for _, f := range files {
 		if freedSize >= neededSize {
 			break
@@ -355,10 +421,13 @@ func (c *FilesystemCache) evictOldest(neededSize int64) error {
 		_ = os.Remove(f.path[:len(f.path)-4] + ".meta")
 
 		freedSize += f.size
+		evicted++
 		c.size.Add(-f.size)
 		c.items.Add(-1)
 	}
 
+	c.notifyEvict("size", evicted, freedSize)
+
 	return nil
 }
 
@@ -385,6 +454,8 @@ func (c *FilesystemCache) cleanup() {
 	defer c.mu.Unlock()
 
 	now := time.Now()
+	var removed int
+	var removedSize int64
 
 	_ = filepath.Walk(c.baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() {
@@ -402,10 +473,21 @@ func (c *FilesystemCache) cleanup() {
 			_ = os.Remove(path)
 			_ = os.Remove(path[:len(path)-4] + ".meta")
 
+			removed++
+			removedSize += size
 			c.size.Add(-size)
 			c.items.Add(-1)
 		}
 
 		return nil
 	})
+
+	c.notifyEvict("expired", removed, removedSize)
+}
+
+// notifyEvict reports removed entries to the OnEvict callback
+func (c *FilesystemCache) notifyEvict(reason string, items int, bytes int64) {
+	if c.onEvict != nil && items > 0 {
+		c.onEvict(reason, items, bytes)
+	}
 }
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -80,6 +81,44 @@ func TestFilesystemCache_SetAndGet(t *testing.T) {
 	}
 }
 
+func TestFilesystemCache_ContentEncoding(t *testing.T) {
+	cache, err := NewFilesystemCache(FilesystemCacheConfig{
+		BaseDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	file := &domain.File{
+		Content:         []byte{0x1f, 0x8b, 0x08},
+		ContentEncoding: "gzip",
+		Metadata: &domain.FileMetadata{
+			Filename:   "test.txt",
+			Size:       100,
+			Compressed: true,
+		},
+	}
+
+	if err := cache.Set(ctx, "gzip-key", file, 1*time.Hour); err != nil {
+		t.Fatalf("failed to set cache: %v", err)
+	}
+
+	cached, err := cache.Get(ctx, "gzip-key")
+	if err != nil {
+		t.Fatalf("failed to get cache: %v", err)
+	}
+
+	if cached.ContentEncoding != "gzip" {
+		t.Errorf("expected content encoding 'gzip', got %q", cached.ContentEncoding)
+	}
+	if cached.Metadata.Size != 100 || !cached.Metadata.Compressed {
+		t.Errorf("metadata not preserved: %+v", cached.Metadata)
+	}
+}
+
 func TestFilesystemCache_Miss(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -295,6 +334,109 @@ func TestFilesystemCache_Eviction(t *testing.T) {
 	}
 }
 
+func TestFilesystemCache_OnEvict(t *testing.T) {
+	type eviction struct {
+		reason string
+		items  int
+	}
+	var evictions []eviction
+
+	cache, err := NewFilesystemCache(FilesystemCacheConfig{
+		BaseDir: t.TempDir(),
+		MaxSize: 50,
+		TTL:     10 * time.Millisecond,
+		OnEvict: func(reason string, items int, bytes int64) {
+			evictions = append(evictions, eviction{reason, items})
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", &domain.File{Content: []byte("small")}, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	cache.Set(ctx, "key2", &domain.File{Content: []byte("large enough to need the space of the first entry")}, time.Hour)
+
+	if len(evictions) != 1 || evictions[0] != (eviction{"size", 1}) {
+		t.Fatalf("expected one size eviction, got %v", evictions)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	cache.cleanup()
+
+	if len(evictions) != 2 || evictions[1] != (eviction{"expired", 1}) {
+		t.Errorf("expected an expired eviction, got %v", evictions)
+	}
+}
+
+func TestFilesystemCache_PurgeLegacy(t *testing.T) {
+	cache, err := NewFilesystemCache(FilesystemCacheConfig{
+		BaseDir: t.TempDir(),
+		MaxSize: 1024 * 1024,
+		TTL:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	// Entries written by this version record their key and survive
+	encrypted := &domain.File{Content: []byte("secret"), Metadata: &domain.FileMetadata{Encrypted: true}}
+	cache.Set(ctx, "vrsc:txid:evk=0123", encrypted, time.Hour)
+	cache.Set(ctx, "vrsc:public", &domain.File{Content: []byte("public")}, time.Hour)
+
+	// Simulate entries written before keys were recorded. Older versions
+	// stored the plain FileMetadata (json.Marshal(file.Metadata)), and
+	// decrypted files were not marked as encrypted.
+	legacy := func(key string, metadata *domain.FileMetadata) {
+		contentPath, metaPath := cache.getPaths(key)
+		os.MkdirAll(filepath.Dir(contentPath), 0755)
+		os.WriteFile(contentPath, []byte("legacy"), 0644)
+		if metadata != nil {
+			metaBytes, _ := json.Marshal(metadata)
+			os.WriteFile(metaPath, metaBytes, 0644)
+		}
+	}
+	legacy("vrsc:old:encrypted", &domain.FileMetadata{Size: 6, ContentType: "text/plain", Extension: "txt"})
+	legacy("vrsc:old-nometa:encrypted", nil)
+	legacy("vrsc:old-public", &domain.FileMetadata{Size: 6, ContentType: "text/plain", Extension: "txt"})
+	cache.calculateSize()
+
+	removed, err := cache.PurgeLegacy(ctx)
+	if err != nil {
+		t.Fatalf("PurgeLegacy() error = %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("PurgeLegacy() removed %d entries, want 3", removed)
+	}
+
+	for _, key := range []string{"vrsc:txid:evk=0123", "vrsc:public"} {
+		if _, err := cache.Get(ctx, key); err != nil {
+			t.Errorf("Get(%q) error = %v, want entry kept", key, err)
+		}
+	}
+	for _, key := range []string{"vrsc:old:encrypted", "vrsc:old-nometa:encrypted", "vrsc:old-public"} {
+		if _, err := cache.Get(ctx, key); err != domain.ErrCacheMiss {
+			t.Errorf("Get(%q) error = %v, want legacy entry purged", key, err)
+		}
+	}
+
+	stats, _ := cache.Stats(ctx)
+	if stats.Items != 2 {
+		t.Errorf("Items = %d after purge, want 2", stats.Items)
+	}
+
+	// A second run finds nothing left to purge
+	if removed, _ := cache.PurgeLegacy(ctx); removed != 0 {
+		t.Errorf("second PurgeLegacy() removed %d entries, want 0", removed)
+	}
+}
+
 func TestFilesystemCache_ContextCancellation(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -81,9 +81,10 @@ func NewRedisCache(cfg RedisCacheConfig) (*RedisCache, error) {
 
 // cacheEntry is the structure stored in Redis
 type cacheEntry struct {
-	Content     []byte               `json:"content"`
-	Metadata    *domain.FileMetadata `json:"metadata,omitempty"`
-	RetrievedAt time.Time            `json:"retrieved_at"`
+	Content         []byte               `json:"content"`
+	ContentEncoding string               `json:"content_encoding,omitempty"`
+	Metadata        *domain.FileMetadata `json:"metadata,omitempty"`
+	RetrievedAt     time.Time            `json:"retrieved_at"`
 }
 
 // Get retrieves a file from cache
@@ -107,9 +108,10 @@ func (c *RedisCache) Get(ctx context.Context, key string) (*domain.File, error)
 	c.hits.Add(1)
 
 	return &domain.File{
-		Content:     entry.Content,
-		Metadata:    entry.Metadata,
-		RetrievedAt: entry.RetrievedAt,
+		Content:         entry.Content,
+		ContentEncoding: entry.ContentEncoding,
+		Metadata:        entry.Metadata,
+		RetrievedAt:     entry.RetrievedAt,
 	}, nil
 }
 
@@ -120,9 +122,10 @@ func (c *RedisCache) Set(ctx context.Context, key string, file *domain.File, ttl
 	}
 
 	entry := cacheEntry{
-		Content:     file.Content,
-		Metadata:    file.Metadata,
-		RetrievedAt: time.Now(),
+		Content:         file.Content,
+		ContentEncoding: file.ContentEncoding,
+		Metadata:        file.Metadata,
+		RetrievedAt:     time.Now(),
 	}
 
 	data, err := json.Marshal(entry)
@@ -153,6 +156,23 @@ func (c *RedisCache) Clear(ctx context.Context) error {
 	return nil
 }
 
+// PurgeLegacy removes encrypted entries stored under the old
+// "chain:txid:encrypted" key scheme
+func (c *RedisCache) PurgeLegacy(ctx context.Context) (int, error) {
+	var removed int
+	iter := c.client.Scan(ctx, 0, "*:encrypted*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return removed, fmt.Errorf("redis del failed: %w", err)
+		}
+		removed++
+	}
+	if err := iter.Err(); err != nil {
+		return removed, fmt.Errorf("redis scan failed: %w", err)
+	}
+	return removed, nil
+}
+
 // Stats returns cache statistics
 func (c *RedisCache) Stats(ctx context.Context) (*domain.CacheStats, error) {
 	// Get Redis info (not currently parsing detailed stats)
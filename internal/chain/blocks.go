@@ -0,0 +1,215 @@
+package chain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// Defaults for reorg detection when a chain does not configure them
+const (
+	defaultReorgCheckInterval = 30 * time.Second
+	defaultReorgDepth         = 100
+)
+
+// BlockRef identifies a block by height and hash
+type BlockRef struct {
+	Height int64
+	Hash   string
+}
+
+// BlockEvent describes a change of a chain's tip
+type BlockEvent struct {
+	ChainID string
+
+	// Tip is the new best block
+	Tip BlockRef
+
+	// Orphaned lists tracked blocks that are no longer on the best chain,
+	// highest first (empty unless a reorg happened)
+	Orphaned []BlockRef
+}
+
+// BlockListener is notified of tip changes detected by SyncBlocks
+type BlockListener func(event BlockEvent)
+
+// blockWindow tracks the hashes of a chain's most recent blocks
+type blockWindow struct {
+	syncMu sync.Mutex // Serializes SyncBlocks for the chain
+
+	mu     sync.RWMutex
+	hashes map[int64]string
+	tip    int64
+}
+
+// ReorgDepth returns how many recent blocks are tracked for the chain
+func (c *Chain) ReorgDepth() int {
+	if c.Config.ReorgDepth > 0 {
+		return c.Config.ReorgDepth
+	}
+	return defaultReorgDepth
+}
+
+// reorgCheckInterval returns how often the chain tip is polled
+func (c *Chain) reorgCheckInterval() time.Duration {
+	if c.Config.ReorgCheckInterval > 0 {
+		return c.Config.ReorgCheckInterval
+	}
+	return defaultReorgCheckInterval
+}
+
+// OnBlock registers a listener for tip changes and reorgs on any chain
+func (m *Manager) OnBlock(listener BlockListener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// SyncBlocks fetches a chain's tip and compares the hashes of its recent
+// blocks with the tracked ones, walking back from the tip until they agree.
+// Tracked blocks replaced by different hashes, or above a lower tip, are
+// reported as orphaned. Listeners are notified when the tip changed; the
+// returned event is nil otherwise.
+func (m *Manager) SyncBlocks(ctx context.Context, chainID string) (*BlockEvent, error) {
+	chain, err := m.chainOrDefault(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := &chain.blocks
+	window.syncMu.Lock()
+	defer window.syncMu.Unlock()
+
+	tip, err := chain.Client.GetBlockCount(ctx)
+	if err != nil {
+		return nil, domain.NewRPCError("getblockcount", err)
+	}
+	chain.setTip(tip)
+
+	lowest := max(tip-int64(chain.ReorgDepth())+1, 0)
+
+	window.mu.RLock()
+	tracked := len(window.hashes) > 0
+	var orphaned []BlockRef
+	for height := window.tip; tracked && height > tip; height-- {
+		if hash, ok := window.hashes[height]; ok {
+			orphaned = append(orphaned, BlockRef{Height: height, Hash: hash})
+		}
+	}
+	window.mu.RUnlock()
+
+	fetched := make(map[int64]string)
+	for height := tip; height >= lowest; height-- {
+		hash, err := chain.Client.GetBlockHash(ctx, height)
+		if err != nil {
+			return nil, domain.NewRPCError("getblockhash", err)
+		}
+
+		window.mu.RLock()
+		known, ok := window.hashes[height]
+		window.mu.RUnlock()
+
+		if ok && known == hash {
+			// Everything below is unchanged
+			break
+		}
+		if ok {
+			orphaned = append(orphaned, BlockRef{Height: height, Hash: known})
+		}
+		fetched[height] = hash
+	}
+
+	if len(fetched) == 0 && len(orphaned) == 0 {
+		return nil, nil
+	}
+
+	window.mu.Lock()
+	if window.hashes == nil {
+		window.hashes = make(map[int64]string)
+	}
+	for height := range window.hashes {
+		if height > tip || height < lowest {
+			delete(window.hashes, height)
+		}
+	}
+	for height, hash := range fetched {
+		window.hashes[height] = hash
+	}
+	window.tip = tip
+	event := BlockEvent{
+		ChainID:  chain.ID,
+		Tip:      BlockRef{Height: tip, Hash: window.hashes[tip]},
+		Orphaned: orphaned,
+	}
+	window.mu.Unlock()
+
+	if len(orphaned) > 0 {
+		m.logger.Warn().
+			Str("chain", chain.ID).
+			Int("orphaned", len(orphaned)).
+			Int64("tip", tip).
+			Str("hash", event.Tip.Hash).
+			Msg("Reorg detected")
+	}
+
+	m.mu.RLock()
+	listeners := append([]BlockListener(nil), m.listeners...)
+	m.mu.RUnlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+
+	return &event, nil
+}
+
+// BlockOrphaned reports whether a block is known to be off the best chain,
+// i.e. a different block is tracked at its height. Blocks outside the
+// tracked window are assumed to be on chain.
+func (m *Manager) BlockOrphaned(chainID string, height int64, hash string) bool {
+	chain, err := m.chainOrDefault(chainID)
+	if err != nil {
+		return false
+	}
+
+	chain.blocks.mu.RLock()
+	defer chain.blocks.mu.RUnlock()
+
+	known, ok := chain.blocks.hashes[height]
+	return ok && known != hash
+}
+
+// WatchBlocks polls every chain's tip at its reorg check interval until ctx
+// is done, notifying listeners of tip changes, reorgs and health changes
+func (m *Manager) WatchBlocks(ctx context.Context) {
+	m.mu.RLock()
+	chains := make([]*Chain, 0, len(m.chains))
+	for _, chain := range m.chains {
+		chains = append(chains, chain)
+	}
+	m.mu.RUnlock()
+
+	for _, chain := range chains {
+		go func(chain *Chain) {
+			ticker := time.NewTicker(chain.reorgCheckInterval())
+			defer ticker.Stop()
+
+			for {
+				_, err := m.SyncBlocks(ctx, chain.ID)
+				if ctx.Err() == nil {
+					if err != nil {
+						m.logger.Warn().Err(err).Str("chain", chain.ID).Msg("Failed to sync blocks")
+					}
+					m.recordHealth(chain.ID, err)
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(chain)
+	}
+}
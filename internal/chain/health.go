@@ -0,0 +1,61 @@
+package chain
+
+import (
+	"sync"
+)
+
+// HealthEvent describes a chain becoming healthy or unhealthy
+type HealthEvent struct {
+	ChainID string
+	Healthy bool
+
+	// Err is the failure that made the chain unhealthy
+	Err error
+}
+
+// HealthListener is notified when a chain's health changes
+type HealthListener func(event HealthEvent)
+
+// chainHealth is the last observed health of a chain
+type chainHealth struct {
+	mu      sync.Mutex
+	known   bool
+	healthy bool
+}
+
+// OnHealth registers a listener for chain health transitions. Health is
+// observed by HealthCheck and by the block watcher.
+func (m *Manager) OnHealth(listener HealthListener) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.healthListeners = append(m.healthListeners, listener)
+}
+
+// recordHealth updates a chain's health from the result of an RPC call and
+// notifies listeners if it changed (including the first observation)
+func (m *Manager) recordHealth(chainID string, err error) {
+	m.mu.RLock()
+	chain, ok := m.chains[chainID]
+	listeners := append([]HealthListener(nil), m.healthListeners...)
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	healthy := err == nil
+
+	chain.health.mu.Lock()
+	changed := !chain.health.known || chain.health.healthy != healthy
+	chain.health.known = true
+	chain.health.healthy = healthy
+	chain.health.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	event := HealthEvent{ChainID: chainID, Healthy: healthy, Err: err}
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
 
 	"github.com/devdudeio/verus-gateway/internal/config"
 	"github.com/devdudeio/verus-gateway/internal/domain"
@@ -12,9 +15,12 @@ import (
 
 // Manager manages multiple blockchain connections
 type Manager struct {
-	chains       map[string]*Chain
-	defaultChain string
-	mu           sync.RWMutex
+	chains          map[string]*Chain
+	defaultChain    string
+	listeners       []BlockListener
+	healthListeners []HealthListener
+	logger          *zerolog.Logger
+	mu              sync.RWMutex
 }
 
 // Chain represents a configured blockchain with its RPC client
@@ -22,14 +28,36 @@ type Chain struct {
 	ID     string
 	Name   string
 	Config config.ChainConfig
+	Limits config.LimitsConfig // Effective limits (global merged with chain overrides)
 	Client *verusrpc.Client
+
+	// Cached chain tip, refreshed at most every tipCacheTTL
+	tipMu      sync.Mutex
+	tipHeight  int64
+	tipFetched time.Time
+
+	// Recent block hashes for reorg detection
+	blocks blockWindow
+
+	// Last observed health, for transition events
+	health chainHealth
 }
 
+// rpcResponseOverhead is the allowance for JSON-RPC framing around the
+// hex-encoded payload when deriving the RPC response size limit
+const rpcResponseOverhead = 64 * 1024
+
+// tipCacheTTL is how long a chain's block height is reused before it is
+// fetched again
+const tipCacheTTL = 10 * time.Second
+
 // NewManager creates a new chain manager
 func NewManager(cfg *config.Config) (*Manager, error) {
+	nop := zerolog.Nop()
 	manager := &Manager{
 		chains:       make(map[string]*Chain),
 		defaultChain: cfg.Chains.Default,
+		logger:       &nop,
 	}
 
 	// Initialize all configured chains
@@ -38,20 +66,31 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 			continue
 		}
 
+		limits := cfg.Limits.Merge(chainCfg.Limits)
+
+		// decryptdata returns the payload hex-encoded, so the response is
+		// roughly twice the raw size
+		var maxResponseSize int64
+		if limits.MaxRawSize > 0 {
+			maxResponseSize = 2*limits.MaxRawSize + rpcResponseOverhead
+		}
+
 		client := verusrpc.NewClient(verusrpc.Config{
-			URL:         chainCfg.RPCURL,
-			User:        chainCfg.RPCUser,
-			Password:    chainCfg.RPCPassword,
-			Timeout:     chainCfg.RPCTimeout,
-			TLSInsecure: chainCfg.TLSInsecure,
-			MaxRetries:  chainCfg.MaxRetries,
-			RetryDelay:  chainCfg.RetryDelay,
+			URL:             chainCfg.RPCURL,
+			User:            chainCfg.RPCUser,
+			Password:        chainCfg.RPCPassword,
+			Timeout:         chainCfg.RPCTimeout,
+			TLSInsecure:     chainCfg.TLSInsecure,
+			MaxRetries:      chainCfg.MaxRetries,
+			RetryDelay:      chainCfg.RetryDelay,
+			MaxResponseSize: maxResponseSize,
 		})
 
 		chain := &Chain{
 			ID:     id,
 			Name:   chainCfg.Name,
 			Config: chainCfg,
+			Limits: limits,
 			Client: client,
 		}
 
@@ -78,6 +117,12 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 	return manager, nil
 }
 
+// SetLogger sets the logger for reorg and block sync warnings (default:
+// discarded)
+func (m *Manager) SetLogger(logger *zerolog.Logger) {
+	m.logger = logger
+}
+
 // GetChain returns the RPC client for a specific chain
 func (m *Manager) GetChain(chainID string) (*verusrpc.Client, error) {
 	m.mu.RLock()
@@ -109,6 +154,84 @@ func (m *Manager) GetChainInfo(chainID string) (*Chain, error) {
 	return chain, nil
 }
 
+// TransactionBlock returns the block containing a transaction. A transaction
+// that is still in the mempool yields a BlockInfo without a hash and with
+// zero confirmations. An empty chainID selects the default chain.
+func (m *Manager) TransactionBlock(ctx context.Context, chainID, txid string) (*domain.BlockInfo, error) {
+	chain, err := m.chainOrDefault(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := chain.Client.GetRawTransaction(ctx, txid)
+	if err != nil {
+		return nil, domain.NewRPCError("getrawtransaction", err)
+	}
+	if tx.BlockHash == "" {
+		return &domain.BlockInfo{}, nil
+	}
+
+	header, err := chain.Client.GetBlockHeader(ctx, tx.BlockHash)
+	if err != nil {
+		return nil, domain.NewRPCError("getblockheader", err)
+	}
+	if header.Confirmations < 1 {
+		// The block is no longer on the best chain
+		return &domain.BlockInfo{}, nil
+	}
+
+	chain.setTip(header.Height + header.Confirmations - 1)
+
+	return &domain.BlockInfo{
+		Height:        header.Height,
+		Hash:          header.Hash,
+		Time:          time.Unix(header.Time, 0).UTC(),
+		Confirmations: header.Confirmations,
+	}, nil
+}
+
+// BlockHeight returns the current block height of a chain. The height is
+// cached briefly so per-request confirmation counts stay cheap.
+func (m *Manager) BlockHeight(ctx context.Context, chainID string) (int64, error) {
+	chain, err := m.chainOrDefault(chainID)
+	if err != nil {
+		return 0, err
+	}
+
+	chain.tipMu.Lock()
+	if !chain.tipFetched.IsZero() && time.Since(chain.tipFetched) < tipCacheTTL {
+		height := chain.tipHeight
+		chain.tipMu.Unlock()
+		return height, nil
+	}
+	chain.tipMu.Unlock()
+
+	height, err := chain.Client.GetBlockCount(ctx)
+	if err != nil {
+		return 0, domain.NewRPCError("getblockcount", err)
+	}
+	chain.setTip(height)
+
+	return height, nil
+}
+
+// setTip records the chain's current block height
+func (c *Chain) setTip(height int64) {
+	c.tipMu.Lock()
+	defer c.tipMu.Unlock()
+	c.tipHeight = height
+	c.tipFetched = time.Now()
+}
+
+// chainOrDefault returns the chain with the given ID, or the default chain
+// if the ID is empty
+func (m *Manager) chainOrDefault(chainID string) (*Chain, error) {
+	if chainID == "" {
+		chainID = m.GetDefaultChainID()
+	}
+	return m.GetChainInfo(chainID)
+}
+
 // ListChains returns all configured chain IDs
 func (m *Manager) ListChains() []string {
 	m.mu.RLock()
@@ -132,10 +255,11 @@ func (m *Manager) HealthCheck(ctx context.Context, chainID string) error {
 	// Try to get chain info
 	_, err = client.GetInfo(ctx)
 	if err != nil {
-		return domain.NewChainError(chainID, fmt.Sprintf("health check failed: %v", err))
+		err = domain.NewChainError(chainID, fmt.Sprintf("health check failed: %v", err))
 	}
+	m.recordHealth(chainID, err)
 
-	return nil
+	return err
 }
 
 // HealthCheckAll checks health of all chains
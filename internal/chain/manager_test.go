@@ -1,12 +1,65 @@
 package chain
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/rs/zerolog"
+
 	"github.com/devdudeio/verus-gateway/internal/config"
 )
 
+// newRPCTestManager creates a manager for chain "test" whose RPC server
+// answers each call with the JSON result returned by respond
+func newRPCTestManager(t *testing.T, respond func(method string, params []json.RawMessage) string) *Manager {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, respond(req.Method, req.Params))
+	}))
+	t.Cleanup(server.Close)
+
+	manager, err := NewManager(&config.Config{
+		Chains: config.ChainsConfig{
+			Default: "test",
+			Chains: map[string]config.ChainConfig{
+				"test": {
+					Name:        "Test",
+					RPCURL:      server.URL,
+					RPCUser:     "user",
+					RPCPassword: "pass",
+					RPCTimeout:  5 * time.Second,
+					MaxRetries:  1,
+					RetryDelay:  time.Millisecond,
+					Enabled:     true,
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Close() })
+
+	return manager
+}
+
 func TestNewManager_Success(t *testing.T) {
 	cfg := &config.Config{
 		Chains: config.ChainsConfig{
@@ -50,6 +103,52 @@ func TestNewManager_Success(t *testing.T) {
 	}
 }
 
+func TestNewManager_Limits(t *testing.T) {
+	cfg := &config.Config{
+		Limits: config.LimitsConfig{MaxRawSize: 1000, MaxDecompressedSize: 2000},
+		Chains: config.ChainsConfig{
+			Chains: map[string]config.ChainConfig{
+				"inherits": {
+					Name:        "Inherits",
+					RPCURL:      "http://localhost:27486",
+					RPCUser:     "user",
+					RPCPassword: "pass",
+					RPCTimeout:  30 * time.Second,
+					Enabled:     true,
+				},
+				"overrides": {
+					Name:        "Overrides",
+					RPCURL:      "http://localhost:27487",
+					RPCUser:     "user",
+					RPCPassword: "pass",
+					RPCTimeout:  30 * time.Second,
+					Enabled:     true,
+					Limits:      config.LimitsConfig{MaxRawSize: 500},
+				},
+			},
+		},
+	}
+
+	manager, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	want := map[string]config.LimitsConfig{
+		"inherits":  {MaxRawSize: 1000, MaxDecompressedSize: 2000},
+		"overrides": {MaxRawSize: 500, MaxDecompressedSize: 2000},
+	}
+	for id, limits := range want {
+		chain, err := manager.GetChainInfo(id)
+		if err != nil {
+			t.Fatalf("GetChainInfo(%s) failed: %v", id, err)
+		}
+		if chain.Limits != limits {
+			t.Errorf("chain %s limits = %+v, want %+v", id, chain.Limits, limits)
+		}
+	}
+}
+
 func TestNewManager_SkipsDisabledChains(t *testing.T) {
 	cfg := &config.Config{
 		Chains: config.ChainsConfig{
@@ -412,3 +511,191 @@ func TestManager_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestTransactionBlock(t *testing.T) {
+	tests := []struct {
+		name          string
+		tx            string
+		wantConfirmed bool
+		wantHeight    int64
+	}{
+		{
+			name:          "confirmed",
+			tx:            `{"txid":"aa","blockhash":"00ab","confirmations":3}`,
+			wantConfirmed: true,
+			wantHeight:    500,
+		},
+		{
+			name: "in mempool",
+			tx:   `{"txid":"aa","confirmations":0}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newRPCTestManager(t, func(method string, params []json.RawMessage) string {
+				switch method {
+				case "getrawtransaction":
+					return tt.tx
+				case "getblockheader":
+					return `{"hash":"00ab","height":500,"confirmations":3,"time":1700000000}`
+				}
+				t.Errorf("unexpected method %s", method)
+				return "null"
+			})
+
+			block, err := manager.TransactionBlock(context.Background(), "", "aa")
+			if err != nil {
+				t.Fatalf("TransactionBlock() error = %v", err)
+			}
+			if block.Confirmed() != tt.wantConfirmed || block.Height != tt.wantHeight {
+				t.Errorf("TransactionBlock() = %+v", block)
+			}
+			if tt.wantConfirmed && !block.Time.Equal(time.Unix(1700000000, 0)) {
+				t.Errorf("block time = %v", block.Time)
+			}
+		})
+	}
+}
+
+func TestBlockHeight_Cached(t *testing.T) {
+	var calls atomic.Int32
+	manager := newRPCTestManager(t, func(method string, params []json.RawMessage) string {
+		calls.Add(1)
+		return "1000"
+	})
+
+	for i := 0; i < 3; i++ {
+		height, err := manager.BlockHeight(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("BlockHeight() error = %v", err)
+		}
+		if height != 1000 {
+			t.Errorf("BlockHeight() = %d, want 1000", height)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("getblockcount called %d times, want 1", got)
+	}
+}
+
+func TestSyncBlocks_DetectsReorg(t *testing.T) {
+	var mu sync.Mutex
+	hashes := []string{"a0", "a1", "a2", "a3", "a4", "a5"}
+
+	manager := newRPCTestManager(t, func(method string, params []json.RawMessage) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch method {
+		case "getblockcount":
+			return fmt.Sprintf("%d", len(hashes)-1)
+		case "getblockhash":
+			var height int
+			_ = json.Unmarshal(params[0], &height)
+			return fmt.Sprintf("%q", hashes[height])
+		}
+		return "null"
+	})
+
+	var events []BlockEvent
+	manager.OnBlock(func(event BlockEvent) { events = append(events, event) })
+
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+	manager.SetLogger(&logger)
+
+	syncBlocks := func() *BlockEvent {
+		t.Helper()
+		event, err := manager.SyncBlocks(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("SyncBlocks() error = %v", err)
+		}
+		return event
+	}
+
+	// Initial sync tracks the window without orphans
+	if event := syncBlocks(); event == nil || event.Tip != (BlockRef{Height: 5, Hash: "a5"}) || len(event.Orphaned) != 0 {
+		t.Fatalf("initial event = %+v", event)
+	}
+
+	// Unchanged tip
+	if event := syncBlocks(); event != nil {
+		t.Errorf("unchanged tip event = %+v, want nil", event)
+	}
+
+	// Blocks 4 and 5 are replaced by a longer fork
+	mu.Lock()
+	hashes = []string{"a0", "a1", "a2", "a3", "b4", "b5", "b6"}
+	mu.Unlock()
+
+	event := syncBlocks()
+	wantOrphaned := []BlockRef{{Height: 5, Hash: "a5"}, {Height: 4, Hash: "a4"}}
+	if event == nil || event.Tip != (BlockRef{Height: 6, Hash: "b6"}) || !reflect.DeepEqual(event.Orphaned, wantOrphaned) {
+		t.Fatalf("reorg event = %+v, want tip b6 and orphaned %v", event, wantOrphaned)
+	}
+	if !bytes.Contains(logs.Bytes(), []byte(`"level":"warn","chain":"test","orphaned":2,"tip":6`)) {
+		t.Errorf("reorg not logged: %s", logs.String())
+	}
+
+	if !manager.BlockOrphaned("test", 4, "a4") {
+		t.Error("BlockOrphaned(a4) = false, want true")
+	}
+	if manager.BlockOrphaned("test", 4, "b4") || manager.BlockOrphaned("test", 3, "a3") {
+		t.Error("BlockOrphaned() = true for a block on the best chain")
+	}
+
+	// A fork that is shorter than the tracked chain orphans the blocks above it
+	mu.Lock()
+	hashes = []string{"a0", "a1", "a2", "a3", "b4", "c5"}
+	mu.Unlock()
+
+	event = syncBlocks()
+	wantOrphaned = []BlockRef{{Height: 6, Hash: "b6"}, {Height: 5, Hash: "b5"}}
+	if event == nil || !reflect.DeepEqual(event.Orphaned, wantOrphaned) {
+		t.Fatalf("shorter fork event = %+v, want orphaned %v", event, wantOrphaned)
+	}
+
+	if len(events) != 3 {
+		t.Errorf("listener notified %d times, want 3", len(events))
+	}
+}
+
+func TestHealthCheck_Transitions(t *testing.T) {
+	var failing atomic.Bool
+
+	manager := newRPCTestManager(t, func(method string, params []json.RawMessage) string {
+		if failing.Load() {
+			return `"not an object"`
+		}
+		return `{"version":1,"blocks":10}`
+	})
+
+	var events []HealthEvent
+	manager.OnHealth(func(event HealthEvent) { events = append(events, event) })
+
+	ctx := context.Background()
+	check := func() { _ = manager.HealthCheck(ctx, "test") }
+
+	check() // First observation
+	check() // Unchanged
+	failing.Store(true)
+	check() // Became unhealthy
+	check() // Unchanged
+	failing.Store(false)
+	check() // Recovered
+
+	want := []bool{true, false, true}
+	if len(events) != len(want) {
+		t.Fatalf("got %d health events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, event := range events {
+		if event.ChainID != "test" || event.Healthy != want[i] {
+			t.Errorf("event %d = %+v, want healthy=%v", i, event, want[i])
+		}
+		if !event.Healthy && event.Err == nil {
+			t.Errorf("event %d: unhealthy without error", i)
+		}
+	}
+}
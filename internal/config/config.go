@@ -1,11 +1,16 @@
 package config
 
 import (
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"net/netip"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
 )
 
 // Config holds all configuration for the application
@@ -13,8 +18,15 @@ type Config struct {
 	Server        ServerConfig        `mapstructure:"server"`
 	Chains        ChainsConfig        `mapstructure:"chains"`
 	Cache         CacheConfig         `mapstructure:"cache"`
+	Limits        LimitsConfig        `mapstructure:"limits"`
+	Sites         SitesConfig         `mapstructure:"sites"`
+	HTTPCache     HTTPCacheConfig     `mapstructure:"http_cache"`
+	Sharing       SharingConfig       `mapstructure:"sharing"`
+	Vault         VaultConfig         `mapstructure:"vault"`
+	Detection     DetectionConfig     `mapstructure:"detection"`
 	Security      SecurityConfig      `mapstructure:"security"`
 	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`
+	Events        EventsConfig        `mapstructure:"events"`
 	Observability ObservabilityConfig `mapstructure:"observability"`
 }
 
@@ -27,6 +39,181 @@ type ServerConfig struct {
 	IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 	MaxRequestSize  int64         `mapstructure:"max_request_size"`
+
+	// TLS serves HTTPS natively instead of behind a terminating proxy
+	TLS TLSConfig `mapstructure:"tls"`
+
+	// Admin is a separate listener for /admin that requires client
+	// certificates
+	Admin AdminListenerConfig `mapstructure:"admin"`
+
+	// Internal is the plain HTTP listener for /metrics, /admin,
+	// /debug/pprof and health checks. These are never served on the public
+	// listener; with Internal disabled, only the admin listener serves them.
+	Internal InternalListenerConfig `mapstructure:"internal"`
+}
+
+// InternalListenerConfig holds the internal listener configuration
+type InternalListenerConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host"`
+	Port    int    `mapstructure:"port"`
+}
+
+// TLSConfig holds TLS configuration. Certificates are reloaded when their
+// files change.
+type TLSConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	CertFile     string   `mapstructure:"cert_file"`
+	KeyFile      string   `mapstructure:"key_file"`
+	MinVersion   string   `mapstructure:"min_version"`   // "1.2" or "1.3"
+	CipherSuites []string `mapstructure:"cipher_suites"` // TLS 1.2 suites (empty = Go defaults)
+}
+
+// AdminListenerConfig holds the mutual TLS admin listener configuration
+type AdminListenerConfig struct {
+	Enabled      bool                `mapstructure:"enabled"`
+	Host         string              `mapstructure:"host"`
+	Port         int                 `mapstructure:"port"`
+	CertFile     string              `mapstructure:"cert_file"`      // Empty = server.tls certificate
+	KeyFile      string              `mapstructure:"key_file"`       // Empty = server.tls key
+	ClientCAFile string              `mapstructure:"client_ca_file"` // CA client certificates must chain to
+	Clients      []AdminClientConfig `mapstructure:"clients"`
+}
+
+// AdminClientConfig maps a client certificate subject to scopes
+type AdminClientConfig struct {
+	Subject string   `mapstructure:"subject"` // e.g. "CN=ops,O=Example" (RFC 2253)
+	Scopes  []string `mapstructure:"scopes"`  // e.g. admin:cache, admin:chains
+}
+
+// tlsVersions maps min_version values to TLS versions
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Version returns the minimum TLS version
+func (t TLSConfig) Version() (uint16, error) {
+	if t.MinVersion == "" {
+		return tls.VersionTLS12, nil
+	}
+	version, ok := tlsVersions[t.MinVersion]
+	if !ok {
+		return 0, fmt.Errorf("tls min_version must be 1.2 or 1.3, got %q", t.MinVersion)
+	}
+	return version, nil
+}
+
+// Ciphers returns the IDs of the configured cipher suites. Only suites Go
+// considers secure are accepted.
+func (t TLSConfig) Ciphers() ([]uint16, error) {
+	if len(t.CipherSuites) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint16, 0, len(t.CipherSuites))
+	for _, name := range t.CipherSuites {
+		id, ok := secureCipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("tls cipher suite %q is unknown or insecure", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// validateTLS validates TLS and the admin listener
+func (s ServerConfig) validateTLS() error {
+	if s.TLS.Enabled && (s.TLS.CertFile == "" || s.TLS.KeyFile == "") {
+		return fmt.Errorf("server tls requires cert_file and key_file")
+	}
+	version, err := s.TLS.Version()
+	if err != nil {
+		return err
+	}
+	if _, err := s.TLS.Ciphers(); err != nil {
+		return err
+	}
+	if version == tls.VersionTLS13 && len(s.TLS.CipherSuites) > 0 {
+		return fmt.Errorf("tls cipher_suites only apply to TLS 1.2; TLS 1.3 suites are not configurable")
+	}
+
+	admin := s.Admin
+	if !admin.Enabled {
+		return nil
+	}
+	if admin.Port < 1 || admin.Port > 65535 || admin.Port == s.Port {
+		return fmt.Errorf("invalid admin port: %d (must differ from the server port)", admin.Port)
+	}
+	if (admin.CertFile == "") != (admin.KeyFile == "") {
+		return fmt.Errorf("admin listener requires both cert_file and key_file")
+	}
+	if admin.CertFile == "" && !s.TLS.Enabled {
+		return fmt.Errorf("admin listener requires a certificate (admin cert_file or server tls)")
+	}
+	if admin.ClientCAFile == "" {
+		return fmt.Errorf("admin listener requires a client_ca_file")
+	}
+	if len(admin.Clients) == 0 {
+		return fmt.Errorf("admin listener requires at least one client")
+	}
+	subjects := make(map[string]bool)
+	for i, client := range admin.Clients {
+		if client.Subject == "" || subjects[client.Subject] {
+			return fmt.Errorf("admin client %d: subject must be set and unique", i)
+		}
+		subjects[client.Subject] = true
+		if len(client.Scopes) == 0 {
+			return fmt.Errorf("admin client %s: at least one scope is required", client.Subject)
+		}
+		for _, scope := range client.Scopes {
+			if !auth.ValidScope(scope) {
+				return fmt.Errorf("admin client %s: unknown scope %q (valid: %s)", client.Subject, scope, strings.Join(auth.Scopes, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// validateInternalListener validates the internal listener and the metrics
+// path it serves
+func (c *Config) validateInternalListener() error {
+	internal := c.Server.Internal
+	if internal.Enabled {
+		if internal.Port < 1 || internal.Port > 65535 || internal.Port == c.Server.Port {
+			return fmt.Errorf("invalid internal port: %d (must differ from the server port)", internal.Port)
+		}
+		if c.Server.Admin.Enabled && internal.Port == c.Server.Admin.Port {
+			return fmt.Errorf("internal port %d is already used by the admin listener", internal.Port)
+		}
+	}
+
+	metrics := c.Observability.Metrics
+	if !metrics.Enabled {
+		return nil
+	}
+	if !internal.Enabled && !c.Server.Admin.Enabled {
+		return fmt.Errorf("metrics require server.internal or server.admin to be enabled")
+	}
+	if !strings.HasPrefix(metrics.Path, "/") {
+		return fmt.Errorf("metrics path must start with /")
+	}
+	for _, reserved := range []string{"/admin", "/debug", "/health", "/ready", "/c", "/chains", "/events"} {
+		if metrics.Path == reserved || strings.HasPrefix(metrics.Path, reserved+"/") {
+			return fmt.Errorf("metrics path %s conflicts with %s", metrics.Path, reserved)
+		}
+	}
+	return nil
+}
+
+// secureCipherSuite looks up a secure cipher suite by name
+func secureCipherSuite(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
 }
 
 // ChainsConfig holds blockchain configuration
@@ -46,6 +233,188 @@ type ChainConfig struct {
 	TLSInsecure bool          `mapstructure:"tls_insecure"`
 	MaxRetries  int           `mapstructure:"max_retries"`
 	RetryDelay  time.Duration `mapstructure:"retry_delay"`
+	Limits      LimitsConfig  `mapstructure:"limits"` // Overrides global limits (0 = inherit)
+
+	// Confirmation policy: content with fewer than MinConfirmations is
+	// rejected with UnconfirmedStatus, or served uncached (0 = no minimum)
+	MinConfirmations  int64  `mapstructure:"min_confirmations"`
+	UnconfirmedPolicy string `mapstructure:"unconfirmed_policy"` // reject (default) or serve
+	UnconfirmedStatus int    `mapstructure:"unconfirmed_status"` // 425 (default) or 409
+
+	// Reorg detection: the chain tip is polled every ReorgCheckInterval and
+	// the hashes of the last ReorgDepth blocks are compared (0 = defaults)
+	ReorgCheckInterval time.Duration `mapstructure:"reorg_check_interval"`
+	ReorgDepth         int           `mapstructure:"reorg_depth"`
+}
+
+// Unconfirmed content policies
+const (
+	UnconfirmedReject = "reject"
+	UnconfirmedServe  = "serve"
+)
+
+// LimitsConfig holds file size limits
+type LimitsConfig struct {
+	MaxRawSize          int64 `mapstructure:"max_raw_size"`          // On-chain payload size in bytes
+	MaxDecompressedSize int64 `mapstructure:"max_decompressed_size"` // Decompressed size in bytes
+	MaxImagePixels      int64 `mapstructure:"max_image_pixels"`      // Largest image (width x height) decoded for transforms
+}
+
+// Merge returns the limits with any non-zero values from override applied
+func (l LimitsConfig) Merge(override LimitsConfig) LimitsConfig {
+	if override.MaxRawSize > 0 {
+		l.MaxRawSize = override.MaxRawSize
+	}
+	if override.MaxDecompressedSize > 0 {
+		l.MaxDecompressedSize = override.MaxDecompressedSize
+	}
+	if override.MaxImagePixels > 0 {
+		l.MaxImagePixels = override.MaxImagePixels
+	}
+	return l
+}
+
+// Validate validates the limits
+func (l LimitsConfig) Validate() error {
+	if l.MaxRawSize < 0 {
+		return fmt.Errorf("max_raw_size must not be negative")
+	}
+	if l.MaxDecompressedSize < 0 {
+		return fmt.Errorf("max_decompressed_size must not be negative")
+	}
+	if l.MaxImagePixels < 0 {
+		return fmt.Errorf("max_image_pixels must not be negative")
+	}
+	return nil
+}
+
+// SitesConfig holds configuration for static sites served from archives
+type SitesConfig struct {
+	IndexFile   string `mapstructure:"index_file"`   // Served for directory paths
+	SPAFallback bool   `mapstructure:"spa_fallback"` // Serve the root index for unknown paths
+	CSP         string `mapstructure:"csp"`          // Content-Security-Policy for site responses
+}
+
+// HTTPCacheConfig holds the Cache-Control policy for served content.
+// Content below a chain's minimum confirmations is always sent with no-store.
+type HTTPCacheConfig struct {
+	Public    string          `mapstructure:"public"`    // Content served without a viewing key
+	Encrypted string          `mapstructure:"encrypted"` // Content decrypted with a viewing key
+	Rules     []HTTPCacheRule `mapstructure:"rules"`     // Overrides, first match wins
+}
+
+// HTTPCacheRule overrides the policy for matching chains and content types
+type HTTPCacheRule struct {
+	Chains       []string `mapstructure:"chains"`        // Empty = all chains
+	ContentTypes []string `mapstructure:"content_types"` // e.g. "text/html" or "image/*"; empty = all
+	Public       string   `mapstructure:"public"`        // Empty = next matching rule or default
+	Encrypted    string   `mapstructure:"encrypted"`     // Empty = next matching rule or default
+}
+
+// SharingConfig holds configuration for signed share links to encrypted files
+type SharingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Secret seals viewing keys in tokens and signs them (min 32 chars).
+	// Changing it invalidates all issued links.
+	Secret string `mapstructure:"secret"`
+
+	DefaultTTL time.Duration `mapstructure:"default_ttl"` // Lifetime of links issued without one
+	MaxTTL     time.Duration `mapstructure:"max_ttl"`     // Longest lifetime a link can have
+
+	// StateFile persists revocations and download counts (empty = memory
+	// only, lost on restart)
+	StateFile string `mapstructure:"state_file"`
+
+	// BaseURL prefixes returned links, e.g. https://gateway.example.com
+	// (empty = derived from the request)
+	BaseURL string `mapstructure:"base_url"`
+}
+
+// VaultConfig holds configuration for the server-side viewing key vault
+type VaultConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Secret seals the stored viewing keys (min 32 chars). Keys stored under
+	// one secret can't be read with another.
+	Secret string `mapstructure:"secret"`
+
+	// File holds the sealed keys
+	File string `mapstructure:"file"`
+
+	// Clients may use stored keys by alias (?key=<alias>)
+	Clients []VaultClientConfig `mapstructure:"clients"`
+}
+
+// VaultClientConfig grants an API key the use of vault aliases
+type VaultClientConfig struct {
+	Name    string   `mapstructure:"name"`     // Shown in the audit log; a security.api_keys name authenticates the client
+	KeyHash string   `mapstructure:"key_hash"` // SHA-256 hash of a key sent as X-API-Key or a Bearer token (optional with a matching security.api_keys entry)
+	Aliases []string `mapstructure:"aliases"`  // "*" = all aliases
+}
+
+// DetectionConfig holds file type detection configuration
+type DetectionConfig struct {
+	Signatures []SignatureConfig `mapstructure:"signatures"` // Checked before the built-in signatures
+}
+
+// SignatureConfig describes a custom file signature
+type SignatureConfig struct {
+	MIME      string                   `mapstructure:"mime"`
+	Extension string                   `mapstructure:"extension"` // Without dot
+	Parent    string                   `mapstructure:"parent"`    // Only refine content generically detected as this type
+	Patterns  []SignaturePatternConfig `mapstructure:"patterns"`  // All must match
+}
+
+// SignaturePatternConfig is a hex-encoded byte pattern at an offset
+type SignaturePatternConfig struct {
+	Offset int    `mapstructure:"offset"`
+	Magic  string `mapstructure:"magic"` // Hex bytes, e.g. "89 50 4e 47"
+	Mask   string `mapstructure:"mask"`  // Optional hex mask, same length as magic
+}
+
+// Decode returns the pattern's magic and mask bytes
+func (p SignaturePatternConfig) Decode() (magic, mask []byte, err error) {
+	magic, err = hex.DecodeString(strings.ReplaceAll(p.Magic, " ", ""))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid magic %q: %w", p.Magic, err)
+	}
+	if len(magic) == 0 {
+		return nil, nil, fmt.Errorf("magic is required")
+	}
+
+	if p.Mask != "" {
+		mask, err = hex.DecodeString(strings.ReplaceAll(p.Mask, " ", ""))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid mask %q: %w", p.Mask, err)
+		}
+		if len(mask) != len(magic) {
+			return nil, nil, fmt.Errorf("mask must be the same length as magic")
+		}
+	}
+
+	return magic, mask, nil
+}
+
+// Validate validates custom signatures
+func (dc *DetectionConfig) Validate() error {
+	for i, sig := range dc.Signatures {
+		if sig.MIME == "" {
+			return fmt.Errorf("signature %d: mime is required", i)
+		}
+		if len(sig.Patterns) == 0 {
+			return fmt.Errorf("signature %d (%s): at least one pattern is required", i, sig.MIME)
+		}
+		for _, p := range sig.Patterns {
+			if p.Offset < 0 {
+				return fmt.Errorf("signature %d (%s): offset must be non-negative", i, sig.MIME)
+			}
+			if _, _, err := p.Decode(); err != nil {
+				return fmt.Errorf("signature %d (%s): %w", i, sig.MIME, err)
+			}
+		}
+	}
+	return nil
 }
 
 // CacheConfig holds cache configuration
@@ -57,6 +426,26 @@ type CacheConfig struct {
 	CleanupInterval time.Duration        `mapstructure:"cleanup_interval"`
 	Redis           RedisCacheConfig     `mapstructure:"redis"`
 	Memcached       MemcachedCacheConfig `mapstructure:"memcached"`
+
+	// KeySecret keys the HMAC of viewing keys in cache keys of encrypted
+	// files (empty = random per process, so encrypted entries do not
+	// survive restarts or get shared between instances)
+	KeySecret string `mapstructure:"key_secret"`
+
+	// Encryption seals cached entries at rest
+	Encryption CacheEncryptionConfig `mapstructure:"encryption"`
+}
+
+// CacheEncryptionConfig holds encryption-at-rest configuration for the cache.
+// Keys are "id:base64key" entries (32-byte AES keys), one per line in KeyFile
+// or comma-separated in Keys; the first key encrypts new entries and the rest
+// only decrypt entries written before a rotation.
+type CacheEncryptionConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	KeyFile string `mapstructure:"key_file"`
+
+	// Keys is usually set through VERUS_GATEWAY_CACHE_ENCRYPTION_KEYS
+	Keys string `mapstructure:"keys"`
 }
 
 // RedisCacheConfig holds Redis cache configuration
@@ -80,7 +469,46 @@ type SecurityConfig struct {
 	CORS           CORSConfig `mapstructure:"cors"`
 	MaxFilenameLen int        `mapstructure:"max_filename_length"`
 	AllowedMethods []string   `mapstructure:"allowed_methods"`
-	TrustedProxies []string   `mapstructure:"trusted_proxies"`
+	TrustedProxies []string   `mapstructure:"trusted_proxies"` // Proxies whose X-Forwarded-For/X-Real-IP are used (IPs or CIDRs)
+
+	// BlockNotifyToken authenticates POST /admin/chains/{chain}/blocknotify
+	// as a Bearer token (empty = endpoint disabled)
+	BlockNotifyToken string `mapstructure:"blocknotify_token"`
+
+	// RejectQueryEVK refuses viewing keys passed as ?evk=; clients must use
+	// the X-Verus-EVK header, "Authorization: EVK" or a POST body
+	RejectQueryEVK bool `mapstructure:"reject_query_evk"`
+
+	// APIKeys grant scopes to callers sending X-API-Key or a Bearer token
+	APIKeys []APIKeyConfig `mapstructure:"api_keys"`
+
+	// AnonymousScopes are granted to requests without a recognized key
+	AnonymousScopes []string `mapstructure:"anonymous_scopes"`
+
+	// JWT accepts bearer tokens from an OIDC identity provider
+	JWT JWTConfig `mapstructure:"jwt"`
+}
+
+// JWTConfig holds JWT bearer token configuration
+type JWTConfig struct {
+	Enabled      bool                `mapstructure:"enabled"`
+	Issuer       string              `mapstructure:"issuer"`         // Required iss claim
+	Audiences    []string            `mapstructure:"audiences"`      // aud must contain one of these
+	JWKSFile     string              `mapstructure:"jwks_file"`      // Local JWKS file
+	JWKSURL      string              `mapstructure:"jwks_url"`       // JWKS URL of the identity provider
+	JWKSCacheTTL time.Duration       `mapstructure:"jwks_cache_ttl"` // How long fetched keys are used
+	ScopeClaim   string              `mapstructure:"scope_claim"`    // Claim holding scopes, roles or groups
+	ScopeMap     map[string][]string `mapstructure:"scope_map"`      // Claim values to scopes (empty = values are scopes)
+	NameClaim    string              `mapstructure:"name_claim"`     // Claim shown in request logs
+	Leeway       time.Duration       `mapstructure:"leeway"`         // Clock skew tolerated for exp and nbf
+}
+
+// APIKeyConfig is an API key and the access it grants
+type APIKeyConfig struct {
+	Name    string   `mapstructure:"name"`     // Shown in request logs
+	KeyHash string   `mapstructure:"key_hash"` // "sha256:<hex>", from verus-gateway hash-key
+	Scopes  []string `mapstructure:"scopes"`   // e.g. files:read, admin:cache
+	Chains  []string `mapstructure:"chains"`   // Chains the key may access (empty = all)
 }
 
 // CORSConfig holds CORS configuration
@@ -101,6 +529,13 @@ type RateLimitConfig struct {
 	Burst       int           `mapstructure:"burst"`
 }
 
+// EventsConfig holds configuration for the GET /events stream
+type EventsConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Heartbeat  time.Duration `mapstructure:"heartbeat"`   // Keep-alive comment interval on idle streams
+	BufferSize int           `mapstructure:"buffer_size"` // Events queued per client before dropping
+}
+
 // ObservabilityConfig holds observability configuration
 type ObservabilityConfig struct {
 	Logging LoggingConfig `mapstructure:"logging"`
@@ -116,11 +551,11 @@ type LoggingConfig struct {
 	FilePath string `mapstructure:"file_path"`
 }
 
-// MetricsConfig holds metrics configuration
+// MetricsConfig holds metrics configuration. Metrics are served by the
+// internal and admin listeners (server.internal, server.admin).
 type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
 	Path    string `mapstructure:"path"`
-	Port    int    `mapstructure:"port"`
 }
 
 // TracingConfig holds tracing configuration
@@ -191,6 +626,20 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.idle_timeout", 120*time.Second)
 	v.SetDefault("server.shutdown_timeout", 30*time.Second)
 	v.SetDefault("server.max_request_size", 32*1024*1024) // 32MB
+	v.SetDefault("server.tls.enabled", false)
+	v.SetDefault("server.tls.cert_file", "")
+	v.SetDefault("server.tls.key_file", "")
+	v.SetDefault("server.tls.min_version", "1.2")
+	v.SetDefault("server.tls.cipher_suites", []string{})
+	v.SetDefault("server.internal.enabled", true)
+	v.SetDefault("server.internal.host", "127.0.0.1")
+	v.SetDefault("server.internal.port", 9090)
+	v.SetDefault("server.admin.enabled", false)
+	v.SetDefault("server.admin.host", "127.0.0.1")
+	v.SetDefault("server.admin.port", 9443)
+	v.SetDefault("server.admin.cert_file", "")
+	v.SetDefault("server.admin.key_file", "")
+	v.SetDefault("server.admin.client_ca_file", "")
 
 	// Cache defaults
 	v.SetDefault("cache.type", "filesystem")
@@ -198,6 +647,31 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("cache.max_size", 1024*1024*1024) // 1GB
 	v.SetDefault("cache.ttl", 24*time.Hour)
 	v.SetDefault("cache.cleanup_interval", 1*time.Hour)
+	v.SetDefault("cache.key_secret", "")
+	v.SetDefault("cache.encryption.enabled", false)
+	v.SetDefault("cache.encryption.key_file", "")
+	v.SetDefault("cache.encryption.keys", "")
+
+	// File size limit defaults
+	v.SetDefault("limits.max_raw_size", 100*1024*1024)          // 100MB
+	v.SetDefault("limits.max_decompressed_size", 100*1024*1024) // 100MB
+	v.SetDefault("limits.max_image_pixels", 40_000_000)         // 40 megapixels
+
+	// Static site defaults
+	v.SetDefault("sites.index_file", "index.html")
+	v.SetDefault("sites.spa_fallback", false)
+	v.SetDefault("http_cache.public", "public, max-age=31536000, immutable")
+	v.SetDefault("http_cache.encrypted", "private, no-store")
+	v.SetDefault("sharing.enabled", false)
+	v.SetDefault("sharing.secret", "")
+	v.SetDefault("sharing.default_ttl", 24*time.Hour)
+	v.SetDefault("sharing.max_ttl", 30*24*time.Hour)
+	v.SetDefault("sharing.state_file", "")
+	v.SetDefault("sharing.base_url", "")
+	v.SetDefault("vault.enabled", false)
+	v.SetDefault("vault.secret", "")
+	v.SetDefault("vault.file", "")
+	v.SetDefault("sites.csp", "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; object-src 'none'; base-uri 'self'; frame-ancestors 'self'")
 
 	// Redis defaults
 	v.SetDefault("cache.redis.addresses", []string{"localhost:6379"})
@@ -217,6 +691,18 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("security.cors.allowed_headers", []string{"Content-Type", "Authorization"})
 	v.SetDefault("security.cors.max_age", 3600)
 	v.SetDefault("security.max_filename_length", 255)
+	v.SetDefault("security.trusted_proxies", []string{"127.0.0.1", "::1"})
+	v.SetDefault("security.reject_query_evk", false)
+	v.SetDefault("security.jwt.enabled", false)
+	v.SetDefault("security.jwt.issuer", "")
+	v.SetDefault("security.jwt.audiences", []string{})
+	v.SetDefault("security.jwt.jwks_file", "")
+	v.SetDefault("security.jwt.jwks_url", "")
+	v.SetDefault("security.jwt.jwks_cache_ttl", auth.DefaultJWKSCacheTTL)
+	v.SetDefault("security.jwt.scope_claim", "scope")
+	v.SetDefault("security.jwt.name_claim", "sub")
+	v.SetDefault("security.jwt.leeway", auth.DefaultJWTLeeway)
+	v.SetDefault("security.anonymous_scopes", []string{auth.ScopeFilesRead, auth.ScopeFilesPrivate, auth.ScopeMetrics})
 
 	// Rate limit defaults
 	v.SetDefault("rate_limit.enabled", true)
@@ -224,6 +710,11 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("rate_limit.max_requests", 30)
 	v.SetDefault("rate_limit.burst", 10)
 
+	// Event stream defaults
+	v.SetDefault("events.enabled", false)
+	v.SetDefault("events.heartbeat", 15*time.Second)
+	v.SetDefault("events.buffer_size", 64)
+
 	// Logging defaults
 	v.SetDefault("observability.logging.level", "info")
 	v.SetDefault("observability.logging.format", "json")
@@ -232,7 +723,6 @@ func setDefaults(v *viper.Viper) {
 	// Metrics defaults
 	v.SetDefault("observability.metrics.enabled", true)
 	v.SetDefault("observability.metrics.path", "/metrics")
-	v.SetDefault("observability.metrics.port", 9090)
 
 	// Tracing defaults
 	v.SetDefault("observability.tracing.enabled", false)
@@ -245,6 +735,12 @@ func (c *Config) Validate() error {
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
+	if err := c.Server.validateTLS(); err != nil {
+		return err
+	}
+	if err := c.validateInternalListener(); err != nil {
+		return err
+	}
 
 	// Validate at least one chain is configured
 	if len(c.Chains.Chains) == 0 {
@@ -265,6 +761,29 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate file size limits
+	if err := c.Limits.Validate(); err != nil {
+		return fmt.Errorf("invalid limits: %w", err)
+	}
+
+	// Validate custom file signatures
+	if err := c.Detection.Validate(); err != nil {
+		return fmt.Errorf("invalid detection config: %w", err)
+	}
+
+	// Validate blocknotify token
+	if token := c.Security.BlockNotifyToken; token != "" && len(token) < 16 {
+		return fmt.Errorf("blocknotify_token must be at least 16 characters")
+	}
+
+	// Validate event stream config
+	if c.Events.Heartbeat != 0 && c.Events.Heartbeat < time.Second {
+		return fmt.Errorf("events heartbeat must be at least 1s")
+	}
+	if c.Events.BufferSize < 0 || c.Events.BufferSize > 10000 {
+		return fmt.Errorf("events buffer_size must be between 0 and 10000")
+	}
+
 	// Validate cache config
 	validCacheTypes := map[string]bool{
 		"filesystem": true,
@@ -275,6 +794,122 @@ func (c *Config) Validate() error {
 	if !validCacheTypes[c.Cache.Type] {
 		return fmt.Errorf("invalid cache type: %s", c.Cache.Type)
 	}
+	if secret := c.Cache.KeySecret; secret != "" && len(secret) < 16 {
+		return fmt.Errorf("cache key_secret must be at least 16 characters")
+	}
+	if enc := c.Cache.Encryption; enc.Enabled && enc.KeyFile == "" && enc.Keys == "" {
+		return fmt.Errorf("cache encryption requires key_file or keys")
+	}
+
+	// Validate share links
+	if c.Sharing.Enabled {
+		if len(c.Sharing.Secret) < 32 {
+			return fmt.Errorf("sharing secret must be at least 32 characters")
+		}
+		if c.Sharing.DefaultTTL <= 0 || c.Sharing.MaxTTL < c.Sharing.DefaultTTL {
+			return fmt.Errorf("sharing default_ttl must be positive and not exceed max_ttl")
+		}
+	}
+
+	// Validate API keys and scopes
+	keyNames := make(map[string]bool)
+	for i, key := range c.Security.APIKeys {
+		if key.Name == "" || key.Name == auth.AnonymousName || keyNames[key.Name] {
+			return fmt.Errorf("api key %d: name must be set, unique and not %q", i, auth.AnonymousName)
+		}
+		keyNames[key.Name] = true
+		if err := auth.ValidHash(key.KeyHash); err != nil {
+			return fmt.Errorf("api key %s: %w", key.Name, err)
+		}
+		if len(key.Scopes) == 0 {
+			return fmt.Errorf("api key %s: at least one scope is required", key.Name)
+		}
+		for _, scope := range key.Scopes {
+			if !auth.ValidScope(scope) {
+				return fmt.Errorf("api key %s: unknown scope %q (valid: %s)", key.Name, scope, strings.Join(auth.Scopes, ", "))
+			}
+		}
+	}
+	for _, scope := range c.Security.AnonymousScopes {
+		if !auth.ValidScope(scope) {
+			return fmt.Errorf("anonymous_scopes: unknown scope %q (valid: %s)", scope, strings.Join(auth.Scopes, ", "))
+		}
+	}
+
+	for _, proxy := range c.Security.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				return fmt.Errorf("trusted_proxies: invalid address or range %q", proxy)
+			}
+		}
+	}
+
+	// Validate JWT configuration
+	if jwt := c.Security.JWT; jwt.Enabled {
+		if jwt.Issuer == "" || len(jwt.Audiences) == 0 {
+			return fmt.Errorf("jwt requires an issuer and at least one audience")
+		}
+		if (jwt.JWKSFile == "") == (jwt.JWKSURL == "") {
+			return fmt.Errorf("jwt requires exactly one of jwks_file and jwks_url")
+		}
+		if jwt.JWKSURL != "" && !strings.HasPrefix(jwt.JWKSURL, "https://") && !strings.HasPrefix(jwt.JWKSURL, "http://") {
+			return fmt.Errorf("jwt jwks_url must be an http(s) URL")
+		}
+		if jwt.JWKSCacheTTL <= 0 || jwt.Leeway < 0 {
+			return fmt.Errorf("jwt jwks_cache_ttl must be positive and leeway not negative")
+		}
+		for value, scopes := range jwt.ScopeMap {
+			for _, scope := range scopes {
+				if !auth.ValidScope(scope) {
+					return fmt.Errorf("jwt scope_map %s: unknown scope %q (valid: %s)", value, scope, strings.Join(auth.Scopes, ", "))
+				}
+			}
+		}
+	}
+
+	// Validate the viewing key vault
+	if c.Vault.Enabled {
+		if len(c.Vault.Secret) < 32 {
+			return fmt.Errorf("vault secret must be at least 32 characters")
+		}
+		if c.Vault.File == "" {
+			return fmt.Errorf("vault file is required")
+		}
+		names := make(map[string]bool)
+		keys := make(map[string]bool)
+		for i, client := range c.Vault.Clients {
+			if client.Name == "" || (client.KeyHash == "" && !keyNames[client.Name]) {
+				return fmt.Errorf("vault client %d requires a name and a key_hash or security.api_keys entry", i)
+			}
+			if client.KeyHash != "" {
+				if err := auth.ValidHash(client.KeyHash); err != nil {
+					return fmt.Errorf("vault client %s: %w", client.Name, err)
+				}
+			}
+			if names[client.Name] || (client.KeyHash != "" && keys[client.KeyHash]) {
+				return fmt.Errorf("vault client %s: duplicate name or key_hash", client.Name)
+			}
+			if len(client.Aliases) == 0 {
+				return fmt.Errorf("vault client %s: at least one alias is required", client.Name)
+			}
+			names[client.Name] = true
+			keys[client.KeyHash] = true
+		}
+	}
+
+	// Validate response cache policies; decrypted content must never be
+	// stored by shared caches
+	if sharedCacheable(c.HTTPCache.Encrypted) {
+		return fmt.Errorf("http_cache encrypted policy must not allow shared caching: %s", c.HTTPCache.Encrypted)
+	}
+	for i, rule := range c.HTTPCache.Rules {
+		if rule.Public == "" && rule.Encrypted == "" {
+			return fmt.Errorf("http_cache rule %d sets no policy", i)
+		}
+		if sharedCacheable(rule.Encrypted) {
+			return fmt.Errorf("http_cache rule %d encrypted policy must not allow shared caching: %s", i, rule.Encrypted)
+		}
+	}
 
 	// Validate logging level
 	validLevels := map[string]bool{
@@ -316,5 +951,46 @@ func (cc *ChainConfig) Validate(id string) error {
 		return fmt.Errorf("max_retries must be between 0 and 10")
 	}
 
+	if err := cc.Limits.Validate(); err != nil {
+		return fmt.Errorf("invalid limits: %w", err)
+	}
+
+	if cc.MinConfirmations < 0 {
+		return fmt.Errorf("min_confirmations cannot be negative")
+	}
+
+	switch cc.UnconfirmedPolicy {
+	case "", UnconfirmedReject, UnconfirmedServe:
+	default:
+		return fmt.Errorf("unconfirmed_policy must be %s or %s", UnconfirmedReject, UnconfirmedServe)
+	}
+
+	switch cc.UnconfirmedStatus {
+	case 0, 409, 425:
+	default:
+		return fmt.Errorf("unconfirmed_status must be 409 or 425")
+	}
+
+	if cc.ReorgCheckInterval < 0 || (cc.ReorgCheckInterval > 0 && cc.ReorgCheckInterval < time.Second) {
+		return fmt.Errorf("reorg_check_interval must be at least 1 second")
+	}
+
+	if cc.ReorgDepth < 0 || cc.ReorgDepth > 10000 {
+		return fmt.Errorf("reorg_depth must be between 0 and 10000")
+	}
+
 	return nil
 }
+
+// sharedCacheable reports whether a Cache-Control value lets shared caches
+// store the response
+func sharedCacheable(policy string) bool {
+	for _, directive := range strings.Split(policy, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "public", "s-maxage":
+			return true
+		}
+	}
+	return false
+}
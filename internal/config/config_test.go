@@ -3,8 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
 )
 
 func TestLoad_DefaultValues(t *testing.T) {
@@ -48,6 +51,15 @@ chains:
 	if cfg.Observability.Logging.Level != "info" {
 		t.Errorf("Default log level = %s, want info", cfg.Observability.Logging.Level)
 	}
+	if cfg.Limits.MaxRawSize != 100*1024*1024 {
+		t.Errorf("Default max raw size = %d, want 100MB", cfg.Limits.MaxRawSize)
+	}
+	if cfg.Limits.MaxDecompressedSize != 100*1024*1024 {
+		t.Errorf("Default max decompressed size = %d, want 100MB", cfg.Limits.MaxDecompressedSize)
+	}
+	if cfg.Limits.MaxImagePixels != 40_000_000 {
+		t.Errorf("Default max image pixels = %d, want 40000000", cfg.Limits.MaxImagePixels)
+	}
 }
 
 func TestLoad_CustomValues(t *testing.T) {
@@ -60,6 +72,8 @@ server:
   host: "127.0.0.1"
   read_timeout: 30s
   write_timeout: 120s
+  internal:
+    port: 9091
 
 chains:
   default: vrsc
@@ -78,6 +92,14 @@ cache:
   type: redis
   ttl: 48h
 
+detection:
+  signatures:
+    - mime: application/x-custom
+      extension: cst
+      patterns:
+        - offset: 4
+          magic: "43 53 54 21"
+
 observability:
   logging:
     level: debug
@@ -99,6 +121,9 @@ observability:
 	if cfg.Server.Host != "127.0.0.1" {
 		t.Errorf("Server host = %s, want 127.0.0.1", cfg.Server.Host)
 	}
+	if !cfg.Server.Internal.Enabled || cfg.Server.Internal.Port != 9091 {
+		t.Errorf("Internal listener = %+v, want enabled on 9091", cfg.Server.Internal)
+	}
 	if cfg.Cache.Type != "redis" {
 		t.Errorf("Cache type = %s, want redis", cfg.Cache.Type)
 	}
@@ -111,6 +136,12 @@ observability:
 	if cfg.Chains.Default != "vrsc" {
 		t.Errorf("Default chain = %s, want vrsc", cfg.Chains.Default)
 	}
+	if len(cfg.Detection.Signatures) != 1 {
+		t.Fatalf("Detection signatures = %d, want 1", len(cfg.Detection.Signatures))
+	}
+	if sig := cfg.Detection.Signatures[0]; sig.MIME != "application/x-custom" || len(sig.Patterns) != 1 || sig.Patterns[0].Offset != 4 {
+		t.Errorf("Detection signature = %+v", sig)
+	}
 }
 
 func TestValidate_InvalidPort(t *testing.T) {
@@ -186,6 +217,501 @@ func TestValidate_InvalidLogLevel(t *testing.T) {
 	}
 }
 
+func TestValidate_ShortBlockNotifyToken(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{
+			Port: 8080,
+		},
+		Chains: ChainsConfig{
+			Chains: map[string]ChainConfig{
+				"test": {
+					Name:        "Test",
+					Enabled:     true,
+					RPCURL:      "http://localhost:8080",
+					RPCUser:     "user",
+					RPCPassword: "pass",
+					RPCTimeout:  10 * time.Second,
+				},
+			},
+		},
+		Cache: CacheConfig{Type: "filesystem"},
+		Observability: ObservabilityConfig{
+			Logging: LoggingConfig{Level: "info", Format: "json"},
+		},
+		Security: SecurityConfig{
+			BlockNotifyToken: "0123456789abcdef",
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error = %v", err)
+	}
+
+	cfg.Security.BlockNotifyToken = "short"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Error("Validate() expected error for short blocknotify token, got nil")
+	}
+}
+
+func TestValidate_ShortCacheKeySecret(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{
+			Port: 8080,
+		},
+		Chains: ChainsConfig{
+			Chains: map[string]ChainConfig{
+				"test": {
+					Name:        "Test",
+					Enabled:     true,
+					RPCURL:      "http://localhost:8080",
+					RPCUser:     "user",
+					RPCPassword: "pass",
+					RPCTimeout:  10 * time.Second,
+				},
+			},
+		},
+		Cache: CacheConfig{Type: "filesystem", KeySecret: "0123456789abcdef"},
+		Observability: ObservabilityConfig{
+			Logging: LoggingConfig{Level: "info", Format: "json"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error = %v", err)
+	}
+
+	cfg.Cache.KeySecret = "short"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Error("Validate() expected error for short cache key secret, got nil")
+	}
+}
+
+func TestValidate_CacheEncryptionKeys(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{
+			Port: 8080,
+		},
+		Chains: ChainsConfig{
+			Chains: map[string]ChainConfig{
+				"test": {
+					Name:        "Test",
+					Enabled:     true,
+					RPCURL:      "http://localhost:8080",
+					RPCUser:     "user",
+					RPCPassword: "pass",
+					RPCTimeout:  10 * time.Second,
+				},
+			},
+		},
+		Cache: CacheConfig{Type: "filesystem", Encryption: CacheEncryptionConfig{Enabled: true}},
+		Observability: ObservabilityConfig{
+			Logging: LoggingConfig{Level: "info", Format: "json"},
+		},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() expected error for cache encryption without keys, got nil")
+	}
+
+	cfg.Cache.Encryption.KeyFile = "/etc/verus-gateway/cache.keys"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+}
+
+func TestValidate_HTTPCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   HTTPCacheConfig
+		wantErr bool
+	}{
+		{"defaults", HTTPCacheConfig{Public: "public, max-age=31536000, immutable", Encrypted: "private, no-store"}, false},
+		{"private encrypted rule", HTTPCacheConfig{Rules: []HTTPCacheRule{{ContentTypes: []string{"image/*"}, Encrypted: "private, max-age=3600"}}}, false},
+		{"public encrypted policy", HTTPCacheConfig{Encrypted: "public, max-age=60"}, true},
+		{"s-maxage encrypted rule", HTTPCacheConfig{Rules: []HTTPCacheRule{{Encrypted: "private, S-MAXAGE=60"}}}, true},
+		{"empty rule", HTTPCacheConfig{Rules: []HTTPCacheRule{{Chains: []string{"vrsc"}}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server: ServerConfig{Port: 8080},
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {Name: "Test", Enabled: true, RPCURL: "http://localhost:8080", RPCUser: "user", RPCPassword: "pass", RPCTimeout: 10 * time.Second},
+					},
+				},
+				Cache:     CacheConfig{Type: "filesystem"},
+				HTTPCache: tt.cache,
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+				},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Sharing(t *testing.T) {
+	valid := SharingConfig{
+		Enabled:    true,
+		Secret:     "0123456789abcdef0123456789abcdef",
+		DefaultTTL: 24 * time.Hour,
+		MaxTTL:     30 * 24 * time.Hour,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*SharingConfig)
+		wantErr bool
+	}{
+		{"valid", func(s *SharingConfig) {}, false},
+		{"disabled ignores settings", func(s *SharingConfig) { *s = SharingConfig{} }, false},
+		{"short secret", func(s *SharingConfig) { s.Secret = "short" }, true},
+		{"default ttl above max", func(s *SharingConfig) { s.DefaultTTL = 60 * 24 * time.Hour }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sharing := valid
+			tt.modify(&sharing)
+			cfg := &Config{
+				Server: ServerConfig{Port: 8080},
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {Name: "Test", Enabled: true, RPCURL: "http://localhost:8080", RPCUser: "user", RPCPassword: "pass", RPCTimeout: 10 * time.Second},
+					},
+				},
+				Cache:   CacheConfig{Type: "filesystem"},
+				Sharing: sharing,
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+				},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_APIKeys(t *testing.T) {
+	hash := "sha256:" + strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name      string
+		keys      []APIKeyConfig
+		anonymous []string
+		wantErr   bool
+	}{
+		{name: "valid", keys: []APIKeyConfig{{Name: "ops", KeyHash: hash, Scopes: []string{"admin:cache", "metrics"}, Chains: []string{"vrsc"}}}, anonymous: []string{"files:read"}},
+		{name: "no keys", anonymous: []string{"files:read", "files:private", "metrics"}},
+		{name: "missing name", keys: []APIKeyConfig{{KeyHash: hash, Scopes: []string{"metrics"}}}, wantErr: true},
+		{name: "reserved name", keys: []APIKeyConfig{{Name: "anonymous", KeyHash: hash, Scopes: []string{"metrics"}}}, wantErr: true},
+		{name: "duplicate name", keys: []APIKeyConfig{{Name: "ops", KeyHash: hash, Scopes: []string{"metrics"}}, {Name: "ops", KeyHash: hash, Scopes: []string{"metrics"}}}, wantErr: true},
+		{name: "plaintext key", keys: []APIKeyConfig{{Name: "ops", KeyHash: "secret", Scopes: []string{"metrics"}}}, wantErr: true},
+		{name: "no scopes", keys: []APIKeyConfig{{Name: "ops", KeyHash: hash}}, wantErr: true},
+		{name: "unknown scope", keys: []APIKeyConfig{{Name: "ops", KeyHash: hash, Scopes: []string{"admin:all"}}}, wantErr: true},
+		{name: "unknown anonymous scope", anonymous: []string{"files:write"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server: ServerConfig{Port: 8080},
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {Name: "Test", Enabled: true, RPCURL: "http://localhost:8080", RPCUser: "user", RPCPassword: "pass", RPCTimeout: 10 * time.Second},
+					},
+				},
+				Cache:    CacheConfig{Type: "filesystem"},
+				Security: SecurityConfig{APIKeys: tt.keys, AnonymousScopes: tt.anonymous},
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+				},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_TLS(t *testing.T) {
+	valid := ServerConfig{
+		Port: 8443,
+		TLS: TLSConfig{
+			Enabled:      true,
+			CertFile:     "/etc/verus-gateway/tls.crt",
+			KeyFile:      "/etc/verus-gateway/tls.key",
+			MinVersion:   "1.2",
+			CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		},
+		Admin: AdminListenerConfig{
+			Enabled:      true,
+			Host:         "127.0.0.1",
+			Port:         9443,
+			ClientCAFile: "/etc/verus-gateway/admin-ca.crt",
+			Clients:      []AdminClientConfig{{Subject: "CN=ops,O=Example", Scopes: []string{"admin:cache", "admin:chains"}}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*ServerConfig)
+		wantErr bool
+	}{
+		{"valid", func(s *ServerConfig) {}, false},
+		{"disabled ignores settings", func(s *ServerConfig) { s.TLS, s.Admin = TLSConfig{}, AdminListenerConfig{} }, false},
+		{"tls 1.3", func(s *ServerConfig) { s.TLS.MinVersion, s.TLS.CipherSuites = "1.3", nil }, false},
+		{"admin with own certificate", func(s *ServerConfig) {
+			s.TLS = TLSConfig{}
+			s.Admin.CertFile, s.Admin.KeyFile = "/etc/verus-gateway/admin.crt", "/etc/verus-gateway/admin.key"
+		}, false},
+		{"missing key file", func(s *ServerConfig) { s.TLS.KeyFile = "" }, true},
+		{"unknown version", func(s *ServerConfig) { s.TLS.MinVersion = "1.0" }, true},
+		{"insecure cipher", func(s *ServerConfig) { s.TLS.CipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"} }, true},
+		{"ciphers with tls 1.3", func(s *ServerConfig) { s.TLS.MinVersion = "1.3" }, true},
+		{"admin on server port", func(s *ServerConfig) { s.Admin.Port = 8443 }, true},
+		{"admin without certificate", func(s *ServerConfig) { s.TLS = TLSConfig{} }, true},
+		{"admin without client ca", func(s *ServerConfig) { s.Admin.ClientCAFile = "" }, true},
+		{"admin without clients", func(s *ServerConfig) { s.Admin.Clients = nil }, true},
+		{"admin client unknown scope", func(s *ServerConfig) { s.Admin.Clients[0].Scopes = []string{"admin:all"} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := valid
+			server.Admin.Clients = append([]AdminClientConfig(nil), valid.Admin.Clients...)
+			tt.modify(&server)
+			cfg := &Config{
+				Server: server,
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {Name: "Test", Enabled: true, RPCURL: "http://localhost:8080", RPCUser: "user", RPCPassword: "pass", RPCTimeout: 10 * time.Second},
+					},
+				},
+				Cache: CacheConfig{Type: "filesystem"},
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+				},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_InternalListener(t *testing.T) {
+	admin := AdminListenerConfig{
+		Enabled:      true,
+		Port:         9443,
+		ClientCAFile: "/etc/verus-gateway/admin-ca.crt",
+		CertFile:     "/etc/verus-gateway/admin.crt",
+		KeyFile:      "/etc/verus-gateway/admin.key",
+		Clients:      []AdminClientConfig{{Subject: "CN=ops", Scopes: []string{"admin:cache"}}},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"valid", func(c *Config) {}, false},
+		{"metrics disabled ignores path", func(c *Config) { c.Observability.Metrics = MetricsConfig{Path: "admin"} }, false},
+		{"custom path", func(c *Config) { c.Observability.Metrics.Path = "/internal/metrics" }, false},
+		{"relative path", func(c *Config) { c.Observability.Metrics.Path = "metrics" }, true},
+		{"path under admin", func(c *Config) { c.Observability.Metrics.Path = "/admin/metrics" }, true},
+		{"path shadows health", func(c *Config) { c.Observability.Metrics.Path = "/health" }, true},
+		{"shared with server port", func(c *Config) { c.Server.Internal.Port = 8080 }, true},
+		{"invalid port", func(c *Config) { c.Server.Internal.Port = 70000 }, true},
+		{"admin listener port", func(c *Config) { c.Server.Admin, c.Server.Admin.Port = admin, 9090 }, true},
+		{"metrics without a listener", func(c *Config) { c.Server.Internal.Enabled = false }, true},
+		{"metrics on the admin listener only", func(c *Config) { c.Server.Internal.Enabled, c.Server.Admin = false, admin }, false},
+		{"disabled without metrics", func(c *Config) {
+			c.Server.Internal = InternalListenerConfig{}
+			c.Observability.Metrics.Enabled = false
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server: ServerConfig{
+					Port:     8080,
+					Internal: InternalListenerConfig{Enabled: true, Host: "127.0.0.1", Port: 9090},
+				},
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {Name: "Test", Enabled: true, RPCURL: "http://localhost:8080", RPCUser: "user", RPCPassword: "pass", RPCTimeout: 10 * time.Second},
+					},
+				},
+				Cache: CacheConfig{Type: "filesystem"},
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+					Metrics: MetricsConfig{Enabled: true, Path: "/metrics"},
+				},
+			}
+			tt.modify(cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_JWT(t *testing.T) {
+	valid := JWTConfig{
+		Enabled:      true,
+		Issuer:       "https://idp.example.com",
+		Audiences:    []string{"verus-gateway"},
+		JWKSURL:      "https://idp.example.com/.well-known/jwks.json",
+		JWKSCacheTTL: 5 * time.Minute,
+		ScopeClaim:   "groups",
+		ScopeMap:     map[string][]string{"gateway-admins": {"admin:cache", "admin:chains"}},
+		Leeway:       30 * time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*JWTConfig)
+		wantErr bool
+	}{
+		{"valid", func(j *JWTConfig) {}, false},
+		{"disabled ignores settings", func(j *JWTConfig) { *j = JWTConfig{} }, false},
+		{"jwks file", func(j *JWTConfig) { j.JWKSURL, j.JWKSFile = "", "/etc/verus-gateway/jwks.json" }, false},
+		{"missing issuer", func(j *JWTConfig) { j.Issuer = "" }, true},
+		{"missing audience", func(j *JWTConfig) { j.Audiences = nil }, true},
+		{"no key source", func(j *JWTConfig) { j.JWKSURL = "" }, true},
+		{"both key sources", func(j *JWTConfig) { j.JWKSFile = "/etc/verus-gateway/jwks.json" }, true},
+		{"non-http url", func(j *JWTConfig) { j.JWKSURL = "file:///etc/jwks.json" }, true},
+		{"zero cache ttl", func(j *JWTConfig) { j.JWKSCacheTTL = 0 }, true},
+		{"unknown mapped scope", func(j *JWTConfig) { j.ScopeMap = map[string][]string{"admins": {"admin:all"}} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwt := valid
+			tt.modify(&jwt)
+			cfg := &Config{
+				Server: ServerConfig{Port: 8080},
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {Name: "Test", Enabled: true, RPCURL: "http://localhost:8080", RPCUser: "user", RPCPassword: "pass", RPCTimeout: 10 * time.Second},
+					},
+				},
+				Cache:    CacheConfig{Type: "filesystem"},
+				Security: SecurityConfig{JWT: jwt},
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+				},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Vault(t *testing.T) {
+	valid := VaultConfig{
+		Enabled: true,
+		Secret:  "0123456789abcdef0123456789abcdef",
+		File:    "/var/lib/verus-gateway/vault.json",
+		Clients: []VaultClientConfig{
+			{Name: "reports", KeyHash: auth.HashKey("reports-key"), Aliases: []string{"finance"}},
+			{Name: "etl", KeyHash: auth.HashKey("etl-key"), Aliases: []string{"*"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*VaultConfig)
+		wantErr bool
+	}{
+		{"valid", func(v *VaultConfig) {}, false},
+		{"disabled ignores settings", func(v *VaultConfig) { *v = VaultConfig{} }, false},
+		{"short secret", func(v *VaultConfig) { v.Secret = "short" }, true},
+		{"no file", func(v *VaultConfig) { v.File = "" }, true},
+		{"client without key", func(v *VaultConfig) { v.Clients = []VaultClientConfig{{Name: "x", Aliases: []string{"*"}}} }, true},
+		{"client without aliases", func(v *VaultConfig) { v.Clients = []VaultClientConfig{{Name: "x", KeyHash: auth.HashKey("k")}} }, true},
+		{"plaintext client key", func(v *VaultConfig) { v.Clients[0].KeyHash = "reports-key" }, true},
+		{"duplicate key hash", func(v *VaultConfig) { v.Clients[1].KeyHash = auth.HashKey("reports-key") }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := valid
+			vault.Clients = append([]VaultClientConfig(nil), valid.Clients...)
+			tt.modify(&vault)
+			cfg := &Config{
+				Server: ServerConfig{Port: 8080},
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {Name: "Test", Enabled: true, RPCURL: "http://localhost:8080", RPCUser: "user", RPCPassword: "pass", RPCTimeout: 10 * time.Second},
+					},
+				},
+				Cache: CacheConfig{Type: "filesystem"},
+				Vault: vault,
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+				},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Events(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  EventsConfig
+		wantErr bool
+	}{
+		{name: "defaults", events: EventsConfig{Enabled: true, Heartbeat: 15 * time.Second, BufferSize: 64}},
+		{name: "zero values", events: EventsConfig{Enabled: true}},
+		{name: "heartbeat too short", events: EventsConfig{Heartbeat: 100 * time.Millisecond}, wantErr: true},
+		{name: "negative buffer", events: EventsConfig{BufferSize: -1}, wantErr: true},
+		{name: "buffer too large", events: EventsConfig{BufferSize: 100000}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server: ServerConfig{Port: 8080},
+				Chains: ChainsConfig{
+					Chains: map[string]ChainConfig{
+						"test": {
+							Name:        "Test",
+							Enabled:     true,
+							RPCURL:      "http://localhost:8080",
+							RPCUser:     "user",
+							RPCPassword: "pass",
+							RPCTimeout:  10 * time.Second,
+						},
+					},
+				},
+				Cache: CacheConfig{Type: "filesystem"},
+				Observability: ObservabilityConfig{
+					Logging: LoggingConfig{Level: "info", Format: "json"},
+				},
+				Events: tt.events,
+			}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestChainConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -260,6 +786,86 @@ func TestChainConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "confirmation policy",
+			cfg: ChainConfig{
+				Name:              "Test",
+				Enabled:           true,
+				RPCURL:            "http://localhost:8080",
+				RPCUser:           "user",
+				RPCPassword:       "pass",
+				RPCTimeout:        10 * time.Second,
+				MinConfirmations:  6,
+				UnconfirmedPolicy: UnconfirmedServe,
+				UnconfirmedStatus: 409,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative min confirmations",
+			cfg: ChainConfig{
+				Name:             "Test",
+				Enabled:          true,
+				RPCURL:           "http://localhost:8080",
+				RPCUser:          "user",
+				RPCPassword:      "pass",
+				RPCTimeout:       10 * time.Second,
+				MinConfirmations: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown unconfirmed policy",
+			cfg: ChainConfig{
+				Name:              "Test",
+				Enabled:           true,
+				RPCURL:            "http://localhost:8080",
+				RPCUser:           "user",
+				RPCPassword:       "pass",
+				RPCTimeout:        10 * time.Second,
+				UnconfirmedPolicy: "wait",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported unconfirmed status",
+			cfg: ChainConfig{
+				Name:              "Test",
+				Enabled:           true,
+				RPCURL:            "http://localhost:8080",
+				RPCUser:           "user",
+				RPCPassword:       "pass",
+				RPCTimeout:        10 * time.Second,
+				UnconfirmedStatus: 503,
+			},
+			wantErr: true,
+		},
+		{
+			name: "reorg check interval too short",
+			cfg: ChainConfig{
+				Name:               "Test",
+				Enabled:            true,
+				RPCURL:             "http://localhost:8080",
+				RPCUser:            "user",
+				RPCPassword:        "pass",
+				RPCTimeout:         10 * time.Second,
+				ReorgCheckInterval: 100 * time.Millisecond,
+			},
+			wantErr: true,
+		},
+		{
+			name: "reorg depth too large",
+			cfg: ChainConfig{
+				Name:        "Test",
+				Enabled:     true,
+				RPCURL:      "http://localhost:8080",
+				RPCUser:     "user",
+				RPCPassword: "pass",
+				RPCTimeout:  10 * time.Second,
+				ReorgDepth:  20000,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -272,6 +878,91 @@ func TestChainConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestDetectionConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sig     SignatureConfig
+		wantErr bool
+	}{
+		{
+			name: "valid signature",
+			sig: SignatureConfig{MIME: "application/x-custom", Patterns: []SignaturePatternConfig{
+				{Offset: 0, Magic: "de ad be ef"},
+			}},
+		},
+		{
+			name: "valid masked signature",
+			sig: SignatureConfig{MIME: "application/x-custom", Patterns: []SignaturePatternConfig{
+				{Offset: 2, Magic: "fff0", Mask: "fff6"},
+			}},
+		},
+		{
+			name:    "missing mime",
+			sig:     SignatureConfig{Patterns: []SignaturePatternConfig{{Magic: "00"}}},
+			wantErr: true,
+		},
+		{
+			name:    "no patterns",
+			sig:     SignatureConfig{MIME: "application/x-custom"},
+			wantErr: true,
+		},
+		{
+			name: "invalid hex",
+			sig: SignatureConfig{MIME: "application/x-custom", Patterns: []SignaturePatternConfig{
+				{Magic: "xyz"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "mask length mismatch",
+			sig: SignatureConfig{MIME: "application/x-custom", Patterns: []SignaturePatternConfig{
+				{Magic: "ffff", Mask: "ff"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "negative offset",
+			sig: SignatureConfig{MIME: "application/x-custom", Patterns: []SignaturePatternConfig{
+				{Offset: -1, Magic: "00"},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := DetectionConfig{Signatures: []SignatureConfig{tt.sig}}
+			err := dc.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLimitsConfig_Merge(t *testing.T) {
+	global := LimitsConfig{MaxRawSize: 100, MaxDecompressedSize: 200}
+
+	tests := []struct {
+		name     string
+		override LimitsConfig
+		want     LimitsConfig
+	}{
+		{"no override", LimitsConfig{}, global},
+		{"raw override", LimitsConfig{MaxRawSize: 10}, LimitsConfig{MaxRawSize: 10, MaxDecompressedSize: 200}},
+		{"both overridden", LimitsConfig{MaxRawSize: 10, MaxDecompressedSize: 20}, LimitsConfig{MaxRawSize: 10, MaxDecompressedSize: 20}},
+		{"image pixels override", LimitsConfig{MaxImagePixels: 1000}, LimitsConfig{MaxRawSize: 100, MaxDecompressedSize: 200, MaxImagePixels: 1000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := global.Merge(tt.override); got != tt.want {
+				t.Errorf("Merge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoad_FileNotFound(t *testing.T) {
 	// Try to load non-existent file - should error
 	_, err := Load("nonexistent.yaml")
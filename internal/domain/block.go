@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// BlockInfo describes the block that contains a file's transaction
+type BlockInfo struct {
+	// Height is the block height (0 while unconfirmed)
+	Height int64
+
+	// Hash is the block hash (empty while unconfirmed)
+	Hash string
+
+	// Time is the block timestamp
+	Time time.Time
+
+	// Confirmations is the number of blocks on top of and including this one
+	Confirmations int64
+}
+
+// Confirmed reports whether the transaction has been mined
+func (b *BlockInfo) Confirmed() bool {
+	return b != nil && b.Hash != ""
+}
+
+// ConfirmationsAt returns the confirmation count at the given chain height
+func (b *BlockInfo) ConfirmationsAt(tipHeight int64) int64 {
+	if !b.Confirmed() || tipHeight < b.Height {
+		return 0
+	}
+	return tipHeight - b.Height + 1
+}
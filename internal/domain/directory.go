@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DirectoryManifestType identifies a directory manifest
+const DirectoryManifestType = "verus-gateway/directory/v1"
+
+// maxDirectoryEntries is the maximum number of files in a directory manifest
+const maxDirectoryEntries = 100000
+
+// DirectoryManifest publishes a folder of files as a single transaction. It
+// maps slash-separated relative paths to the transactions holding the files.
+//
+//	{
+//	  "type": "verus-gateway/directory/v1",
+//	  "name": "dataset",
+//	  "files": {
+//	    "README.md": {"txid": "..."},
+//	    "data/2024.csv": {"txid": "...", "evk": "zxviews...", "content_type": "text/csv"}
+//	  }
+//	}
+type DirectoryManifest struct {
+	// Type must be DirectoryManifestType
+	Type string `json:"type"`
+
+	// Name is a display name for the directory (optional)
+	Name string `json:"name,omitempty"`
+
+	// Files maps relative paths to entries
+	Files map[string]DirectoryEntry `json:"files"`
+}
+
+// DirectoryEntry is a file in a directory manifest
+type DirectoryEntry struct {
+	// TXID is the transaction holding the file
+	TXID string `json:"txid"`
+
+	// EVK is the viewing key for the file (optional, defaults to the
+	// key used to read the manifest)
+	EVK string `json:"evk,omitempty"`
+
+	// ContentType overrides the detected MIME type (optional)
+	ContentType string `json:"content_type,omitempty"`
+
+	// Size is the file size in bytes, shown in listings (optional)
+	Size int64 `json:"size,omitempty"`
+}
+
+// DirectoryListingEntry is a file or subdirectory in a directory listing
+type DirectoryListingEntry struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Type        string `json:"type"` // "file" or "dir"
+	TXID        string `json:"txid,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// ParseDirectoryManifest parses and validates a directory manifest
+func ParseDirectoryManifest(content []byte) (*DirectoryManifest, error) {
+	var manifest DirectoryManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, NewInvalidManifestError("directory manifest is not valid JSON")
+	}
+	if manifest.Type != DirectoryManifestType {
+		return nil, NewInvalidManifestError(fmt.Sprintf("manifest type must be %q", DirectoryManifestType))
+	}
+
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// Validate validates the manifest
+func (m *DirectoryManifest) Validate() error {
+	if len(m.Files) == 0 {
+		return NewInvalidManifestError("directory manifest has no files")
+	}
+	if len(m.Files) > maxDirectoryEntries {
+		return NewInvalidManifestError(fmt.Sprintf("directory manifest has too many files (max %d)", maxDirectoryEntries))
+	}
+
+	for p, entry := range m.Files {
+		if CleanDirectoryPath(p) != p || p == "" {
+			return NewInvalidManifestError(fmt.Sprintf("invalid path %q", p))
+		}
+		if !txidPattern.MatchString(entry.TXID) {
+			return NewInvalidManifestError(fmt.Sprintf("file %q has invalid txid", p))
+		}
+		if entry.EVK != "" && !evkPattern.MatchString(entry.EVK) {
+			return NewInvalidManifestError(fmt.Sprintf("file %q has invalid evk", p))
+		}
+	}
+
+	return nil
+}
+
+// CleanDirectoryPath normalizes a path within a directory manifest: no
+// leading or trailing slash, no "." or ".." elements. Paths escaping the
+// root are cleaned to stay inside it.
+func CleanDirectoryPath(p string) string {
+	p = path.Clean("/" + p)
+	return strings.TrimPrefix(p, "/")
+}
+
+// Lookup returns the file entry at a path
+func (m *DirectoryManifest) Lookup(p string) (DirectoryEntry, bool) {
+	entry, ok := m.Files[CleanDirectoryPath(p)]
+	return entry, ok
+}
+
+// List returns the files and subdirectories directly inside a directory,
+// sorted with directories first. It returns false if no file lies under dir.
+func (m *DirectoryManifest) List(dir string) ([]DirectoryListingEntry, bool) {
+	dir = CleanDirectoryPath(dir)
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+
+	seenDirs := make(map[string]bool)
+	var entries []DirectoryListingEntry
+	found := false
+
+	for p, entry := range m.Files {
+		rest, ok := strings.CutPrefix(p, prefix)
+		if !ok {
+			continue
+		}
+		found = true
+
+		if name, _, isDir := strings.Cut(rest, "/"); isDir {
+			if !seenDirs[name] {
+				seenDirs[name] = true
+				entries = append(entries, DirectoryListingEntry{
+					Name: name,
+					Path: prefix + name + "/",
+					Type: "dir",
+				})
+			}
+			continue
+		}
+
+		entries = append(entries, DirectoryListingEntry{
+			Name:        rest,
+			Path:        p,
+			Type:        "file",
+			TXID:        entry.TXID,
+			Size:        entry.Size,
+			ContentType: entry.ContentType,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Type != entries[j].Type {
+			return entries[i].Type == "dir"
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries, found
+}
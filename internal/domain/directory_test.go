@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func testDirectoryManifest() *DirectoryManifest {
+	return &DirectoryManifest{
+		Type: DirectoryManifestType,
+		Files: map[string]DirectoryEntry{
+			"README.md":          {TXID: testChunkTXID},
+			"data/2023.csv":      {TXID: testChunkTXID, Size: 10},
+			"data/2024.csv":      {TXID: testChunkTXID, Size: 20},
+			"data/raw/dump.json": {TXID: testChunkTXID},
+			"docs/index.html":    {TXID: testChunkTXID},
+		},
+	}
+}
+
+func TestParseDirectoryManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "valid manifest",
+			content: `{"type":"verus-gateway/directory/v1","files":{"a/b.txt":{"txid":"` + testChunkTXID + `"}}}`,
+		},
+		{
+			name:    "not json",
+			content: "hello",
+			wantErr: true,
+		},
+		{
+			name:    "wrong type",
+			content: `{"type":"verus-gateway/chunked-file/v1","files":{"a.txt":{"txid":"` + testChunkTXID + `"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "no files",
+			content: `{"type":"verus-gateway/directory/v1","files":{}}`,
+			wantErr: true,
+		},
+		{
+			name:    "path traversal",
+			content: `{"type":"verus-gateway/directory/v1","files":{"../secret":{"txid":"` + testChunkTXID + `"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "leading slash",
+			content: `{"type":"verus-gateway/directory/v1","files":{"/a.txt":{"txid":"` + testChunkTXID + `"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid txid",
+			content: `{"type":"verus-gateway/directory/v1","files":{"a.txt":{"txid":"abc"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid evk",
+			content: `{"type":"verus-gateway/directory/v1","files":{"a.txt":{"txid":"` + testChunkTXID + `","evk":"nope"}}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDirectoryManifest([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDirectoryManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidManifest) {
+				t.Errorf("expected ErrInvalidManifest, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDirectoryManifest_Lookup(t *testing.T) {
+	m := testDirectoryManifest()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"README.md", true},
+		{"/data/2024.csv", true},
+		{"data/../README.md", true},
+		{"data", false},
+		{"missing.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if _, ok := m.Lookup(tt.path); ok != tt.want {
+				t.Errorf("Lookup(%q) = %v, want %v", tt.path, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirectoryManifest_List(t *testing.T) {
+	m := testDirectoryManifest()
+
+	tests := []struct {
+		dir       string
+		wantNames []string
+		wantFound bool
+	}{
+		{"", []string{"data", "docs", "README.md"}, true},
+		{"data", []string{"raw", "2023.csv", "2024.csv"}, true},
+		{"data/", []string{"raw", "2023.csv", "2024.csv"}, true},
+		{"data/raw", []string{"dump.json"}, true},
+		{"nope", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			entries, found := m.List(tt.dir)
+			if found != tt.wantFound {
+				t.Fatalf("List(%q) found = %v, want %v", tt.dir, found, tt.wantFound)
+			}
+			if len(entries) != len(tt.wantNames) {
+				t.Fatalf("List(%q) = %v, want names %v", tt.dir, entries, tt.wantNames)
+			}
+			for i, entry := range entries {
+				if entry.Name != tt.wantNames[i] {
+					t.Errorf("entry %d = %q, want %q", i, entry.Name, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
@@ -51,6 +51,15 @@ var (
 
 	// ErrUnsupportedFormat indicates unsupported file format
 	ErrUnsupportedFormat = errors.New("unsupported format")
+
+	// ErrInvalidManifest indicates a malformed manifest
+	ErrInvalidManifest = errors.New("invalid manifest")
+
+	// ErrChunkIntegrity indicates a chunk failed size or hash verification
+	ErrChunkIntegrity = errors.New("chunk integrity check failed")
+
+	// ErrUnconfirmed indicates a transaction has too few confirmations
+	ErrUnconfirmed = errors.New("insufficient confirmations")
 )
 
 // Error represents a domain error with context
@@ -172,3 +181,58 @@ func NewDecompressionError(reason string) *Error {
 		ErrDecompressionFailed,
 	).WithDetail("reason", reason)
 }
+
+// NewFileTooLargeError creates a file too large error for the given limit
+// type (e.g. "raw", "decompressed")
+func NewFileTooLargeError(limitType string, limit int64) *Error {
+	return NewError(
+		"FILE_TOO_LARGE",
+		fmt.Sprintf("file exceeds %s size limit of %d bytes", limitType, limit),
+		413,
+		ErrFileTooLarge,
+	).WithDetail("limit_type", limitType).WithDetail("limit", limit)
+}
+
+// NewInvalidManifestError creates an error for a malformed on-chain manifest
+func NewInvalidManifestError(reason string) *Error {
+	return NewError(
+		"INVALID_MANIFEST",
+		fmt.Sprintf("invalid manifest: %s", reason),
+		502,
+		ErrInvalidManifest,
+	).WithDetail("reason", reason)
+}
+
+// NewChunkIntegrityError creates an error for a chunk that failed verification
+func NewChunkIntegrityError(txid, reason string) *Error {
+	return NewError(
+		"CHUNK_INTEGRITY_FAILED",
+		fmt.Sprintf("chunk verification failed: %s", reason),
+		502,
+		ErrChunkIntegrity,
+	).WithDetail("txid", txid).WithDetail("reason", reason)
+}
+
+// NewUnsupportedFormatError creates an error for content in a format the
+// requested operation cannot handle
+func NewUnsupportedFormatError(reason string) *Error {
+	return NewError(
+		"UNSUPPORTED_FORMAT",
+		reason,
+		415,
+		ErrUnsupportedFormat,
+	)
+}
+
+// NewUnconfirmedError creates an error for a transaction below the chain's
+// confirmation threshold, reported with the given HTTP status (425 or 409)
+func NewUnconfirmedError(txid string, confirmations, required int64, httpStatus int) *Error {
+	return NewError(
+		"INSUFFICIENT_CONFIRMATIONS",
+		fmt.Sprintf("transaction has %d of %d required confirmations", confirmations, required),
+		httpStatus,
+		ErrUnconfirmed,
+	).WithDetail("txid", txid).
+		WithDetail("confirmations", confirmations).
+		WithDetail("min_confirmations", required)
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"regexp"
 	"strings"
 	"time"
@@ -17,9 +20,22 @@ type File struct {
 	// Content is the raw file content
 	Content []byte
 
+	// ContentEncoding is the HTTP content coding of Content ("gzip" when
+	// Content holds the compressed bytes as stored on chain, empty otherwise)
+	ContentEncoding string
+
 	// Metadata contains file metadata
 	Metadata *FileMetadata
 
+	// Manifest is set when the file is assembled from chunks stored in
+	// other transactions. Content is then empty; the data is streamed with
+	// FileService.StreamChunks.
+	Manifest *ChunkManifest
+
+	// Variant identifies a derived representation such as a resized image
+	// (empty for the original file)
+	Variant string
+
 	// RetrievedAt is when the file was retrieved
 	RetrievedAt time.Time
 }
@@ -44,11 +60,26 @@ type FileMetadata struct {
 	// Compressed indicates if the content was compressed
 	Compressed bool
 
+	// Compression is the codec the stored content was compressed with
+	// (e.g. "gzip", "zstd"); empty if it was stored uncompressed
+	Compression string
+
 	// Encrypted indicates if the content was encrypted
 	Encrypted bool
 
 	// CreatedAt is when the file was stored on chain (if available)
 	CreatedAt *time.Time
+
+	// Block is the block containing the file's transaction (nil if unknown)
+	Block *BlockInfo
+
+	// Unconfirmed is set when the transaction is below the chain's
+	// confirmation threshold but served anyway. Such files are never cached.
+	Unconfirmed bool
+
+	// Properties holds format-specific properties extracted from the file
+	// headers (e.g. "width", "pages", "duration_seconds"); nil if none
+	Properties map[string]interface{}
 }
 
 // FileRequest represents a request to retrieve a file
@@ -67,6 +98,17 @@ type FileRequest struct {
 
 	// UseCache indicates whether to use cached version
 	UseCache bool
+
+	// Compression explicitly names the codec the stored payload uses
+	// (optional; detected from magic bytes when empty)
+	Compression string
+
+	// AcceptGzip indicates the client accepts gzip content coding, so
+	// gzip-stored content may be passed through without decompression
+	AcceptGzip bool
+
+	// Transform requests a resized image variant (optional)
+	Transform *ImageTransform
 }
 
 var (
@@ -79,6 +121,9 @@ var (
 	// filenamePattern matches safe filenames (alphanumeric, dots, dashes, underscores, spaces, parentheses, brackets)
 	filenamePattern = regexp.MustCompile(`^[a-zA-Z0-9._\-() \[\]]+$`)
 
+	// compressionPattern matches codec names (e.g. gzip, zstd, x-gzip)
+	compressionPattern = regexp.MustCompile(`^[a-zA-Z0-9\-]{1,16}$`)
+
 	// chainIDPattern matches valid chain IDs (alphanumeric, dashes, underscores)
 	chainIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
 )
@@ -128,26 +173,71 @@ func (r *FileRequest) Validate() error {
 		}
 	}
 
+	// Validate compression format name if provided
+	if r.Compression != "" && !compressionPattern.MatchString(r.Compression) {
+		return NewInvalidInputError("compression", "compression contains invalid characters")
+	}
+
+	// Validate image transform if provided
+	if r.Transform != nil {
+		if err := r.Transform.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Validate EVK if provided
 	if r.EVK != "" {
-		if len(r.EVK) < 95 || len(r.EVK) > 500 {
-			return NewInvalidInputError("evk", "viewing key has invalid length (must be 95-500 characters)")
-		}
+		return ValidateEVK(r.EVK)
+	}
 
-		if !evkPattern.MatchString(r.EVK) {
-			return NewInvalidInputError("evk", "viewing key has invalid format (must start with 'zxviews')")
-		}
+	return nil
+}
+
+// ValidateEVK checks that a viewing key is well-formed
+func ValidateEVK(evk string) error {
+	if len(evk) < 95 || len(evk) > 500 {
+		return NewInvalidInputError("evk", "viewing key has invalid length (must be 95-500 characters)")
+	}
+
+	if !evkPattern.MatchString(evk) {
+		return NewInvalidInputError("evk", "viewing key has invalid format (must start with 'zxviews')")
 	}
 
 	return nil
 }
 
-// CacheKey returns the cache key for this file
-func (r *FileRequest) CacheKey() string {
-	// Include EVK in cache key for encrypted files
+// CacheKey returns the cache key for this file. Encrypted files are keyed by
+// an HMAC of the EVK under the server's secret, so each viewing key has its
+// own entries and keys cannot be recovered or brute-forced from cache keys.
+func (r *FileRequest) CacheKey(secret []byte) string {
+	key := r.ChainID + ":" + r.TXID
+
 	if r.EVK != "" {
-		// Use a hash of EVK to avoid storing sensitive data in key
-		return r.ChainID + ":" + r.TXID + ":encrypted"
+		key += ":evk=" + EVKDigest(secret, r.EVK)
 	}
-	return r.ChainID + ":" + r.TXID
+
+	// An explicit codec can change the decoded content
+	if r.Compression != "" {
+		key += ":z=" + strings.ToLower(r.Compression)
+	}
+
+	// Derived image variants are cached separately from the original
+	if r.Transform != nil {
+		key += ":t=" + r.Transform.Key()
+	}
+
+	return key
+}
+
+// EVKDigest returns a hex HMAC-SHA256 of a viewing key under secret,
+// truncated to 128 bits
+func EVKDigest(secret []byte, evk string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(evk))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// GzipCacheKey returns the cache key for the gzip-encoded form of this file
+func (r *FileRequest) GzipCacheKey(secret []byte) string {
+	return r.CacheKey(secret) + ":gzip"
 }
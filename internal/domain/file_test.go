@@ -179,6 +179,9 @@ func TestFileRequest_Validate(t *testing.T) {
 }
 
 func TestFileRequest_CacheKey(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	evk := "zxviews1q0duytgcqqqqpqre26wkl45gvwwwd706xw608hucmvfalr8rgq93rrg27zzp4j7r2rqd8dlsjg7uw7hghts"
+
 	tests := []struct {
 		name string
 		req  *FileRequest
@@ -197,21 +200,64 @@ func TestFileRequest_CacheKey(t *testing.T) {
 			req: &FileRequest{
 				TXID:    "abc123",
 				ChainID: "vrsctest",
-				EVK:     "zxviews1q0duytgcqqqqpqre26wkl45gvwwwd706xw608hucmvfalr8rgq93rrg27zzp4j7r2rqd8dlsjg7uw7hghts",
+				EVK:     evk,
+			},
+			want: "vrsctest:abc123:evk=" + EVKDigest(secret, evk),
+		},
+		{
+			name: "With explicit compression",
+			req: &FileRequest{
+				TXID:        "abc123",
+				ChainID:     "vrsctest",
+				Compression: "Brotli",
 			},
-			want: "vrsctest:abc123:encrypted",
+			want: "vrsctest:abc123:z=brotli",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.req.CacheKey(); got != tt.want {
+			if got := tt.req.CacheKey(secret); got != tt.want {
 				t.Errorf("FileRequest.CacheKey() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestFileRequest_CacheKey_PerEVK(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	a := &FileRequest{TXID: "abc123", ChainID: "vrsctest", EVK: "zxviews1qaaaa"}
+	b := &FileRequest{TXID: "abc123", ChainID: "vrsctest", EVK: "zxviews1qbbbb"}
+
+	if a.CacheKey(secret) == b.CacheKey(secret) {
+		t.Error("different viewing keys must use different cache keys")
+	}
+	if a.CacheKey(secret) == a.CacheKey([]byte("another-secret-value")) {
+		t.Error("cache keys must depend on the server secret")
+	}
+	if contains(a.CacheKey(secret), a.EVK) {
+		t.Error("cache key must not contain the viewing key")
+	}
+	if got := EVKDigest(secret, a.EVK); len(got) != 32 {
+		t.Errorf("EVKDigest() length = %d, want 32", len(got))
+	}
+}
+
+func TestFileRequest_GzipCacheKey(t *testing.T) {
+	req := &FileRequest{
+		TXID:    "abc123",
+		ChainID: "vrsctest",
+	}
+
+	if got, want := req.GzipCacheKey(nil), "vrsctest:abc123:gzip"; got != want {
+		t.Errorf("FileRequest.GzipCacheKey() = %v, want %v", got, want)
+	}
+
+	if req.GzipCacheKey(nil) == req.CacheKey(nil) {
+		t.Error("gzip and identity forms must use different cache keys")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsMiddle(s, substr)))
 }
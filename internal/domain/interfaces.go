@@ -26,6 +26,16 @@ type Cache interface {
 	Close() error
 }
 
+// LegacyPurger is implemented by caches that can remove encrypted entries
+// stored under the old "chain:txid:encrypted" key scheme, which did not bind
+// entries to the viewing key that decrypted them
+type LegacyPurger interface {
+	// PurgeLegacy removes legacy encrypted entries, along with any other
+	// legacy entries it can't tell apart from them, and returns how many
+	// were removed
+	PurgeLegacy(ctx context.Context) (int, error)
+}
+
 // CacheStats contains cache statistics
 type CacheStats struct {
 	// Hits is the number of cache hits
@@ -121,7 +131,7 @@ type FileDetector interface {
 
 // Decompressor defines the interface for data decompression
 type Decompressor interface {
-	// Decompress decompresses gzip-compressed data
+	// Decompress decompresses data in any supported compression format
 	Decompress(data []byte, maxSize int64) ([]byte, error)
 
 	// IsCompressed checks if data is compressed
@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// ChunkManifestType identifies a chunked file manifest
+const ChunkManifestType = "verus-gateway/chunked-file/v1"
+
+const (
+	// maxManifestSize is the largest object considered as a manifest
+	maxManifestSize = 4 * 1024 * 1024
+
+	// maxManifestChunks is the maximum number of chunks in a manifest
+	maxManifestChunks = 100000
+)
+
+// sha256Pattern matches a hex-encoded SHA-256 digest
+var sha256Pattern = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+// ChunkManifest describes a file stored as ordered chunks across several
+// transactions. Each chunk is the data stored in one transaction, as returned
+// by decryptdata; the file is the concatenation of all chunks.
+//
+//	{
+//	  "type": "verus-gateway/chunked-file/v1",
+//	  "filename": "video.mp4",
+//	  "content_type": "video/mp4",
+//	  "size": 2097152,
+//	  "chunks": [
+//	    {"txid": "...", "size": 1048576, "sha256": "..."},
+//	    {"txid": "...", "size": 1048576, "sha256": "..."}
+//	  ]
+//	}
+type ChunkManifest struct {
+	// Type must be ChunkManifestType
+	Type string `json:"type"`
+
+	// Filename is the original filename (optional)
+	Filename string `json:"filename,omitempty"`
+
+	// ContentType is the MIME type of the assembled file (optional)
+	ContentType string `json:"content_type,omitempty"`
+
+	// Size is the total size of the assembled file in bytes
+	Size int64 `json:"size"`
+
+	// Chunks are the chunks in file order
+	Chunks []Chunk `json:"chunks"`
+}
+
+// Chunk is a single chunk of a chunked file
+type Chunk struct {
+	// TXID is the transaction holding the chunk
+	TXID string `json:"txid"`
+
+	// Size is the chunk size in bytes
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex-encoded SHA-256 of the chunk data
+	SHA256 string `json:"sha256"`
+}
+
+// ChunkSpan is the part of a chunk needed to serve a byte range
+type ChunkSpan struct {
+	// Index is the chunk's position in the manifest
+	Index int
+
+	// Offset is where the span starts within the chunk
+	Offset int64
+
+	// Length is the number of bytes of the chunk in the span
+	Length int64
+}
+
+// ParseChunkManifest parses content as a chunk manifest. It returns false
+// if content is not a manifest, and an error if it claims to be one but is
+// invalid.
+func ParseChunkManifest(content []byte) (*ChunkManifest, bool, error) {
+	if len(content) > maxManifestSize || !bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		return nil, false, nil
+	}
+	if !bytes.Contains(content, []byte(ChunkManifestType)) {
+		return nil, false, nil
+	}
+
+	var manifest ChunkManifest
+	if err := json.Unmarshal(content, &manifest); err != nil || manifest.Type != ChunkManifestType {
+		return nil, false, nil
+	}
+
+	if err := manifest.Validate(); err != nil {
+		return nil, true, err
+	}
+
+	return &manifest, true, nil
+}
+
+// Validate validates the manifest
+func (m *ChunkManifest) Validate() error {
+	if len(m.Chunks) == 0 {
+		return NewInvalidManifestError("manifest has no chunks")
+	}
+	if len(m.Chunks) > maxManifestChunks {
+		return NewInvalidManifestError(fmt.Sprintf("manifest has too many chunks (max %d)", maxManifestChunks))
+	}
+
+	if m.Size <= 0 {
+		return NewInvalidManifestError("manifest has invalid size")
+	}
+
+	var total int64
+	for i, chunk := range m.Chunks {
+		if !txidPattern.MatchString(chunk.TXID) {
+			return NewInvalidManifestError(fmt.Sprintf("chunk %d has invalid txid", i))
+		}
+		if !sha256Pattern.MatchString(chunk.SHA256) {
+			return NewInvalidManifestError(fmt.Sprintf("chunk %d has invalid sha256", i))
+		}
+		if chunk.Size <= 0 {
+			return NewInvalidManifestError(fmt.Sprintf("chunk %d has invalid size", i))
+		}
+		// Checked before adding so crafted sizes can't overflow the total
+		if chunk.Size > m.Size-total {
+			return NewInvalidManifestError(fmt.Sprintf("chunk sizes exceed manifest size %d", m.Size))
+		}
+		total += chunk.Size
+	}
+
+	if total != m.Size {
+		return NewInvalidManifestError(fmt.Sprintf("chunk sizes add up to %d, manifest size is %d", total, m.Size))
+	}
+
+	return nil
+}
+
+// Spans returns the chunk spans covering length bytes starting at offset
+func (m *ChunkManifest) Spans(offset, length int64) []ChunkSpan {
+	if length <= 0 {
+		return nil
+	}
+
+	var spans []ChunkSpan
+	end := offset + length
+
+	var chunkStart int64
+	for i, chunk := range m.Chunks {
+		chunkEnd := chunkStart + chunk.Size
+		if chunkEnd > offset && chunkStart < end {
+			start := max(offset, chunkStart)
+			stop := min(end, chunkEnd)
+			spans = append(spans, ChunkSpan{
+				Index:  i,
+				Offset: start - chunkStart,
+				Length: stop - start,
+			})
+		}
+		if chunkEnd >= end {
+			break
+		}
+		chunkStart = chunkEnd
+	}
+
+	return spans
+}
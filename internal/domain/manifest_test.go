@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	testChunkTXID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testChunkHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestParseChunkManifest(t *testing.T) {
+	chunk := `{"txid":"` + testChunkTXID + `","size":10,"sha256":"` + testChunkHash + `"}`
+
+	tests := []struct {
+		name         string
+		content      string
+		wantManifest bool
+		wantErr      bool
+	}{
+		{
+			name:         "valid manifest",
+			content:      `{"type":"verus-gateway/chunked-file/v1","size":20,"chunks":[` + chunk + `,` + chunk + `]}`,
+			wantManifest: true,
+		},
+		{
+			name:    "plain text",
+			content: "hello world",
+		},
+		{
+			name:    "other json",
+			content: `{"type":"something-else","size":10}`,
+		},
+		{
+			name:         "size mismatch",
+			content:      `{"type":"verus-gateway/chunked-file/v1","size":15,"chunks":[` + chunk + `]}`,
+			wantManifest: true,
+			wantErr:      true,
+		},
+		{
+			name: "overflowing chunk sizes",
+			content: `{"type":"verus-gateway/chunked-file/v1","size":10,"chunks":[` +
+				`{"txid":"` + testChunkTXID + `","size":9223372036854775807,"sha256":"` + testChunkHash + `"},` +
+				`{"txid":"` + testChunkTXID + `","size":9223372036854775807,"sha256":"` + testChunkHash + `"},` +
+				`{"txid":"` + testChunkTXID + `","size":12,"sha256":"` + testChunkHash + `"}]}`,
+			wantManifest: true,
+			wantErr:      true,
+		},
+		{
+			name:         "negative manifest size",
+			content:      `{"type":"verus-gateway/chunked-file/v1","size":-10,"chunks":[` + chunk + `]}`,
+			wantManifest: true,
+			wantErr:      true,
+		},
+		{
+			name:         "no chunks",
+			content:      `{"type":"verus-gateway/chunked-file/v1","size":0,"chunks":[]}`,
+			wantManifest: true,
+			wantErr:      true,
+		},
+		{
+			name:         "invalid chunk txid",
+			content:      `{"type":"verus-gateway/chunked-file/v1","size":10,"chunks":[{"txid":"xyz","size":10,"sha256":"` + testChunkHash + `"}]}`,
+			wantManifest: true,
+			wantErr:      true,
+		},
+		{
+			name:         "invalid chunk hash",
+			content:      `{"type":"verus-gateway/chunked-file/v1","size":10,"chunks":[{"txid":"` + testChunkTXID + `","size":10,"sha256":"abc"}]}`,
+			wantManifest: true,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, ok, err := ParseChunkManifest([]byte(tt.content))
+			if ok != tt.wantManifest {
+				t.Fatalf("ParseChunkManifest() ok = %v, want %v", ok, tt.wantManifest)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChunkManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidManifest) {
+				t.Errorf("expected ErrInvalidManifest, got %v", err)
+			}
+			if ok && !tt.wantErr && manifest == nil {
+				t.Error("expected manifest, got nil")
+			}
+		})
+	}
+}
+
+func TestChunkManifest_Spans(t *testing.T) {
+	manifest := &ChunkManifest{
+		Type: ChunkManifestType,
+		Size: 25,
+		Chunks: []Chunk{
+			{TXID: testChunkTXID, Size: 10, SHA256: testChunkHash},
+			{TXID: testChunkTXID, Size: 10, SHA256: testChunkHash},
+			{TXID: testChunkTXID, Size: 5, SHA256: testChunkHash},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		offset int64
+		length int64
+		want   []ChunkSpan
+	}{
+		{"whole file", 0, 25, []ChunkSpan{{0, 0, 10}, {1, 0, 10}, {2, 0, 5}}},
+		{"within first chunk", 2, 5, []ChunkSpan{{0, 2, 5}}},
+		{"across boundary", 8, 4, []ChunkSpan{{0, 8, 2}, {1, 0, 2}}},
+		{"exact chunk", 10, 10, []ChunkSpan{{1, 0, 10}}},
+		{"tail", 22, 3, []ChunkSpan{{2, 2, 3}}},
+		{"empty", 5, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := manifest.Spans(tt.offset, tt.length)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Spans() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("span %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseChunkManifest_IgnoresLargeContent(t *testing.T) {
+	content := `{"type":"verus-gateway/chunked-file/v1",` + strings.Repeat(" ", maxManifestSize) + `}`
+	if _, ok, _ := ParseChunkManifest([]byte(content)); ok {
+		t.Error("expected oversized content not to be treated as a manifest")
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import "fmt"
+
+// Image fit modes
+const (
+	// FitContain scales the image to fit inside the box, keeping its aspect ratio
+	FitContain = "contain"
+
+	// FitCover scales the image to cover the box and crops the overflow
+	FitCover = "cover"
+
+	// FitFill stretches the image to exactly the box size
+	FitFill = "fill"
+)
+
+// Image output formats
+const (
+	ImageFormatPNG  = "png"
+	ImageFormatJPEG = "jpeg"
+)
+
+const (
+	// MaxTransformDimension is the largest output width or height
+	MaxTransformDimension = 4096
+
+	// DefaultJPEGQuality is the JPEG quality used when none is requested
+	DefaultJPEGQuality = 85
+)
+
+// ImageTransform describes a resized image variant
+type ImageTransform struct {
+	// Width and Height bound the output size in pixels (0 = derive from
+	// the other dimension)
+	Width  int
+	Height int
+
+	// Fit is how the image is fitted into Width x Height (default: contain)
+	Fit string
+
+	// Format is the output format (default: same as input, GIF becomes PNG)
+	Format string
+
+	// Quality is the JPEG quality, 1-100 (default: DefaultJPEGQuality)
+	Quality int
+}
+
+// Normalize fills in defaults so equivalent transforms share a cache key
+func (t *ImageTransform) Normalize() {
+	if t.Fit == "" {
+		t.Fit = FitContain
+	}
+	if t.Format == "jpg" {
+		t.Format = ImageFormatJPEG
+	}
+	if t.Quality == 0 {
+		t.Quality = DefaultJPEGQuality
+	}
+}
+
+// Validate validates the transform
+func (t *ImageTransform) Validate() error {
+	if t.Width < 0 || t.Width > MaxTransformDimension {
+		return NewInvalidInputError("w", fmt.Sprintf("width must be between 1 and %d", MaxTransformDimension))
+	}
+	if t.Height < 0 || t.Height > MaxTransformDimension {
+		return NewInvalidInputError("h", fmt.Sprintf("height must be between 1 and %d", MaxTransformDimension))
+	}
+	if t.Width == 0 && t.Height == 0 && t.Format == "" {
+		return NewInvalidInputError("w", "width, height or format is required")
+	}
+
+	switch t.Fit {
+	case "", FitContain, FitCover, FitFill:
+	default:
+		return NewInvalidInputError("fit", "fit must be contain, cover or fill")
+	}
+	if (t.Fit == FitCover || t.Fit == FitFill) && (t.Width == 0 || t.Height == 0) {
+		return NewInvalidInputError("fit", "cover and fill require both width and height")
+	}
+
+	switch t.Format {
+	case "", ImageFormatPNG, ImageFormatJPEG, "jpg":
+	default:
+		return NewInvalidInputError("format", "format must be png or jpeg")
+	}
+
+	if t.Quality < 0 || t.Quality > 100 {
+		return NewInvalidInputError("q", "quality must be between 1 and 100")
+	}
+
+	return nil
+}
+
+// Key returns a canonical identifier for the transform, used in cache keys
+// and ETags
+func (t *ImageTransform) Key() string {
+	format := t.Format
+	if format == "" {
+		format = "auto"
+	}
+	return fmt.Sprintf("%dx%d-%s-%s-q%d", t.Width, t.Height, t.Fit, format, t.Quality)
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "testing"
+
+func TestImageTransform_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform ImageTransform
+		wantErr   bool
+	}{
+		{"width only", ImageTransform{Width: 200}, false},
+		{"height only", ImageTransform{Height: 200}, false},
+		{"format only", ImageTransform{Format: "png"}, false},
+		{"cover with box", ImageTransform{Width: 100, Height: 100, Fit: FitCover}, false},
+		{"jpeg with quality", ImageTransform{Width: 100, Format: "jpeg", Quality: 70}, false},
+		{"empty", ImageTransform{}, true},
+		{"too wide", ImageTransform{Width: MaxTransformDimension + 1}, true},
+		{"negative height", ImageTransform{Height: -1}, true},
+		{"unknown fit", ImageTransform{Width: 100, Fit: "stretch"}, true},
+		{"cover needs both", ImageTransform{Width: 100, Fit: FitCover}, true},
+		{"unknown format", ImageTransform{Width: 100, Format: "webp"}, true},
+		{"quality too high", ImageTransform{Width: 100, Quality: 101}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.transform.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageTransform_Key(t *testing.T) {
+	a := &ImageTransform{Width: 200, Format: "jpg"}
+	b := &ImageTransform{Width: 200, Fit: FitContain, Format: ImageFormatJPEG, Quality: DefaultJPEGQuality}
+	a.Normalize()
+	b.Normalize()
+
+	if a.Key() != b.Key() {
+		t.Errorf("equivalent transforms have different keys: %q vs %q", a.Key(), b.Key())
+	}
+
+	c := &ImageTransform{Width: 300}
+	c.Normalize()
+	if a.Key() == c.Key() {
+		t.Error("different transforms should have different keys")
+	}
+}
+
+func TestFileRequest_CacheKey_Transform(t *testing.T) {
+	req := &FileRequest{TXID: "abc", ChainID: "vrsc"}
+	original := req.CacheKey(nil)
+
+	req.Transform = &ImageTransform{Width: 100}
+	req.Transform.Normalize()
+
+	if got := req.CacheKey(nil); got == original {
+		t.Errorf("transformed cache key %q should differ from original", got)
+	}
+}
@@ -0,0 +1,200 @@
+// Package events provides an in-process event bus that gateway subsystems
+// publish to and that clients can subscribe to, e.g. over GET /events.
+package events
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event topics
+const (
+	TopicBlock       = "block"        // New chain tip
+	TopicReorg       = "reorg"        // Blocks orphaned by a reorg
+	TopicChainHealth = "chain.health" // Chain became healthy or unhealthy
+	TopicCacheEvict  = "cache.evict"  // Entries evicted for space or expiry
+	TopicCachePurge  = "cache.purge"  // Entries removed by an admin or invalidated
+	TopicFileServed  = "file.served"  // File content served
+)
+
+// Topics lists all event topics
+var Topics = []string{
+	TopicBlock,
+	TopicReorg,
+	TopicChainHealth,
+	TopicCacheEvict,
+	TopicCachePurge,
+	TopicFileServed,
+}
+
+// ValidTopic reports whether topic is one of Topics
+func ValidTopic(topic string) bool {
+	return contains(Topics, topic)
+}
+
+// defaultBufferSize is the number of events queued per subscriber before
+// further events are dropped for it
+const defaultBufferSize = 64
+
+// Event is something that happened in the gateway
+type Event struct {
+	// ID is a sequence number assigned on publish
+	ID uint64 `json:"id"`
+
+	// Topic is one of the Topic constants
+	Topic string `json:"topic"`
+
+	// Chain is the chain the event relates to (empty for gateway-wide events)
+	Chain string `json:"chain,omitempty"`
+
+	// Time is when the event was published
+	Time time.Time `json:"time"`
+
+	// Data holds topic-specific fields
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Filter selects events by chain and topic. Empty lists match everything.
+// Gateway-wide events (without a chain) match any chain filter.
+type Filter struct {
+	Chains []string
+	Topics []string
+}
+
+// Matches reports whether an event passes the filter
+func (f Filter) Matches(event Event) bool {
+	if len(f.Topics) > 0 && !contains(f.Topics, event.Topic) {
+		return false
+	}
+	if len(f.Chains) > 0 && event.Chain != "" && !contains(f.Chains, event.Chain) {
+		return false
+	}
+	return true
+}
+
+// ParseList splits a comma-separated query value, dropping empty items
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Bus fans published events out to subscribers. Publishing never blocks: a
+// subscriber whose buffer is full misses events, which are counted as
+// dropped. A nil *Bus discards events.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	bufferSize  int
+	seq         atomic.Uint64
+}
+
+// NewBus creates an event bus with the given per-subscriber buffer size
+// (0 = default)
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Bus{
+		subscribers: make(map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Publish sends an event to all matching subscribers, assigning its ID and
+// time
+func (b *Bus) Publish(topic, chain string, data map[string]interface{}) {
+	if b == nil {
+		return
+	}
+
+	event := Event{
+		ID:    b.seq.Add(1),
+		Topic: topic,
+		Chain: chain,
+		Time:  time.Now().UTC(),
+		Data:  data,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for events matching filter. The
+// subscription must be closed when no longer needed.
+func (b *Bus) Subscribe(filter Filter) *Subscription {
+	sub := &Subscription{
+		bus:    b,
+		filter: filter,
+		events: make(chan Event, b.bufferSize),
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Subscribers returns the number of active subscriptions
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// Subscription receives events from a bus
+type Subscription struct {
+	bus     *Bus
+	filter  Filter
+	events  chan Event
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Events returns the channel events are delivered on. It is closed by Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events missed because the buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subscribers, s)
+		s.bus.mu.Unlock()
+		close(s.events)
+	})
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
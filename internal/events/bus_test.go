@@ -0,0 +1,90 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBus_PublishFiltered(t *testing.T) {
+	bus := NewBus(8)
+
+	all := bus.Subscribe(Filter{})
+	defer all.Close()
+	blocks := bus.Subscribe(Filter{Chains: []string{"vrsc"}, Topics: []string{TopicBlock}})
+	defer blocks.Close()
+
+	bus.Publish(TopicBlock, "vrsc", map[string]interface{}{"height": 10})
+	bus.Publish(TopicBlock, "vrsctest", nil)
+	bus.Publish(TopicCachePurge, "", map[string]interface{}{"scope": "all"})
+
+	if got := len(all.Events()); got != 3 {
+		t.Errorf("unfiltered subscriber got %d events, want 3", got)
+	}
+
+	if got := len(blocks.Events()); got != 1 {
+		t.Fatalf("filtered subscriber got %d events, want 1", got)
+	}
+	event := <-blocks.Events()
+	if event.Topic != TopicBlock || event.Chain != "vrsc" || event.Data["height"] != 10 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.ID != 1 || event.Time.IsZero() {
+		t.Errorf("event ID/time not assigned: %+v", event)
+	}
+}
+
+func TestFilter_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		event  Event
+		want   bool
+	}{
+		{"empty filter", Filter{}, Event{Topic: TopicBlock, Chain: "vrsc"}, true},
+		{"chain match", Filter{Chains: []string{"vrsc"}}, Event{Topic: TopicBlock, Chain: "vrsc"}, true},
+		{"chain mismatch", Filter{Chains: []string{"vrsc"}}, Event{Topic: TopicBlock, Chain: "vrsctest"}, false},
+		{"gateway-wide event passes chain filter", Filter{Chains: []string{"vrsc"}}, Event{Topic: TopicCacheEvict}, true},
+		{"topic mismatch", Filter{Topics: []string{TopicReorg}}, Event{Topic: TopicBlock, Chain: "vrsc"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBus_SlowSubscriberDropsEvents(t *testing.T) {
+	bus := NewBus(2)
+	sub := bus.Subscribe(Filter{})
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(TopicFileServed, "vrsc", nil)
+	}
+
+	if got := sub.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+
+	sub.Close()
+	sub.Close() // Idempotent
+	if bus.Subscribers() != 0 {
+		t.Errorf("Subscribers() = %d after Close, want 0", bus.Subscribers())
+	}
+
+	// Publishing after close and on a nil bus is safe
+	bus.Publish(TopicFileServed, "vrsc", nil)
+	var nilBus *Bus
+	nilBus.Publish(TopicFileServed, "vrsc", nil)
+}
+
+func TestParseList(t *testing.T) {
+	if got, want := ParseList(" vrsc, ,vrsctest,"), []string{"vrsc", "vrsctest"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseList() = %v, want %v", got, want)
+	}
+	if got := ParseList(""); got != nil {
+		t.Errorf("ParseList(\"\") = %v, want nil", got)
+	}
+}
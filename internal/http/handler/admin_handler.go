@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -98,6 +99,11 @@ func (h *AdminHandler) ListChains(w http.ResponseWriter, r *http.Request) {
 			"id":      chainInfo.ID,
 			"name":    chainInfo.Name,
 			"default": chainInfo.ID == defaultChain,
+			"limits": map[string]interface{}{
+				"max_raw_size":          chainInfo.Limits.MaxRawSize,
+				"max_decompressed_size": chainInfo.Limits.MaxDecompressedSize,
+				"max_image_pixels":      chainInfo.Limits.MaxImagePixels,
+			},
 		})
 	}
 
@@ -167,6 +173,58 @@ func (h *AdminHandler) DeleteCacheEntry(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// BlockNotify handles POST /admin/chains/{chain}/blocknotify, called from
+// the node's -blocknotify hook. It syncs the chain tip right away, which
+// runs reorg checks and notifies block subscribers.
+func (h *AdminHandler) BlockNotify(w http.ResponseWriter, r *http.Request) {
+	chainID := chi.URLParam(r, "chain")
+
+	if _, err := h.chainManager.GetChainInfo(chainID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   "chain not found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// The body optionally names the new block ({"hash": "..."}); the tip is
+	// always read from the node
+	var body struct {
+		Hash string `json:"hash"`
+	}
+	_ = json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&body)
+
+	event, err := h.chainManager.SyncBlocks(r.Context(), chainID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   "failed to sync chain",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response := map[string]interface{}{
+		"chain":   chainID,
+		"changed": event != nil,
+	}
+	if body.Hash != "" {
+		response["notified_hash"] = body.Hash
+	}
+	if event != nil {
+		response["height"] = event.Tip.Height
+		response["hash"] = event.Tip.Hash
+		response["orphaned"] = len(event.Orphaned)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // PrometheusMetrics handles GET /metrics (Prometheus metrics endpoint)
 func (h *AdminHandler) PrometheusMetrics(w http.ResponseWriter, r *http.Request) {
 	// Update cache stats in metrics before serving
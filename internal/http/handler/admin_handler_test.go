@@ -1,10 +1,19 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devdudeio/verus-gateway/internal/chain"
+	"github.com/devdudeio/verus-gateway/internal/config"
 )
 
 func TestNewAdminHandler(t *testing.T) {
@@ -84,3 +93,80 @@ func TestHealthResponseFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockNotify(t *testing.T) {
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		result := `2`
+		if req.Method == "getblockhash" {
+			result = fmt.Sprintf(`"hash%s"`, req.Params[0])
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, result)
+	}))
+	defer rpc.Close()
+
+	mgr, err := chain.NewManager(&config.Config{
+		Chains: config.ChainsConfig{
+			Default: "vrsctest",
+			Chains: map[string]config.ChainConfig{
+				"vrsctest": {
+					Name:        "Test",
+					Enabled:     true,
+					RPCURL:      rpc.URL,
+					RPCUser:     "user",
+					RPCPassword: "pass",
+					RPCTimeout:  5 * time.Second,
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	defer mgr.Close()
+
+	var events []chain.BlockEvent
+	mgr.OnBlock(func(event chain.BlockEvent) { events = append(events, event) })
+
+	handler := NewAdminHandler(nil, mgr, nil, "dev")
+
+	notify := func(chainID string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		r := httptest.NewRequest(http.MethodPost, "/admin/chains/"+chainID+"/blocknotify", strings.NewReader(`{"hash":"hash2"}`))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("chain", chainID)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		w := httptest.NewRecorder()
+		handler.BlockNotify(w, r)
+
+		var response map[string]interface{}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w, response
+	}
+
+	w, response := notify("vrsctest")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if response["changed"] != true || response["height"] != float64(2) || response["hash"] != "hash2" {
+		t.Errorf("unexpected response: %v", response)
+	}
+	if len(events) != 1 {
+		t.Errorf("subscribers notified %d times, want 1", len(events))
+	}
+
+	// Repeated notification for the same tip
+	if _, response := notify("vrsctest"); response["changed"] != false {
+		t.Errorf("expected unchanged tip, got %v", response)
+	}
+
+	if w, _ := notify("unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for unknown chain, got %d", w.Code)
+	}
+}
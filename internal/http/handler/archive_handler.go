@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListEntries handles GET /c/{chain}/file/{txid}/entries?evk=xxx
+// Lists the files inside a ZIP or tar (optionally compressed) archive.
+func (h *FileHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	chainID := chi.URLParam(r, "chain")
+	txid := chi.URLParam(r, "txid")
+
+	archive, err := h.fileService.GetArchive(r.Context(), &domain.FileRequest{
+		TXID:        txid,
+		EVK:         requestEVK(r),
+		ChainID:     chainID,
+		Compression: r.URL.Query().Get("compression"),
+		UseCache:    true,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if requestEVK(r) != "" {
+		h.setCacheHeaders(w, chainID, "application/json", true, false)
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"txid":    txid,
+		"chain":   chainID,
+		"format":  archive.Format(),
+		"count":   len(archive.Entries()),
+		"entries": archive.Entries(),
+	})
+}
+
+// GetEntry handles GET /c/{chain}/file/{txid}/entries/{path...}?evk=xxx
+// Extracts a single file from an archive on the server.
+func (h *FileHandler) GetEntry(w http.ResponseWriter, r *http.Request) {
+	chainID := chi.URLParam(r, "chain")
+	txid := chi.URLParam(r, "txid")
+	name := chi.URLParam(r, "*")
+
+	archive, err := h.fileService.GetArchive(r.Context(), &domain.FileRequest{
+		TXID:        txid,
+		EVK:         requestEVK(r),
+		ChainID:     chainID,
+		Compression: r.URL.Query().Get("compression"),
+		UseCache:    true,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	entry, ok := archive.Lookup(name)
+	if !ok {
+		h.writeError(w, r, domain.NewNotFoundError("archive entry", name).WithDetail("txid", txid))
+		return
+	}
+
+	data, err := archive.ReadFile(entry.Name)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(entry.Name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	filename := strings.ReplaceAll(path.Base(entry.Name), `"`, `\"`)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	h.setCacheHeaders(w, chainID, contentType, requestEVK(r) != "", false)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s/%s"`, txid, url.PathEscape(entry.Name)))
+
+	h.publishServed(chainID, txid, entry.Name, contentType, int64(len(data)), requestEVK(r) != "")
+	http.ServeContent(w, r, entry.Name, entry.Modified, bytes.NewReader(data))
+}
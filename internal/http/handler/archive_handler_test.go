@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// newArchiveTestHandler creates a FileHandler serving a tar archive
+func newArchiveTestHandler(t *testing.T) *FileHandler {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"docs/readme.txt": "read me",
+		"data.json":       `{"a":1}`,
+		"../evil.sh":      "rm -rf /",
+	} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+
+	archive, err := storage.OpenArchive(buf.Bytes(), storage.ArchiveConfig{})
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+
+	return newTestHandler(&mockFileService{
+		getArchiveFunc: func(ctx context.Context, req *domain.FileRequest) (*storage.Archive, error) {
+			return archive, nil
+		},
+	})
+}
+
+func entriesRequest(target, path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("chain", "vrsctest")
+	rctx.URLParams.Add("txid", testManifestTXID)
+	if path != "" {
+		rctx.URLParams.Add("*", path)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestListEntries(t *testing.T) {
+	handler := newArchiveTestHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.ListEntries(w, entriesRequest("/c/vrsctest/file/"+testManifestTXID+"/entries", ""))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Format  string                 `json:"format"`
+		Count   int                    `json:"count"`
+		Entries []storage.ArchiveEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.Format != storage.ArchiveTar {
+		t.Errorf("format = %q, want %q", resp.Format, storage.ArchiveTar)
+	}
+	if resp.Count != 2 || len(resp.Entries) != 2 {
+		t.Fatalf("expected 2 entries (traversal entry skipped), got %+v", resp.Entries)
+	}
+}
+
+func TestGetEntry(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		rangeHeader     string
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+	}{
+		{"text entry", "docs/readme.txt", "", http.StatusOK, "read me", "text/plain; charset=utf-8"},
+		{"json entry", "data.json", "", http.StatusOK, `{"a":1}`, "application/json"},
+		{"range", "docs/readme.txt", "bytes=0-3", http.StatusPartialContent, "read", ""},
+		{"traversal", "../evil.sh", "", http.StatusNotFound, "", ""},
+		{"missing", "nope.txt", "", http.StatusNotFound, "", ""},
+	}
+
+	handler := newArchiveTestHandler(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := entriesRequest("/c/vrsctest/file/"+testManifestTXID+"/entries/"+tt.path, tt.path)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+
+			w := httptest.NewRecorder()
+			handler.GetEntry(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantContentType != "" && w.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.wantContentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/devdudeio/verus-gateway/internal/config"
+	"github.com/devdudeio/verus-gateway/internal/http/middleware"
+)
+
+// Default Cache-Control values
+const (
+	defaultPublicCacheControl    = "public, max-age=31536000, immutable"
+	defaultEncryptedCacheControl = "private, no-store"
+	unconfirmedCacheControl      = "no-store"
+)
+
+// CachePolicy decides the Cache-Control header of served content. Content
+// decrypted with a viewing key gets the encrypted policy so shared caches and
+// CDNs don't store it; rules override either policy per chain and content
+// type. A nil *CachePolicy uses the defaults.
+type CachePolicy struct {
+	public    string
+	encrypted string
+	rules     []config.HTTPCacheRule
+}
+
+// NewCachePolicy creates a cache policy from configuration
+func NewCachePolicy(cfg config.HTTPCacheConfig) *CachePolicy {
+	p := &CachePolicy{
+		public:    cfg.Public,
+		encrypted: cfg.Encrypted,
+		rules:     cfg.Rules,
+	}
+	if p.public == "" {
+		p.public = defaultPublicCacheControl
+	}
+	if p.encrypted == "" {
+		p.encrypted = defaultEncryptedCacheControl
+	}
+	return p
+}
+
+// CacheControl returns the Cache-Control value for content of contentType
+// served from chainID
+func (p *CachePolicy) CacheControl(chainID, contentType string, encrypted bool) string {
+	if p == nil {
+		p = NewCachePolicy(config.HTTPCacheConfig{})
+	}
+
+	for _, rule := range p.rules {
+		if !matchesRule(rule, chainID, contentType) {
+			continue
+		}
+		if encrypted && rule.Encrypted != "" {
+			return rule.Encrypted
+		}
+		if !encrypted && rule.Public != "" {
+			return rule.Public
+		}
+	}
+
+	if encrypted {
+		return p.encrypted
+	}
+	return p.public
+}
+
+// matchesRule reports whether a rule applies to a chain and content type
+func matchesRule(rule config.HTTPCacheRule, chainID, contentType string) bool {
+	if len(rule.Chains) > 0 && !containsFold(rule.Chains, chainID) {
+		return false
+	}
+	if len(rule.ContentTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	for _, pattern := range rule.ContentTypes {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" || pattern == "*/*" || pattern == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether list holds value, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCachePolicy sets the policy for Cache-Control headers (nil = defaults)
+func (h *FileHandler) SetCachePolicy(policy *CachePolicy) {
+	h.cachePolicy = policy
+}
+
+// setCacheHeaders sets Cache-Control for served content and marks the
+// response as varying with the viewing key header. Unconfirmed content may
+// still be reorganized away and is never stored.
+func (h *FileHandler) setCacheHeaders(w http.ResponseWriter, chainID, contentType string, encrypted, unconfirmed bool) {
+	if unconfirmed {
+		w.Header().Set("Cache-Control", unconfirmedCacheControl)
+	} else {
+		w.Header().Set("Cache-Control", h.cachePolicy.CacheControl(chainID, contentType, encrypted))
+	}
+	addVary(w, middleware.EVKHeader)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/config"
+)
+
+func TestCachePolicy_CacheControl(t *testing.T) {
+	policy := NewCachePolicy(config.HTTPCacheConfig{
+		Rules: []config.HTTPCacheRule{
+			{Chains: []string{"vrsctest"}, Public: "public, max-age=60"},
+			{ContentTypes: []string{"text/html"}, Public: "public, max-age=300", Encrypted: "private, max-age=60"},
+			{ContentTypes: []string{"image/*"}, Encrypted: "private, max-age=3600"},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		chainID     string
+		contentType string
+		encrypted   bool
+		want        string
+	}{
+		{"public default", "vrsc", "application/pdf", false, defaultPublicCacheControl},
+		{"encrypted default", "vrsc", "application/pdf", true, defaultEncryptedCacheControl},
+		{"per chain", "VRSCTEST", "application/pdf", false, "public, max-age=60"},
+		{"per chain only overrides public", "vrsctest", "application/pdf", true, defaultEncryptedCacheControl},
+		{"first match wins", "vrsctest", "text/html", false, "public, max-age=60"},
+		{"later rule fills encrypted policy", "vrsctest", "text/html", true, "private, max-age=60"},
+		{"content type with parameters", "vrsc", "text/html; charset=utf-8", false, "public, max-age=300"},
+		{"content type wildcard", "vrsc", "image/png", true, "private, max-age=3600"},
+		{"wildcard needs subtype", "vrsc", "imagery/x", true, defaultEncryptedCacheControl},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.CacheControl(tt.chainID, tt.contentType, tt.encrypted); got != tt.want {
+				t.Errorf("CacheControl() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var defaults *CachePolicy
+	if got := defaults.CacheControl("vrsc", "text/plain", true); got != defaultEncryptedCacheControl {
+		t.Errorf("nil policy CacheControl() = %q, want %q", got, defaultEncryptedCacheControl)
+	}
+}
+
+func TestSetCacheHeaders(t *testing.T) {
+	handler := &FileHandler{}
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Vary", "Accept-Encoding")
+	handler.setCacheHeaders(w, "vrsc", "text/plain", true, false)
+	if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+		t.Errorf("Cache-Control = %q, want private, no-store", got)
+	}
+	if got := w.Header().Values("Vary"); len(got) != 2 || got[1] != "X-Verus-EVK" {
+		t.Errorf("Vary = %v, want Accept-Encoding and X-Verus-EVK", got)
+	}
+
+	w = httptest.NewRecorder()
+	handler.setCacheHeaders(w, "vrsc", "text/plain", false, true)
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q for unconfirmed content, want no-store", got)
+	}
+}
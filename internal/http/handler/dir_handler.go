@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+// directoryListingTemplate renders a directory listing as HTML
+var directoryListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of /{{.Path}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { padding: 0.2em 1em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Index of {{if .Name}}{{.Name}}{{end}}/{{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Type</th></tr>
+{{if .Path}}<tr><td><a href="../{{.Query}}">../</a></td><td></td><td></td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.Href}}">{{.Name}}{{if eq .Type "dir"}}/{{end}}</a></td><td>{{if .Size}}{{.Size}}{{end}}</td><td>{{.ContentType}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// directoryListingRow is a listing entry with its link target
+type directoryListingRow struct {
+	domain.DirectoryListingEntry
+	Href string
+}
+
+// GetDir handles GET /c/{chain}/dir/{txid}/{path...}?evk=xxx&format=json|html
+// The transaction holds a directory manifest; file paths are served as files
+// and directory paths are rendered as listings.
+func (h *FileHandler) GetDir(w http.ResponseWriter, r *http.Request) {
+	chainID := chi.URLParam(r, "chain")
+	txid := chi.URLParam(r, "txid")
+	evk := requestEVK(r)
+	dirPath := domain.CleanDirectoryPath(chi.URLParam(r, "*"))
+
+	manifest, err := h.fileService.GetDirectory(r.Context(), &domain.FileRequest{
+		TXID:     txid,
+		EVK:      evk,
+		ChainID:  chainID,
+		UseCache: true,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if entry, ok := manifest.Lookup(dirPath); ok && dirPath != "" {
+		h.serveDirEntry(w, r, chainID, evk, dirPath, entry)
+		return
+	}
+
+	entries, ok := manifest.List(dirPath)
+	if !ok {
+		h.writeError(w, r, domain.NewNotFoundError("path", dirPath).WithDetail("txid", txid))
+		return
+	}
+
+	// Directory URLs end in a slash so relative links resolve inside them
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		redirectWithSlash(w, r)
+		return
+	}
+
+	if evk != "" {
+		h.setCacheHeaders(w, chainID, "", true, false)
+	}
+
+	if wantsHTML(r) {
+		h.writeDirHTML(w, r, manifest, dirPath, entries)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"txid":    txid,
+		"chain":   chainID,
+		"name":    manifest.Name,
+		"path":    dirPath,
+		"entries": entries,
+	})
+}
+
+// serveDirEntry serves a file referenced by a directory manifest
+func (h *FileHandler) serveDirEntry(w http.ResponseWriter, r *http.Request, chainID, evk, filePath string, entry domain.DirectoryEntry) {
+	// Entries without their own viewing key use the manifest's
+	if entry.EVK != "" {
+		evk = entry.EVK
+	}
+
+	req := &domain.FileRequest{
+		TXID:       entry.TXID,
+		EVK:        evk,
+		ChainID:    chainID,
+		UseCache:   true,
+		AcceptGzip: acceptsGzip(r),
+	}
+
+	file, err := h.fileService.GetFile(r.Context(), req)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// The manifest's path is more reliable than content sniffing
+	file.Metadata.Filename = path.Base(filePath)
+	if entry.ContentType != "" {
+		file.Metadata.ContentType = entry.ContentType
+	} else if ct := mime.TypeByExtension(path.Ext(filePath)); ct != "" {
+		file.Metadata.ContentType = ct
+	}
+
+	h.serveFile(w, r, req, file)
+}
+
+// writeDirHTML renders a directory listing as HTML
+func (h *FileHandler) writeDirHTML(w http.ResponseWriter, r *http.Request, manifest *domain.DirectoryManifest, dirPath string, entries []domain.DirectoryListingEntry) {
+	// Carry the query string over to links, minus the listing format.
+	// Viewing keys and share tokens are left out so they don't end up in
+	// page HTML, browser history or Referer headers.
+	query := r.URL.Query()
+	query.Del("format")
+	query.Del("evk")
+	query.Del("token")
+	suffix := ""
+	if len(query) > 0 {
+		suffix = "?" + query.Encode()
+	}
+
+	rows := make([]directoryListingRow, len(entries))
+	for i, entry := range entries {
+		href := url.PathEscape(entry.Name)
+		if entry.Type == "dir" {
+			href += "/"
+		}
+		rows[i] = directoryListingRow{DirectoryListingEntry: entry, Href: href + suffix}
+	}
+
+	displayPath := dirPath
+	if displayPath != "" {
+		displayPath += "/"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	err := directoryListingTemplate.Execute(w, map[string]interface{}{
+		"Name":    manifest.Name,
+		"Path":    displayPath,
+		"Query":   suffix,
+		"Entries": rows,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] Failed to render directory listing: %v\n", err)
+	}
+}
+
+// redirectWithSlash redirects a directory URL to the same URL with a trailing
+// slash, keeping the query string
+func redirectWithSlash(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Path + "/"
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
+
+// wantsHTML reports whether a listing should be rendered as HTML: an explicit
+// format query parameter wins, otherwise browsers asking for text/html get it
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	testManifestTXID = "1111111111111111111111111111111111111111111111111111111111111111"
+	testEntryTXID    = "2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func newDirTestHandler() *FileHandler {
+	return newTestHandler(&mockFileService{
+		getDirFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.DirectoryManifest, error) {
+			return &domain.DirectoryManifest{
+				Type: domain.DirectoryManifestType,
+				Name: "dataset",
+				Files: map[string]domain.DirectoryEntry{
+					"README.md":      {TXID: testEntryTXID},
+					"data/2024.csv":  {TXID: testEntryTXID, ContentType: "text/csv", Size: 5},
+					"site/style.css": {TXID: testEntryTXID},
+				},
+			}, nil
+		},
+		getFileFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+			return &domain.File{
+				TXID:    req.TXID,
+				ChainID: req.ChainID,
+				Content: []byte("a,b,c"),
+				Metadata: &domain.FileMetadata{
+					ContentType: "text/plain",
+					Size:        5,
+				},
+			}, nil
+		},
+	})
+}
+
+func dirRequest(target, path, accept string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("chain", "vrsctest")
+	rctx.URLParams.Add("txid", testManifestTXID)
+	rctx.URLParams.Add("*", path)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestGetDir(t *testing.T) {
+	base := "/c/vrsctest/dir/" + testManifestTXID + "/"
+
+	tests := []struct {
+		name            string
+		target          string
+		path            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantLocation    string
+	}{
+		{
+			name:            "file with manifest content type",
+			target:          base + "data/2024.csv",
+			path:            "data/2024.csv",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv",
+			wantBody:        "a,b,c",
+		},
+		{
+			name:            "file with content type from extension",
+			target:          base + "site/style.css",
+			path:            "site/style.css",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/css; charset=utf-8",
+		},
+		{
+			name:            "json listing",
+			target:          base + "data/",
+			path:            "data/",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `"2024.csv"`,
+		},
+		{
+			name:            "html listing",
+			target:          base,
+			path:            "",
+			accept:          "text/html,application/xhtml+xml",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        `<a href="data/">data/</a>`,
+		},
+		{
+			name:            "html listing links leave out keys",
+			target:          base + "?evk=zxviewsquerykey&token=vgs1.a.b&key=finance",
+			path:            "",
+			accept:          "text/html",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        `<a href="data/?key=finance">data/</a>`,
+		},
+		{
+			name:            "format parameter overrides accept",
+			target:          base + "?format=json",
+			path:            "",
+			accept:          "text/html",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+		},
+		{
+			name:         "directory without trailing slash redirects",
+			target:       base + "data?evk=x",
+			path:         "data",
+			wantStatus:   http.StatusMovedPermanently,
+			wantLocation: base + "data/?evk=x",
+		},
+		{
+			name:       "missing path",
+			target:     base + "nope.txt",
+			path:       "nope.txt",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	handler := newDirTestHandler()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.GetDir(w, dirRequest(tt.target, tt.path, tt.accept))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantContentType != "" && w.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.wantContentType)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body %q does not contain %q", w.Body.String(), tt.wantBody)
+			}
+			if strings.Contains(w.Body.String(), "zxviews") || strings.Contains(w.Body.String(), "vgs1.") {
+				t.Errorf("body exposes a key: %s", w.Body.String())
+			}
+			if tt.wantLocation != "" && w.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", w.Header().Get("Location"), tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestGetDir_ListingHidesKeys(t *testing.T) {
+	handler := newTestHandler(&mockFileService{
+		getDirFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.DirectoryManifest, error) {
+			return &domain.DirectoryManifest{
+				Type: domain.DirectoryManifestType,
+				Files: map[string]domain.DirectoryEntry{
+					"secret.txt": {TXID: testEntryTXID, EVK: "zxviewssecretkey"},
+				},
+			}, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	handler.GetDir(w, dirRequest("/c/vrsctest/dir/"+testManifestTXID+"/", "", ""))
+
+	if strings.Contains(w.Body.String(), "zxviews") {
+		t.Error("listing must not expose viewing keys")
+	}
+
+	var resp struct {
+		Entries []domain.DirectoryListingEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode listing: %v", err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Path != "secret.txt" {
+		t.Errorf("unexpected entries: %+v", resp.Entries)
+	}
+}
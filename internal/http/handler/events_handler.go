@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/events"
+	"github.com/devdudeio/verus-gateway/internal/http/middleware"
+)
+
+// defaultHeartbeat is how often an idle event stream sends a comment to
+// keep proxies from closing it
+const defaultHeartbeat = 15 * time.Second
+
+// privateEventFields identify files and cache entries; only callers that
+// may decrypt files (files:private) receive them
+var privateEventFields = []string{"txid", "key", "path"}
+
+// EventsHandler streams gateway events to clients as server-sent events
+type EventsHandler struct {
+	*FileHandler
+	bus       *events.Bus
+	heartbeat time.Duration
+}
+
+// NewEventsHandler creates a new events handler (heartbeat 0 = default)
+func NewEventsHandler(bus *events.Bus, heartbeat time.Duration) *EventsHandler {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	return &EventsHandler{
+		FileHandler: &FileHandler{},
+		bus:         bus,
+		heartbeat:   heartbeat,
+	}
+}
+
+// Stream handles GET /events?chain=vrsc,vrsctest&topic=block,reorg
+// Both filters are optional comma-separated lists. Each event is sent with
+// its topic as the SSE event name and the JSON-encoded event as data.
+// Keys restricted to some chains only receive those chains' events, and
+// callers without files:private don't see txids, paths or cache keys.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	filter := events.Filter{
+		Chains: events.ParseList(r.URL.Query().Get("chain")),
+		Topics: events.ParseList(r.URL.Query().Get("topic")),
+	}
+	for _, topic := range filter.Topics {
+		if !events.ValidTopic(topic) {
+			h.writeError(w, r, domain.NewInvalidInputError("topic", fmt.Sprintf("unknown topic %q (valid: %s)", topic, strings.Join(events.Topics, ", "))))
+			return
+		}
+	}
+
+	redact := false
+	if info := middleware.GetAuthInfo(r.Context()); info != nil {
+		principal := info.Principal
+		if len(principal.Chains) > 0 {
+			if len(filter.Chains) == 0 {
+				filter.Chains = principal.Chains
+			}
+			for _, chainID := range filter.Chains {
+				if !principal.AllowsChain(chainID) {
+					h.writeError(w, r, domain.NewError("FORBIDDEN", fmt.Sprintf("API key lacks access to chain %q", chainID), http.StatusForbidden, nil))
+					return
+				}
+			}
+		}
+		redact = !principal.Has(auth.ScopeFilesPrivate)
+	}
+
+	sub := h.bus.Subscribe(filter)
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	w.WriteHeader(http.StatusOK)
+
+	// Tell the client how long to wait before reconnecting
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if redact {
+				event = redactEvent(event)
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				fmt.Printf("[ERROR] Failed to encode event %d: %v\n", event.ID, err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Topic, data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// redactEvent returns event without its privateEventFields. The data map is
+// shared with other subscribers, so it is copied rather than modified.
+func redactEvent(event events.Event) events.Event {
+	data := make(map[string]interface{}, len(event.Data))
+	for key, value := range event.Data {
+		if !slices.Contains(privateEventFields, key) {
+			data[key] = value
+		}
+	}
+	event.Data = data
+	return event
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+	"github.com/devdudeio/verus-gateway/internal/events"
+	"github.com/devdudeio/verus-gateway/internal/http/middleware"
+)
+
+func TestEventsHandler_Stream(t *testing.T) {
+	bus := events.NewBus(8)
+	server := httptest.NewServer(http.HandlerFunc(NewEventsHandler(bus, time.Hour).Stream))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?chain=vrsc&topic=block,cache.purge")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// Wait for the subscription before publishing
+	deadline := time.Now().Add(time.Second)
+	for bus.Subscribers() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	bus.Publish(events.TopicBlock, "vrsctest", nil)                                            // Other chain
+	bus.Publish(events.TopicFileServed, "vrsc", nil)                                           // Other topic
+	bus.Publish(events.TopicBlock, "vrsc", map[string]interface{}{"height": 42, "hash": "ab"}) // Delivered
+
+	name, event := readEvent(t, resp)
+	if name != events.TopicBlock {
+		t.Errorf("event name = %q, want %q", name, events.TopicBlock)
+	}
+	if event.Chain != "vrsc" || event.Data["height"] != float64(42) {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestEventsHandler_InvalidTopic(t *testing.T) {
+	bus := events.NewBus(8)
+	h := NewEventsHandler(bus, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/events?topic=block,bogus", nil)
+	w := httptest.NewRecorder()
+	h.Stream(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] != "INVALID_INPUT" || !strings.Contains(fmt.Sprint(body["message"]), "bogus") {
+		t.Errorf("body = %s, want an INVALID_INPUT error naming the topic", w.Body.String())
+	}
+	if _, ok := body["request_id"]; !ok {
+		t.Errorf("body = %s, want a request_id", w.Body.String())
+	}
+	if bus.Subscribers() != 0 {
+		t.Errorf("Subscribers() = %d, want 0", bus.Subscribers())
+	}
+}
+
+// readEvent returns the name and data of the next event on an SSE stream
+func readEvent(t *testing.T, resp *http.Response) (string, events.Event) {
+	t.Helper()
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	var name, data string
+	timeout := time.After(2 * time.Second)
+	for data == "" {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream closed before an event arrived")
+			}
+			if value, ok := strings.CutPrefix(line, "event: "); ok {
+				name = value
+			}
+			if value, ok := strings.CutPrefix(line, "data: "); ok {
+				data = value
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+
+	var event events.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("invalid event data %q: %v", data, err)
+	}
+	return name, event
+}
+
+func TestEventsHandler_Principal(t *testing.T) {
+	ring, err := auth.NewKeyring([]auth.Key{
+		{Name: "watcher", Hash: auth.HashKey("watcher-key"), Scopes: []string{auth.ScopeEventsRead}, Chains: []string{"vrsc"}},
+		{Name: "private", Hash: auth.HashKey("private-key"), Scopes: []string{auth.ScopeEventsRead, auth.ScopeFilesPrivate}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus(8)
+	authenticate := middleware.Authenticate(ring, nil, auth.Anonymous(nil))
+	server := httptest.NewServer(authenticate(http.HandlerFunc(NewEventsHandler(bus, time.Hour).Stream)))
+	defer server.Close()
+
+	waitSubscribers := func(n int) {
+		deadline := time.Now().Add(time.Second)
+		for bus.Subscribers() != n && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	subscribe := func(key, query string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+query, nil)
+		req.Header.Set("X-API-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		return resp
+	}
+
+	// Chains outside the key's allowlist are refused
+	resp := subscribe("watcher-key", "?chain=vrsctest")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status for other chain = %d, want 403", resp.StatusCode)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		wantTXID bool
+	}{
+		{"restricted key", "watcher-key", false},
+		{"private key", "private-key", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waitSubscribers(0)
+			resp := subscribe(tt.key, "?topic=file.served")
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			waitSubscribers(1)
+
+			if tt.key == "watcher-key" {
+				bus.Publish(events.TopicFileServed, "vrsctest", map[string]interface{}{"txid": "other"}) // Other chain
+			}
+			bus.Publish(events.TopicFileServed, "vrsc", map[string]interface{}{"txid": "abc", "size": 7})
+
+			_, event := readEvent(t, resp)
+			if event.Chain != "vrsc" || event.Data["size"] != float64(7) {
+				t.Fatalf("unexpected event %+v", event)
+			}
+			if _, ok := event.Data["txid"]; ok != tt.wantTXID {
+				t.Errorf("event data %v has txid = %v, want %v", event.Data, ok, tt.wantTXID)
+			}
+		})
+	}
+}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/events"
 	"github.com/devdudeio/verus-gateway/internal/http/middleware"
+	"github.com/devdudeio/verus-gateway/internal/observability/logger"
 	"github.com/devdudeio/verus-gateway/internal/service"
+	"github.com/devdudeio/verus-gateway/internal/share"
+	"github.com/devdudeio/verus-gateway/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -17,11 +23,17 @@ import (
 type FileServiceInterface interface {
 	GetFile(ctx context.Context, req *domain.FileRequest) (*domain.File, error)
 	GetMetadata(ctx context.Context, req *domain.FileRequest) (*domain.FileMetadata, error)
+	StreamChunks(ctx context.Context, req *domain.FileRequest, manifest *domain.ChunkManifest, w io.Writer, offset, length int64) error
+	GetDirectory(ctx context.Context, req *domain.FileRequest) (*domain.DirectoryManifest, error)
+	GetArchive(ctx context.Context, req *domain.FileRequest) (*storage.Archive, error)
 }
 
 // FileHandler handles file-related HTTP requests
 type FileHandler struct {
 	fileService FileServiceInterface
+	events      *events.Bus
+	cachePolicy *CachePolicy
+	shares      *share.Manager
 }
 
 // NewFileHandler creates a new file handler
@@ -33,14 +45,132 @@ func NewFileHandler(fileService *service.FileService) *FileHandler {
 	return h
 }
 
-// GetFile handles GET /c/{chain}/file/{txid_or_filename}?txid=xxx&evk=xxx
+// SetEvents sets the bus served files are published to (nil disables them)
+func (h *FileHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetShares sets the manager share tokens are redeemed with (nil = share
+// links disabled)
+func (h *FileHandler) SetShares(shares *share.Manager) {
+	h.shares = shares
+}
+
+// publishServed reports content served from a transaction. Archive and
+// directory entries carry their path.
+func (h *FileHandler) publishServed(chainID, txid, entryPath, contentType string, size int64, encrypted bool) {
+	data := map[string]interface{}{
+		"txid":         txid,
+		"size":         size,
+		"content_type": contentType,
+		"encrypted":    encrypted,
+	}
+	if entryPath != "" {
+		data["path"] = entryPath
+	}
+	h.events.Publish(events.TopicFileServed, chainID, data)
+}
+
+// GetFile handles GET /c/{chain}/file/{txid_or_filename}?txid=xxx&evk=xxx&compression=xxx
 // Supports both TXID-based and filename-based retrieval:
 // - If path param is 64 hex chars: treated as TXID
 // - Otherwise: treated as filename (requires txid query param)
+// A share link's ?token= stands in for the viewing key.
 func (h *FileHandler) GetFile(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if token := query.Get("token"); token != "" {
+		h.getSharedFile(w, r, token)
+		return
+	}
+	h.getFile(w, r, query.Get("txid"), requestEVK(r), query.Get("compression"))
+}
+
+// getSharedFile serves the file a share token grants access to, enforcing
+// its expiry, address range and download limit. A download only counts once
+// the file is served, and requests resuming one don't count again.
+func (h *FileHandler) getSharedFile(w http.ResponseWriter, r *http.Request, token string) {
+	if h.shares == nil {
+		h.writeError(w, r, domain.NewInvalidInputError("token", "share links are not enabled"))
+		return
+	}
+
+	query := r.URL.Query()
+	txid := chi.URLParam(r, "txid")
+	if len(txid) != 64 || !isHexString(txid) {
+		txid = query.Get("txid")
+	}
+
+	redeem := h.shares.Redeem
+	if resumesDownload(r) {
+		redeem = h.shares.Resume
+	}
+	grant, err := redeem(token, chi.URLParam(r, "chain"), txid, r.RemoteAddr)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if !h.getFile(w, r, grant.TXID, grant.EVK, query.Get("compression")) {
+		h.shares.Release(grant)
+	}
+}
+
+// resumesDownload reports whether r continues a download the client already
+// started: a Range request that doesn't start at the first byte, or a
+// conditional request for content it holds
+func resumesDownload(r *http.Request) bool {
+	if r.Header.Get("If-Range") != "" || r.Header.Get("If-None-Match") != "" {
+		return true
+	}
+	spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes=")
+	return ok && !strings.HasPrefix(strings.TrimSpace(spec), "0-")
+}
+
+// maxFileRequestBody bounds the JSON body of POST file requests
+const maxFileRequestBody = 64 * 1024
+
+// fileRequestBody is the JSON body of POST /c/{chain}/file/{txid_or_filename}
+type fileRequestBody struct {
+	EVK         string `json:"evk"`
+	TXID        string `json:"txid"`
+	Compression string `json:"compression"`
+}
+
+// PostFile handles POST /c/{chain}/file/{txid_or_filename} with a JSON body
+// {"evk": "zxviews...", "txid": "...", "compression": "..."}, which keeps the
+// viewing key out of the URL. Fields missing from the body fall back to the
+// headers and query parameters GetFile accepts.
+func (h *FileHandler) PostFile(w http.ResponseWriter, r *http.Request) {
+	var body fileRequestBody
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxFileRequestBody)).Decode(&body); err != nil && err != io.EOF {
+		h.writeError(w, r, domain.NewInvalidInputError("body", "request body must be a JSON object"))
+		return
+	}
+
+	// A key in the body bypasses RequirePrivateScope, so check it here
+	if body.EVK != "" && !middleware.AuthorizeViewingKey(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	if body.EVK == "" {
+		body.EVK = requestEVK(r)
+	}
+	if body.TXID == "" {
+		body.TXID = query.Get("txid")
+	}
+	if body.Compression == "" {
+		body.Compression = query.Get("compression")
+	}
+
+	h.getFile(w, r, body.TXID, body.EVK, body.Compression)
+}
+
+// getFile serves a file named by the path parameter, with txid used when the
+// path parameter is a filename. It reports whether the file was served.
+func (h *FileHandler) getFile(w http.ResponseWriter, r *http.Request, txid, evk, compression string) bool {
 	chainID := chi.URLParam(r, "chain")
 	pathParam := chi.URLParam(r, "txid")
-	evk := r.URL.Query().Get("evk")
 
 	// Determine if path param is TXID or filename
 	// TXID is always 64 hex characters
@@ -48,28 +178,39 @@ func (h *FileHandler) GetFile(w http.ResponseWriter, r *http.Request) {
 	if len(pathParam) == 64 && isHexString(pathParam) {
 		// Path param is a TXID
 		req = &domain.FileRequest{
-			TXID:     pathParam,
-			EVK:      evk,
-			ChainID:  chainID,
-			UseCache: true,
+			TXID:        pathParam,
+			EVK:         evk,
+			ChainID:     chainID,
+			Compression: compression,
+			UseCache:    true,
+			AcceptGzip:  acceptsGzip(r),
 		}
 	} else {
 		// Path param is a filename, get TXID from query
-		txid := r.URL.Query().Get("txid")
 		req = &domain.FileRequest{
-			TXID:     txid,
-			EVK:      evk,
-			ChainID:  chainID,
-			Filename: pathParam,
-			UseCache: true,
+			TXID:        txid,
+			EVK:         evk,
+			ChainID:     chainID,
+			Filename:    pathParam,
+			Compression: compression,
+			UseCache:    true,
+			AcceptGzip:  acceptsGzip(r),
 		}
 	}
 
+	// Optional image resizing
+	transform, err := parseImageTransform(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return false
+	}
+	req.Transform = transform
+
 	// Get file
 	file, err := h.fileService.GetFile(r.Context(), req)
 	if err != nil {
 		h.writeError(w, r, err)
-		return
+		return false
 	}
 
 	// Override filename from URL if metadata doesn't have it
@@ -77,14 +218,145 @@ func (h *FileHandler) GetFile(w http.ResponseWriter, r *http.Request) {
 		file.Metadata.Filename = req.Filename
 	}
 
+	h.serveFile(w, r, req, file)
+	return true
+}
+
+// serveFile writes a retrieved file to the response
+func (h *FileHandler) serveFile(w http.ResponseWriter, r *http.Request, req *domain.FileRequest, file *domain.File) {
+	h.publishServed(req.ChainID, req.TXID, "", file.Metadata.ContentType, file.Metadata.Size, req.EVK != "")
+
+	// Chunked files are streamed chunk by chunk
+	if file.Manifest != nil {
+		h.serveChunked(w, r, req, file)
+		return
+	}
+
 	// Set headers
-	h.setFileHeaders(w, file)
+	h.setFileHeaders(w, req, file)
 
 	// Write content
 	w.WriteHeader(http.StatusOK)
 	w.Write(file.Content)
 }
 
+// serveChunked streams a chunked file, honoring a single byte range
+func (h *FileHandler) serveChunked(w http.ResponseWriter, r *http.Request, req *domain.FileRequest, file *domain.File) {
+	size := file.Manifest.Size
+
+	h.setFileHeaders(w, req, file)
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	status := http.StatusOK
+	offset, length := int64(0), size
+
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		start, end, ok, satisfiable := parseByteRange(rangeHeader, size)
+		if ok && !satisfiable {
+			w.Header().Del("Content-Length")
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if ok {
+			status = http.StatusPartialContent
+			offset, length = start, end-start+1
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		}
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", length))
+	w.WriteHeader(status)
+
+	// Headers are already sent, so failures can only be logged
+	if err := h.fileService.StreamChunks(r.Context(), req, file.Manifest, w, offset, length); err != nil {
+		fmt.Printf("[ERROR] Chunked transfer failed: %s (request_id=%s)\n", logger.MaskEVKs(err.Error()), middleware.GetRequestID(r.Context()))
+	}
+}
+
+// parseByteRange parses a Range header of the form "bytes=a-b", "bytes=a-"
+// or "bytes=-n" against a resource of the given size, returning the inclusive
+// range. ok is false if the header should be ignored (malformed or multiple
+// ranges); satisfiable is false if the range lies outside the resource.
+func parseByteRange(header string, size int64) (start, end int64, ok, satisfiable bool) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, false
+	}
+
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, false
+	}
+
+	if first == "" {
+		// Suffix range: the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false, false
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, true, false
+		}
+		return max(size-n, 0), size - 1, true, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, false
+	}
+
+	end = size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false, false
+		}
+		end = min(end, size-1)
+	}
+
+	if start >= size {
+		return 0, 0, true, false
+	}
+	return start, end, true, true
+}
+
+// parseImageTransform reads the image transformation query parameters
+// (w, h, fit, format, q). It returns nil if none are present.
+func parseImageTransform(r *http.Request) (*domain.ImageTransform, error) {
+	query := r.URL.Query()
+	if query.Get("w") == "" && query.Get("h") == "" && query.Get("fit") == "" &&
+		query.Get("format") == "" && query.Get("q") == "" {
+		return nil, nil
+	}
+
+	transform := &domain.ImageTransform{
+		Fit:    strings.ToLower(query.Get("fit")),
+		Format: strings.ToLower(query.Get("format")),
+	}
+
+	for _, param := range []struct {
+		name string
+		dest *int
+	}{
+		{"w", &transform.Width},
+		{"h", &transform.Height},
+		{"q", &transform.Quality},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, domain.NewInvalidInputError(param.name, "must be a positive integer")
+		}
+		*param.dest = n
+	}
+
+	transform.Normalize()
+	return transform, nil
+}
+
 // isHexString checks if a string contains only hexadecimal characters
 func isHexString(s string) bool {
 	for _, c := range s {
@@ -95,18 +367,51 @@ func isHexString(s string) bool {
 	return true
 }
 
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "x-gzip" && coding != "*" {
+			continue
+		}
+
+		// An explicit q=0 means "not acceptable"
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if qv, err := strconv.ParseFloat(q, 64); err == nil && qv == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// addVary adds a token to the Vary header unless it is already present
+func addVary(w http.ResponseWriter, token string) {
+	for _, v := range w.Header().Values("Vary") {
+		for _, existing := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), token) {
+				return
+			}
+		}
+	}
+	w.Header().Add("Vary", token)
+}
+
 // HeadFile handles HEAD /c/{chain}/file/{txid}?evk=xxx
 func (h *FileHandler) HeadFile(w http.ResponseWriter, r *http.Request) {
 	chainID := chi.URLParam(r, "chain")
 	txid := chi.URLParam(r, "txid")
-	evk := r.URL.Query().Get("evk")
+	evk := requestEVK(r)
 
 	// Build request
 	req := &domain.FileRequest{
-		TXID:     txid,
-		EVK:      evk,
-		ChainID:  chainID,
-		UseCache: true,
+		TXID:        txid,
+		EVK:         evk,
+		ChainID:     chainID,
+		Compression: r.URL.Query().Get("compression"),
+		UseCache:    true,
 	}
 
 	// Get metadata only
@@ -126,6 +431,9 @@ func (h *FileHandler) HeadFile(w http.ResponseWriter, r *http.Request) {
 	if metadata.Filename != "" {
 		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, metadata.Filename))
 	}
+	setBlockHeaders(w, metadata)
+	h.setCacheHeaders(w, chainID, metadata.ContentType, evk != "", metadata.Unconfirmed)
+	addVary(w, "Accept-Encoding")
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -134,14 +442,15 @@ func (h *FileHandler) HeadFile(w http.ResponseWriter, r *http.Request) {
 func (h *FileHandler) GetMeta(w http.ResponseWriter, r *http.Request) {
 	chainID := chi.URLParam(r, "chain")
 	txid := chi.URLParam(r, "txid")
-	evk := r.URL.Query().Get("evk")
+	evk := requestEVK(r)
 
 	// Build request
 	req := &domain.FileRequest{
-		TXID:     txid,
-		EVK:      evk,
-		ChainID:  chainID,
-		UseCache: true,
+		TXID:        txid,
+		EVK:         evk,
+		ChainID:     chainID,
+		Compression: r.URL.Query().Get("compression"),
+		UseCache:    true,
 	}
 
 	// Get metadata
@@ -151,6 +460,11 @@ func (h *FileHandler) GetMeta(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Metadata of public files isn't marked cacheable as confirmations change
+	if evk != "" || metadata.Unconfirmed {
+		h.setCacheHeaders(w, chainID, "application/json", evk != "", metadata.Unconfirmed)
+	}
+
 	// Write JSON response
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"txid":         txid,
@@ -160,11 +474,16 @@ func (h *FileHandler) GetMeta(w http.ResponseWriter, r *http.Request) {
 		"content_type": metadata.ContentType,
 		"extension":    metadata.Extension,
 		"compressed":   metadata.Compressed,
+		"compression":  metadata.Compression,
+		"properties":   metadata.Properties,
+		"created_at":   metadata.CreatedAt,
+		"block":        blockJSON(metadata.Block),
+		"unconfirmed":  metadata.Unconfirmed,
 	})
 }
 
 // setFileHeaders sets appropriate HTTP headers for file responses
-func (h *FileHandler) setFileHeaders(w http.ResponseWriter, file *domain.File) {
+func (h *FileHandler) setFileHeaders(w http.ResponseWriter, req *domain.FileRequest, file *domain.File) {
 	if file.Metadata.ContentType != "" {
 		w.Header().Set("Content-Type", file.Metadata.ContentType)
 	} else {
@@ -177,13 +496,57 @@ func (h *FileHandler) setFileHeaders(w http.ResponseWriter, file *domain.File) {
 		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
 	}
 
-	if file.Metadata.Size > 0 {
+	// The representation depends on Accept-Encoding (gzip passthrough)
+	addVary(w, "Accept-Encoding")
+
+	etag := file.TXID
+	if file.Variant != "" {
+		etag += "-" + file.Variant
+	}
+	if file.ContentEncoding != "" {
+		// Content is still encoded; the metadata describes the decoded file
+		w.Header().Set("Content-Encoding", file.ContentEncoding)
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(file.Content)))
+		etag += "-" + file.ContentEncoding
+	} else if file.Metadata.Size > 0 {
 		w.Header().Set("Content-Length", fmt.Sprintf("%d", file.Metadata.Size))
 	}
 
-	// Cache headers
-	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, file.TXID))
+	setBlockHeaders(w, file.Metadata)
+
+	h.setCacheHeaders(w, req.ChainID, w.Header().Get("Content-Type"), req.EVK != "", file.Metadata.Unconfirmed)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, etag))
+}
+
+// setBlockHeaders sets Last-Modified and the block headers from a file's
+// on-chain block information
+func setBlockHeaders(w http.ResponseWriter, metadata *domain.FileMetadata) {
+	if metadata.CreatedAt != nil {
+		w.Header().Set("Last-Modified", metadata.CreatedAt.UTC().Format(http.TimeFormat))
+	}
+	if block := metadata.Block; block != nil {
+		if block.Confirmed() {
+			w.Header().Set("X-Verus-Block-Height", strconv.FormatInt(block.Height, 10))
+		}
+		w.Header().Set("X-Verus-Confirmations", strconv.FormatInt(block.Confirmations, 10))
+	}
+}
+
+// blockJSON returns the block information for a metadata response
+func blockJSON(block *domain.BlockInfo) map[string]interface{} {
+	if block == nil {
+		return nil
+	}
+
+	info := map[string]interface{}{
+		"confirmations": block.Confirmations,
+	}
+	if block.Confirmed() {
+		info["height"] = block.Height
+		info["hash"] = block.Hash
+		info["time"] = block.Time
+	}
+	return info
 }
 
 // writeJSON writes a JSON response
@@ -196,6 +559,15 @@ func (h *FileHandler) writeJSON(w http.ResponseWriter, statusCode int, data inte
 	}
 }
 
+// requestEVK returns the viewing key found by the ViewingKey middleware,
+// falling back to the query string on routes without it
+func requestEVK(r *http.Request) string {
+	if evk, ok := r.Context().Value(middleware.EVKKey).(string); ok {
+		return evk
+	}
+	return r.URL.Query().Get("evk")
+}
+
 // writeError writes an error response
 func (h *FileHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
 	requestID := middleware.GetRequestID(r.Context())
@@ -219,7 +591,7 @@ func (h *FileHandler) writeError(w http.ResponseWriter, r *http.Request, err err
 	}
 
 	// Log the error
-	fmt.Printf("[ERROR] Request failed: %v (request_id=%s)\n", err, requestID)
+	fmt.Printf("[ERROR] Request failed: %s (request_id=%s)\n", logger.MaskEVKs(err.Error()), requestID)
 
 	// Write error response
 	response := map[string]interface{}{
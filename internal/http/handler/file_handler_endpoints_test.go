@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/devdudeio/verus-gateway/internal/auth"
 	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/http/middleware"
+	"github.com/devdudeio/verus-gateway/internal/storage"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -16,6 +21,9 @@ import (
 type mockFileService struct {
 	getFileFunc     func(ctx context.Context, req *domain.FileRequest) (*domain.File, error)
 	getMetadataFunc func(ctx context.Context, req *domain.FileRequest) (*domain.FileMetadata, error)
+	streamFunc      func(ctx context.Context, req *domain.FileRequest, manifest *domain.ChunkManifest, w io.Writer, offset, length int64) error
+	getDirFunc      func(ctx context.Context, req *domain.FileRequest) (*domain.DirectoryManifest, error)
+	getArchiveFunc  func(ctx context.Context, req *domain.FileRequest) (*storage.Archive, error)
 }
 
 func (m *mockFileService) GetFile(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
@@ -32,6 +40,27 @@ func (m *mockFileService) GetMetadata(ctx context.Context, req *domain.FileReque
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockFileService) StreamChunks(ctx context.Context, req *domain.FileRequest, manifest *domain.ChunkManifest, w io.Writer, offset, length int64) error {
+	if m.streamFunc != nil {
+		return m.streamFunc(ctx, req, manifest, w, offset, length)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockFileService) GetDirectory(ctx context.Context, req *domain.FileRequest) (*domain.DirectoryManifest, error) {
+	if m.getDirFunc != nil {
+		return m.getDirFunc(ctx, req)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockFileService) GetArchive(ctx context.Context, req *domain.FileRequest) (*storage.Archive, error) {
+	if m.getArchiveFunc != nil {
+		return m.getArchiveFunc(ctx, req)
+	}
+	return nil, errors.New("not implemented")
+}
+
 // newTestHandler creates a FileHandler with a mock service for testing
 func newTestHandler(mockService *mockFileService) *FileHandler {
 	return &FileHandler{
@@ -198,6 +227,221 @@ func TestGetFile_Filename(t *testing.T) {
 	}
 }
 
+func TestPostFile(t *testing.T) {
+	const (
+		txid = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		evk  = "zxviews1qtest123456789012345678901234567890123456789012345678901234567890123456789012345678"
+	)
+
+	tests := []struct {
+		name            string
+		pathParam       string
+		body            string
+		header          string
+		wantStatus      int
+		wantTXID        string
+		wantEVK         string
+		wantCompression string
+	}{
+		{
+			name:       "viewing key in body",
+			pathParam:  txid,
+			body:       `{"evk":"` + evk + `"}`,
+			wantStatus: http.StatusOK,
+			wantTXID:   txid,
+			wantEVK:    evk,
+		},
+		{
+			name:            "filename with txid and compression in body",
+			pathParam:       "report.pdf",
+			body:            `{"evk":"` + evk + `","txid":"` + txid + `","compression":"zstd"}`,
+			wantStatus:      http.StatusOK,
+			wantTXID:        txid,
+			wantEVK:         evk,
+			wantCompression: "zstd",
+		},
+		{
+			name:       "empty body falls back to header",
+			pathParam:  txid,
+			header:     evk,
+			wantStatus: http.StatusOK,
+			wantTXID:   txid,
+			wantEVK:    evk,
+		},
+		{
+			name:       "invalid body",
+			pathParam:  txid,
+			body:       `evk=` + evk,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *domain.FileRequest
+			handler := newTestHandler(&mockFileService{
+				getFileFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+					got = req
+					return &domain.File{
+						TXID:     req.TXID,
+						Content:  []byte("content"),
+						Metadata: &domain.FileMetadata{ContentType: "text/plain", Size: 7},
+					}, nil
+				},
+			})
+
+			router := chi.NewRouter()
+			router.With(middleware.ViewingKey(true)).Post("/c/{chain}/file/{txid}", handler.PostFile)
+
+			req := httptest.NewRequest(http.MethodPost, "/c/vrsctest/file/"+tt.pathParam, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set(middleware.EVKHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if got.TXID != tt.wantTXID || got.EVK != tt.wantEVK || got.Compression != tt.wantCompression {
+				t.Errorf("request = {TXID:%q EVK:%q Compression:%q}, want {%q %q %q}",
+					got.TXID, got.EVK, got.Compression, tt.wantTXID, tt.wantEVK, tt.wantCompression)
+			}
+		})
+	}
+}
+
+func TestPostFile_BodyKeyRequiresPrivateScope(t *testing.T) {
+	const (
+		txid = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		evk  = "zxviews1qtest123456789012345678901234567890123456789012345678901234567890123456789012345678"
+	)
+
+	ring, err := auth.NewKeyring([]auth.Key{
+		{Name: "reader", Hash: auth.HashKey("reader-key"), Scopes: []string{auth.ScopeFilesRead}},
+		{Name: "private", Hash: auth.HashKey("private-key"), Scopes: []string{auth.ScopeFilesRead, auth.ScopeFilesPrivate}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := newTestHandler(&mockFileService{
+		getFileFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+			return &domain.File{TXID: req.TXID, Content: []byte("content"), Metadata: &domain.FileMetadata{ContentType: "text/plain", Size: 7}}, nil
+		},
+	})
+
+	router := chi.NewRouter()
+	router.Use(middleware.Authenticate(ring, nil, auth.Anonymous([]string{auth.ScopeFilesRead})))
+	router.Route("/c/{chain}", func(r chi.Router) {
+		r.Use(middleware.RequireScope(auth.ScopeFilesRead))
+		r.Use(middleware.ViewingKey(true))
+		r.Use(middleware.RequirePrivateScope)
+		r.Post("/file/{txid}", handler.PostFile)
+	})
+
+	tests := []struct {
+		name       string
+		key        string
+		body       string
+		wantStatus int
+	}{
+		{"anonymous public file", "", `{}`, http.StatusOK},
+		{"anonymous viewing key", "", `{"evk":"` + evk + `"}`, http.StatusUnauthorized},
+		{"reader viewing key", "reader-key", `{"evk":"` + evk + `"}`, http.StatusForbidden},
+		{"private viewing key", "private-key", `{"evk":"` + evk + `"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/c/vrsctest/file/"+txid, strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetFile_Chunked(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	manifest := &domain.ChunkManifest{Type: domain.ChunkManifestType, Size: int64(len(content))}
+
+	mockService := &mockFileService{
+		getFileFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+			return &domain.File{
+				TXID:     req.TXID,
+				ChainID:  req.ChainID,
+				Manifest: manifest,
+				Metadata: &domain.FileMetadata{
+					ContentType: "video/mp4",
+					Size:        manifest.Size,
+				},
+			}, nil
+		},
+		streamFunc: func(ctx context.Context, req *domain.FileRequest, m *domain.ChunkManifest, w io.Writer, offset, length int64) error {
+			_, err := w.Write(content[offset : offset+length])
+			return err
+		},
+	}
+
+	tests := []struct {
+		name             string
+		rangeHeader      string
+		wantStatus       int
+		wantBody         string
+		wantContentRange string
+	}{
+		{"full file", "", http.StatusOK, string(content), ""},
+		{"range", "bytes=5-9", http.StatusPartialContent, "56789", "bytes 5-9/20"},
+		{"suffix range", "bytes=-3", http.StatusPartialContent, "hij", "bytes 17-19/20"},
+		{"unsatisfiable", "bytes=50-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */20"},
+	}
+
+	handler := newTestHandler(mockService)
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/c/vrsctest/file/"+txid, nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("chain", "vrsctest")
+			rctx.URLParams.Add("txid", txid)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			handler.GetFile(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.wantContentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantContentRange)
+			}
+			if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("Accept-Ranges = %q, want bytes", got)
+			}
+		})
+	}
+}
+
 func TestHeadFile(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -300,6 +544,8 @@ func TestGetMeta(t *testing.T) {
 				ContentType: "application/pdf",
 				Extension:   ".pdf",
 				Compressed:  false,
+				Properties:  map[string]interface{}{"pages": 12},
+				Block:       &domain.BlockInfo{Height: 1234, Hash: "00ab", Confirmations: 7},
 			},
 			wantStatus:  http.StatusOK,
 			checkFields: true,
@@ -373,6 +619,14 @@ func TestGetMeta(t *testing.T) {
 				if got := resp["filename"].(string); got != tt.mockMetadata.Filename {
 					t.Errorf("filename = %q, want %q", got, tt.mockMetadata.Filename)
 				}
+				block, ok := resp["block"].(map[string]interface{})
+				if !ok || block["height"] != float64(1234) || block["confirmations"] != float64(7) {
+					t.Errorf("block = %v, want height 1234 and 7 confirmations", resp["block"])
+				}
+				props, ok := resp["properties"].(map[string]interface{})
+				if !ok || props["pages"] != float64(12) {
+					t.Errorf("properties = %v, want pages 12", resp["properties"])
+				}
 			}
 		})
 	}
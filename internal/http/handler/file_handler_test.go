@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/devdudeio/verus-gateway/internal/domain"
 )
@@ -13,9 +14,11 @@ import (
 // Test helper functions
 func TestSetFileHeaders(t *testing.T) {
 	handler := &FileHandler{}
+	blockTime := time.Unix(1700000000, 0).UTC()
 
 	tests := []struct {
 		name        string
+		evk         string
 		file        *domain.File
 		wantHeaders map[string]string
 	}{
@@ -67,6 +70,110 @@ func TestSetFileHeaders(t *testing.T) {
 				"Content-Disposition": `inline; filename="test\"file.txt"`,
 			},
 		},
+		{
+			name: "passes gzip content through",
+			file: &domain.File{
+				TXID:            "abc123",
+				Content:         []byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00},
+				ContentEncoding: "gzip",
+				Metadata: &domain.FileMetadata{
+					Filename:    "test.txt",
+					ContentType: "text/plain",
+					Size:        1000,
+					Compressed:  true,
+				},
+			},
+			wantHeaders: map[string]string{
+				"Content-Type":     "text/plain",
+				"Content-Encoding": "gzip",
+				"Content-Length":   "6",
+				"Vary":             "Accept-Encoding",
+				"ETag":             `"abc123-gzip"`,
+			},
+		},
+		{
+			name: "resized variant has its own etag",
+			file: &domain.File{
+				TXID:    "abc123",
+				Content: []byte("png"),
+				Variant: "100x0-contain-auto-q85",
+				Metadata: &domain.FileMetadata{
+					ContentType: "image/png",
+					Size:        3,
+				},
+			},
+			wantHeaders: map[string]string{
+				"ETag": `"abc123-100x0-contain-auto-q85"`,
+			},
+		},
+		{
+			name: "sets block headers",
+			file: &domain.File{
+				TXID:    "abc123",
+				Content: []byte("test"),
+				Metadata: &domain.FileMetadata{
+					ContentType: "text/plain",
+					Size:        4,
+					CreatedAt:   &blockTime,
+					Block:       &domain.BlockInfo{Height: 1234, Hash: "00ab", Time: blockTime, Confirmations: 7},
+				},
+			},
+			wantHeaders: map[string]string{
+				"Last-Modified":         "Tue, 14 Nov 2023 22:13:20 GMT",
+				"X-Verus-Block-Height":  "1234",
+				"X-Verus-Confirmations": "7",
+			},
+		},
+		{
+			name: "unconfirmed file has no height",
+			file: &domain.File{
+				TXID:    "abc123",
+				Content: []byte("test"),
+				Metadata: &domain.FileMetadata{
+					ContentType: "text/plain",
+					Size:        4,
+					Block:       &domain.BlockInfo{},
+				},
+			},
+			wantHeaders: map[string]string{
+				"Last-Modified":         "",
+				"X-Verus-Block-Height":  "",
+				"X-Verus-Confirmations": "0",
+			},
+		},
+		{
+			name: "unconfirmed file is not cacheable",
+			file: &domain.File{
+				TXID:    "abc123",
+				Content: []byte("test"),
+				Metadata: &domain.FileMetadata{
+					ContentType: "text/plain",
+					Size:        4,
+					Block:       &domain.BlockInfo{Height: 100, Hash: "00ab", Time: blockTime, Confirmations: 2},
+					Unconfirmed: true,
+				},
+			},
+			wantHeaders: map[string]string{
+				"Cache-Control":         "no-store",
+				"X-Verus-Confirmations": "2",
+			},
+		},
+		{
+			name: "decrypted file is private",
+			evk:  "zxviews1test",
+			file: &domain.File{
+				TXID:    "abc123",
+				Content: []byte("test"),
+				Metadata: &domain.FileMetadata{
+					ContentType: "text/plain",
+					Size:        4,
+					Encrypted:   true,
+				},
+			},
+			wantHeaders: map[string]string{
+				"Cache-Control": "private, no-store",
+			},
+		},
 		{
 			name: "handles no filename",
 			file: &domain.File{
@@ -86,7 +193,7 @@ func TestSetFileHeaders(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
-			handler.setFileHeaders(w, tt.file)
+			handler.setFileHeaders(w, &domain.FileRequest{TXID: tt.file.TXID, ChainID: "vrsctest", EVK: tt.evk}, tt.file)
 
 			for key, want := range tt.wantHeaders {
 				got := w.Header().Get(key)
@@ -98,6 +205,101 @@ func TestSetFileHeaders(t *testing.T) {
 	}
 }
 
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"gzip, deflate, br", true},
+		{"deflate, GZIP;q=0.8", true},
+		{"br", false},
+		{"gzip;q=0", false},
+		{"*", true},
+		{"identity", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			if got := acceptsGzip(req); got != tt.want {
+				t.Errorf("acceptsGzip(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header          string
+		wantStart       int64
+		wantEnd         int64
+		wantOK          bool
+		wantSatisfiable bool
+	}{
+		{"bytes=0-99", 0, 99, true, true},
+		{"bytes=100-", 100, 999, true, true},
+		{"bytes=-100", 900, 999, true, true},
+		{"bytes=-5000", 0, 999, true, true},
+		{"bytes=500-5000", 500, 999, true, true},
+		{"bytes=1000-", 0, 0, true, false},
+		{"bytes=-0", 0, 0, true, false},
+		{"bytes=0-10,20-30", 0, 0, false, false},
+		{"bytes=10-5", 0, 0, false, false},
+		{"items=0-10", 0, 0, false, false},
+		{"bytes=abc", 0, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, end, ok, satisfiable := parseByteRange(tt.header, 1000)
+			if ok != tt.wantOK || satisfiable != tt.wantSatisfiable {
+				t.Fatalf("ok, satisfiable = %v, %v, want %v, %v", ok, satisfiable, tt.wantOK, tt.wantSatisfiable)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("range = %d-%d, want %d-%d", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParseImageTransform(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    *domain.ImageTransform
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"evk=abc", nil, false},
+		{"w=200", &domain.ImageTransform{Width: 200, Fit: domain.FitContain, Quality: domain.DefaultJPEGQuality}, false},
+		{"w=100&h=100&fit=COVER&format=jpg&q=70", &domain.ImageTransform{Width: 100, Height: 100, Fit: domain.FitCover, Format: domain.ImageFormatJPEG, Quality: 70}, false},
+		{"w=abc", nil, true},
+		{"h=-5", nil, true},
+		{"q=0", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			got, err := parseImageTransform(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImageTransform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if got != nil && !tt.wantErr {
+					t.Errorf("expected no transform, got %+v", got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("parseImageTransform() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestWriteJSON(t *testing.T) {
 	handler := &FileHandler{}
 
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/service"
+	"github.com/devdudeio/verus-gateway/internal/share"
+)
+
+// ShareHandler issues and revokes share links to encrypted files
+type ShareHandler struct {
+	*FileHandler
+	shares  *share.Manager
+	baseURL string
+}
+
+// NewShareHandler creates a new share handler. Links are built on baseURL,
+// or on the request's scheme and host if it is empty.
+func NewShareHandler(fileService *service.FileService, shares *share.Manager, baseURL string) *ShareHandler {
+	return &ShareHandler{
+		FileHandler: NewFileHandler(fileService),
+		shares:      shares,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// shareRequestBody is the JSON body of POST /c/{chain}/share
+type shareRequestBody struct {
+	TXID         string `json:"txid"`
+	EVK          string `json:"evk"`
+	TTL          string `json:"ttl"`           // Go duration, e.g. "72h"
+	MaxDownloads int    `json:"max_downloads"` // 0 = unlimited
+	AllowedIPs   string `json:"allowed_ips"`   // CIDR range or address
+}
+
+// Create handles POST /c/{chain}/share. The viewing key is checked against
+// the file before a link is issued, so broken links are never handed out.
+func (h *ShareHandler) Create(w http.ResponseWriter, r *http.Request) {
+	chainID := chi.URLParam(r, "chain")
+
+	var body shareRequestBody
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxFileRequestBody)).Decode(&body); err != nil {
+		h.writeError(w, r, domain.NewInvalidInputError("body", "request body must be a JSON object"))
+		return
+	}
+	if body.EVK == "" {
+		body.EVK = requestEVK(r)
+	}
+
+	if len(body.TXID) != 64 || !isHexString(body.TXID) {
+		h.writeError(w, r, domain.NewInvalidInputError("txid", "must be 64 hex characters"))
+		return
+	}
+
+	var ttl time.Duration
+	if body.TTL != "" {
+		parsed, err := time.ParseDuration(body.TTL)
+		if err != nil || parsed <= 0 {
+			h.writeError(w, r, domain.NewInvalidInputError("ttl", "must be a positive duration such as 72h"))
+			return
+		}
+		ttl = parsed
+	}
+
+	opts := share.Options{
+		Chain:   chainID,
+		TXID:    body.TXID,
+		EVK:     body.EVK,
+		TTL:     ttl,
+		MaxUses: body.MaxDownloads,
+		IPRange: body.AllowedIPs,
+	}
+	if opts.EVK == "" {
+		h.writeError(w, r, domain.NewInvalidInputError("evk", "a viewing key is required"))
+		return
+	}
+
+	// Fails for unknown chains and keys that don't decrypt the file
+	if _, err := h.fileService.GetMetadata(r.Context(), &domain.FileRequest{
+		TXID:     body.TXID,
+		EVK:      body.EVK,
+		ChainID:  chainID,
+		UseCache: true,
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	token, grant, err := h.shares.Issue(opts)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"id":         grant.ID,
+		"chain":      grant.Chain,
+		"txid":       grant.TXID,
+		"token":      token,
+		"url":        h.shareURL(r, grant, token),
+		"expires_at": grant.ExpiresAt,
+	}
+	if grant.MaxUses > 0 {
+		response["max_downloads"] = grant.MaxUses
+	}
+	if grant.IPRange != "" {
+		response["allowed_ips"] = grant.IPRange
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSON(w, http.StatusCreated, response)
+}
+
+// Revoke handles DELETE /admin/shares/{id}
+func (h *ShareHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.shares.Revoke(id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("share %s revoked", id),
+	})
+}
+
+// shareURL returns the file URL a token is redeemed at
+func (h *ShareHandler) shareURL(r *http.Request, grant *share.Grant, token string) string {
+	base := h.baseURL
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return fmt.Sprintf("%s/c/%s/file/%s?token=%s", base, url.PathEscape(grant.Chain), grant.TXID, url.QueryEscape(token))
+}
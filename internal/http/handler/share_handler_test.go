@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/share"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestShareHandler_CreateRedeemRevoke(t *testing.T) {
+	const (
+		txid = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		evk  = "zxviews1qtest123456789012345678901234567890123456789012345678901234567890123456789012345678"
+	)
+
+	shares, err := share.NewManager(share.Config{Secret: []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var servedEVK string
+	mockService := &mockFileService{
+		getMetadataFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.FileMetadata, error) {
+			if req.EVK != evk {
+				return nil, domain.NewError("DECRYPTION_FAILED", "decryption failed", http.StatusBadRequest, domain.ErrDecryptionFailed)
+			}
+			return &domain.FileMetadata{ContentType: "text/plain"}, nil
+		},
+		getFileFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+			servedEVK = req.EVK
+			return &domain.File{TXID: req.TXID, Content: []byte("secret"), Metadata: &domain.FileMetadata{ContentType: "text/plain", Size: 6}}, nil
+		},
+	}
+	fileHandler := newTestHandler(mockService)
+	fileHandler.SetShares(shares)
+	shareHandler := &ShareHandler{FileHandler: newTestHandler(mockService), shares: shares}
+
+	r := chi.NewRouter()
+	r.Get("/c/{chain}/file/{txid}", fileHandler.GetFile)
+	r.Post("/c/{chain}/share", shareHandler.Create)
+	r.Delete("/admin/shares/{id}", shareHandler.Revoke)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = "203.0.113.7:51234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Keys that don't decrypt the file get no link
+	if w := do(http.MethodPost, "/c/vrsctest/share", `{"txid":"`+txid+`","evk":"zxviews1wrong"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Create() with wrong key status = %d, want 400", w.Code)
+	}
+
+	w := do(http.MethodPost, "/c/vrsctest/share", `{"txid":"`+txid+`","evk":"`+evk+`","ttl":"1h","max_downloads":1,"allowed_ips":"203.0.113.0/24"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create() status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(w.Body.String(), evk) {
+		t.Error("Create() response contains the viewing key")
+	}
+
+	link, err := url.Parse(created.URL)
+	if err != nil || link.Host != "example.com" || link.Query().Get("token") != created.Token {
+		t.Fatalf("Create() url = %q, want a file link carrying the token", created.URL)
+	}
+
+	w = do(http.MethodGet, link.RequestURI(), "")
+	if w.Code != http.StatusOK || servedEVK != evk {
+		t.Fatalf("GetFile() with token status = %d (evk %q), want 200 decrypted with the shared key", w.Code, servedEVK)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+		t.Errorf("Cache-Control = %q, want private, no-store", got)
+	}
+
+	// The single download is used up
+	if w = do(http.MethodGet, link.RequestURI(), ""); w.Code != http.StatusForbidden {
+		t.Errorf("GetFile() past download limit status = %d, want 403", w.Code)
+	}
+
+	// Revoked links stop working
+	w = do(http.MethodPost, "/c/vrsctest/share", `{"txid":"`+txid+`","evk":"`+evk+`"}`)
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w = do(http.MethodDelete, "/admin/shares/"+created.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("Revoke() status = %d, body = %s", w.Code, w.Body.String())
+	}
+	w = do(http.MethodGet, "/c/vrsctest/file/"+txid+"?token="+url.QueryEscape(created.Token), "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "TOKEN_REVOKED") {
+		t.Errorf("GetFile() with revoked token = %d %s, want 401 TOKEN_REVOKED", w.Code, w.Body.String())
+	}
+}
+
+func TestGetFile_TokenWithoutSharing(t *testing.T) {
+	handler := newTestHandler(&mockFileService{})
+
+	r := chi.NewRouter()
+	r.Get("/c/{chain}/file/{txid}", handler.GetFile)
+
+	req := httptest.NewRequest(http.MethodGet, "/c/vrsctest/file/abc?token=vgs1.e30.sig", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 when share links are disabled", w.Code)
+	}
+}
+
+func TestGetFile_FailedShareDownloadNotCounted(t *testing.T) {
+	const txid = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	shares, err := share.NewManager(share.Config{Secret: []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := shares.Issue(share.Options{Chain: "vrsctest", TXID: txid, EVK: "zxviews1qtest", MaxUses: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fail := true
+	handler := newTestHandler(&mockFileService{
+		getFileFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+			if fail {
+				return nil, domain.NewError("RPC_ERROR", "node unavailable", http.StatusBadGateway, nil)
+			}
+			return &domain.File{TXID: req.TXID, Content: []byte("secret"), Metadata: &domain.FileMetadata{ContentType: "text/plain", Size: 6}}, nil
+		},
+	})
+	handler.SetShares(shares)
+
+	r := chi.NewRouter()
+	r.Get("/c/{chain}/file/{txid}", handler.GetFile)
+
+	get := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/c/vrsctest/file/"+txid+"?token="+url.QueryEscape(token), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := get(); code != http.StatusBadGateway {
+		t.Fatalf("GetFile() with failing node status = %d, want 502", code)
+	}
+	fail = false
+	if code := get(); code != http.StatusOK {
+		t.Errorf("GetFile() after failed download status = %d, want 200", code)
+	}
+	if code := get(); code != http.StatusForbidden {
+		t.Errorf("GetFile() past download limit status = %d, want 403", code)
+	}
+}
+
+func TestGetFile_RangedShareDownloads(t *testing.T) {
+	const txid = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	content := []byte("0123456789abcdefghij")
+
+	shares, err := share.NewManager(share.Config{Secret: []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := shares.Issue(share.Options{Chain: "vrsctest", TXID: txid, EVK: "zxviews1qtest", MaxUses: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &domain.ChunkManifest{Type: domain.ChunkManifestType, Size: int64(len(content))}
+	handler := newTestHandler(&mockFileService{
+		getFileFunc: func(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+			return &domain.File{TXID: req.TXID, Manifest: manifest, Metadata: &domain.FileMetadata{ContentType: "video/mp4", Size: manifest.Size}}, nil
+		},
+		streamFunc: func(ctx context.Context, req *domain.FileRequest, m *domain.ChunkManifest, w io.Writer, offset, length int64) error {
+			_, err := w.Write(content[offset : offset+length])
+			return err
+		},
+	})
+	handler.SetShares(shares)
+
+	r := chi.NewRouter()
+	r.Get("/c/{chain}/file/{txid}", handler.GetFile)
+
+	get := func(rangeHeader string) int {
+		req := httptest.NewRequest(http.MethodGet, "/c/vrsctest/file/"+txid+"?token="+url.QueryEscape(token), nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	steps := []struct {
+		name       string
+		rangeValue string
+		want       int
+	}{
+		{"initial range counts the download", "bytes=0-9", http.StatusPartialContent},
+		{"resumed range", "bytes=10-", http.StatusPartialContent},
+		{"later chunk", "bytes=15-19", http.StatusPartialContent},
+		{"second full download", "", http.StatusForbidden},
+		{"restart from the first byte", "bytes=0-", http.StatusForbidden},
+	}
+	for _, step := range steps {
+		if got := get(step.rangeValue); got != step.want {
+			t.Errorf("%s: status = %d, want %d", step.name, got, step.want)
+		}
+	}
+}
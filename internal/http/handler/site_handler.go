@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/devdudeio/verus-gateway/internal/config"
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/service"
+	"github.com/devdudeio/verus-gateway/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// siteSettings are the gateway's site settings with the overrides from an
+// archive's storage.SiteConfigFile, which is never served. A site's CSP is
+// sent in addition to the configured one rather than replacing it; browsers
+// enforce every policy, so it can only restrict.
+type siteSettings struct {
+	IndexFile   string
+	SPAFallback *bool
+	CSP         string // Configured policy
+	SiteCSP     string // Additional policy from the archive
+}
+
+// SiteHandler serves static websites from ZIP or tar archives
+type SiteHandler struct {
+	*FileHandler
+	config config.SitesConfig
+}
+
+// NewSiteHandler creates a new site handler
+func NewSiteHandler(fileService *service.FileService, cfg config.SitesConfig) *SiteHandler {
+	if cfg.IndexFile == "" {
+		cfg.IndexFile = "index.html"
+	}
+	return &SiteHandler{
+		FileHandler: NewFileHandler(fileService),
+		config:      cfg,
+	}
+}
+
+// GetSite handles GET /c/{chain}/site/{txid}/{path...}?evk=xxx
+// The transaction holds a ZIP or tar archive served as a website root.
+func (h *SiteHandler) GetSite(w http.ResponseWriter, r *http.Request) {
+	chainID := chi.URLParam(r, "chain")
+	txid := chi.URLParam(r, "txid")
+	sitePath := domain.CleanDirectoryPath(chi.URLParam(r, "*"))
+
+	archive, err := h.fileService.GetArchive(r.Context(), &domain.FileRequest{
+		TXID:     txid,
+		EVK:      requestEVK(r),
+		ChainID:  chainID,
+		UseCache: true,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// Directory URLs without a trailing slash break relative links
+	if sitePath == "" && !strings.HasSuffix(r.URL.Path, "/") {
+		redirectWithSlash(w, r)
+		return
+	}
+
+	settings := h.settings(archive)
+
+	name := sitePath
+	if sitePath == "" || strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(sitePath, settings.IndexFile)
+	}
+
+	if _, ok := archive.Lookup(name); !ok || name == storage.SiteConfigFile {
+		index := path.Join(sitePath, settings.IndexFile)
+		switch {
+		case name != index && hasEntry(archive, index):
+			// Directory with an index: redirect so relative links resolve inside it
+			redirectWithSlash(w, r)
+			return
+		case *settings.SPAFallback && hasEntry(archive, settings.IndexFile):
+			name = settings.IndexFile
+		default:
+			h.writeError(w, r, domain.NewNotFoundError("path", sitePath).WithDetail("txid", txid))
+			return
+		}
+	}
+
+	entry, _ := archive.Lookup(name)
+	data, err := archive.ReadFile(name)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if settings.CSP != "" {
+		w.Header().Set("Content-Security-Policy", settings.CSP)
+	}
+	if settings.SiteCSP != "" {
+		w.Header().Add("Content-Security-Policy", settings.SiteCSP)
+	}
+	h.setCacheHeaders(w, chainID, contentType, requestEVK(r) != "", false)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s/%s"`, txid, url.PathEscape(name)))
+
+	h.publishServed(chainID, txid, name, contentType, int64(len(data)), requestEVK(r) != "")
+	http.ServeContent(w, r, name, entry.Modified, bytes.NewReader(data))
+}
+
+// settings returns the gateway's site settings with any overrides from the
+// archive's site config applied. The config is parsed once, when the archive
+// is opened; an invalid one is ignored.
+func (h *SiteHandler) settings(archive *storage.Archive) siteSettings {
+	spaFallback := h.config.SPAFallback
+	settings := siteSettings{
+		IndexFile:   h.config.IndexFile,
+		SPAFallback: &spaFallback,
+		CSP:         h.config.CSP,
+	}
+
+	override, _ := archive.SiteConfig()
+	if override == nil {
+		return settings
+	}
+
+	if index := domain.CleanDirectoryPath(override.IndexFile); index != "" {
+		settings.IndexFile = index
+	}
+	if override.SPAFallback != nil {
+		settings.SPAFallback = override.SPAFallback
+	}
+	settings.SiteCSP = override.CSP
+
+	return settings
+}
+
+// hasEntry reports whether an archive contains a file
+func hasEntry(archive *storage.Archive, name string) bool {
+	_, ok := archive.Lookup(name)
+	return ok
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/config"
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// newSiteTestHandler creates a SiteHandler serving a ZIP built from files
+func newSiteTestHandler(t *testing.T, files map[string]string, cfg config.SitesConfig) *SiteHandler {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	archive, err := storage.OpenArchive(buf.Bytes(), storage.ArchiveConfig{})
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+
+	h := NewSiteHandler(nil, cfg)
+	h.fileService = &mockFileService{
+		getArchiveFunc: func(ctx context.Context, req *domain.FileRequest) (*storage.Archive, error) {
+			return archive, nil
+		},
+	}
+	return h
+}
+
+func siteRequest(target, path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("chain", "vrsctest")
+	rctx.URLParams.Add("txid", testManifestTXID)
+	rctx.URLParams.Add("*", path)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestGetSite(t *testing.T) {
+	files := map[string]string{
+		"index.html":       "<h1>home</h1>",
+		"about/index.html": "<h1>about</h1>",
+		"css/site.css":     "body{}",
+		".verus-site.json": `{"csp":"default-src 'none'"}`,
+	}
+	base := "/c/vrsctest/site/" + testManifestTXID
+
+	tests := []struct {
+		name            string
+		target          string
+		path            string
+		spa             bool
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+		wantLocation    string
+	}{
+		{"root index", base + "/", "", false, http.StatusOK, "<h1>home</h1>", "text/html; charset=utf-8", ""},
+		{"root without slash", base, "", false, http.StatusMovedPermanently, "", "", base + "/"},
+		{"asset", base + "/css/site.css", "css/site.css", false, http.StatusOK, "body{}", "text/css; charset=utf-8", ""},
+		{"subdirectory index", base + "/about/", "about/", false, http.StatusOK, "<h1>about</h1>", "", ""},
+		{"subdirectory without slash", base + "/about", "about", false, http.StatusMovedPermanently, "", "", base + "/about/"},
+		{"missing without fallback", base + "/app/route", "app/route", false, http.StatusNotFound, "", "", ""},
+		{"missing with spa fallback", base + "/app/route", "app/route", true, http.StatusOK, "<h1>home</h1>", "", ""},
+		{"site config hidden", base + "/.verus-site.json", ".verus-site.json", false, http.StatusNotFound, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newSiteTestHandler(t, files, config.SitesConfig{SPAFallback: tt.spa, CSP: "default-src 'self'"})
+
+			w := httptest.NewRecorder()
+			h.GetSite(w, siteRequest(tt.target, tt.path))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantContentType != "" && w.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.wantContentType)
+			}
+			if tt.wantLocation != "" && w.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", w.Header().Get("Location"), tt.wantLocation)
+			}
+			// The site's policy is added to the configured one, never replacing it
+			if got := w.Header().Values("Content-Security-Policy"); w.Code == http.StatusOK &&
+				(len(got) != 2 || got[0] != "default-src 'self'" || got[1] != "default-src 'none'") {
+				t.Errorf("CSP = %q, want configured and site policies", got)
+			}
+		})
+	}
+}
+
+func TestGetSite_DefaultCSP(t *testing.T) {
+	h := newSiteTestHandler(t, map[string]string{"index.html": "hi"}, config.SitesConfig{CSP: "default-src 'self'"})
+
+	w := httptest.NewRecorder()
+	h.GetSite(w, siteRequest("/c/vrsctest/site/"+testManifestTXID+"/", ""))
+
+	if got := w.Header().Get("Content-Security-Policy"); got != "default-src 'self'" {
+		t.Errorf("CSP = %q, want configured default", got)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+}
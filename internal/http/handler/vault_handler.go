@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/http/middleware"
+	"github.com/devdudeio/verus-gateway/internal/vault"
+)
+
+// VaultHandler manages the viewing keys stored in the vault. Keys can be
+// stored, described and deleted but never read back.
+type VaultHandler struct {
+	*FileHandler
+	vault *vault.Vault
+	audit *zerolog.Logger
+}
+
+// NewVaultHandler creates a new vault handler. Changes are written to the
+// audit log.
+func NewVaultHandler(v *vault.Vault, audit *zerolog.Logger) *VaultHandler {
+	return &VaultHandler{
+		FileHandler: &FileHandler{},
+		vault:       v,
+		audit:       audit,
+	}
+}
+
+// vaultKeyBody is the JSON body of PUT /admin/vault/{alias}
+type vaultKeyBody struct {
+	EVK         string `json:"evk"`
+	Description string `json:"description"`
+}
+
+// List handles GET /admin/vault
+func (h *VaultHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": h.vault.List(),
+	})
+}
+
+// Get handles GET /admin/vault/{alias}
+func (h *VaultHandler) Get(w http.ResponseWriter, r *http.Request) {
+	key, err := h.vault.Get(chi.URLParam(r, "alias"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, key)
+}
+
+// Put handles PUT /admin/vault/{alias}, storing or replacing a key
+func (h *VaultHandler) Put(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	var body vaultKeyBody
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxFileRequestBody)).Decode(&body); err != nil {
+		h.writeError(w, r, domain.NewInvalidInputError("body", "request body must be a JSON object"))
+		return
+	}
+
+	key, created, err := h.vault.Put(alias, body.EVK, body.Description)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	status, event := http.StatusOK, "vault_key_updated"
+	if created {
+		status, event = http.StatusCreated, "vault_key_created"
+	}
+	h.auditChange(r, event, alias)
+
+	h.writeJSON(w, status, key)
+}
+
+// Delete handles DELETE /admin/vault/{alias}
+func (h *VaultHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	if err := h.vault.Delete(alias); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.auditChange(r, "vault_key_deleted", alias)
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("vault key %s deleted", alias),
+	})
+}
+
+// auditChange writes a vault change to the audit log
+func (h *VaultHandler) auditChange(r *http.Request, event, alias string) {
+	h.audit.Info().
+		Str("event", event).
+		Str("alias", alias).
+		Str("remote_addr", r.RemoteAddr).
+		Str("request_id", middleware.GetRequestID(r.Context())).
+		Msg("Vault key changed")
+}
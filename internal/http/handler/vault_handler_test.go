@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/vault"
+)
+
+func TestVaultHandler_CRUD(t *testing.T) {
+	const evk = "zxviews1qtest12345678901234567890123456789012345678901234567890123456789012345678901234567890123456"
+
+	v, err := vault.Open(vault.Config{
+		Secret: []byte("0123456789abcdef0123456789abcdef"),
+		File:   filepath.Join(t.TempDir(), "vault.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var auditLog bytes.Buffer
+	audit := zerolog.New(&auditLog)
+	h := NewVaultHandler(v, &audit)
+
+	r := chi.NewRouter()
+	r.Get("/admin/vault", h.List)
+	r.Get("/admin/vault/{alias}", h.Get)
+	r.Put("/admin/vault/{alias}", h.Put)
+	r.Delete("/admin/vault/{alias}", h.Delete)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"create", http.MethodPut, "/admin/vault/finance", `{"evk":"` + evk + `","description":"Reports"}`, http.StatusCreated, `"alias":"finance"`},
+		{"replace", http.MethodPut, "/admin/vault/finance", `{"evk":"` + evk + `"}`, http.StatusOK, `"alias":"finance"`},
+		{"missing evk", http.MethodPut, "/admin/vault/finance", `{}`, http.StatusBadRequest, "INVALID_INPUT"},
+		{"bad body", http.MethodPut, "/admin/vault/finance", `not json`, http.StatusBadRequest, "INVALID_INPUT"},
+		{"get", http.MethodGet, "/admin/vault/finance", "", http.StatusOK, `"alias":"finance"`},
+		{"list", http.MethodGet, "/admin/vault", "", http.StatusOK, `"keys":[{"alias":"finance"`},
+		{"delete", http.MethodDelete, "/admin/vault/finance", "", http.StatusOK, "deleted"},
+		{"get deleted", http.MethodGet, "/admin/vault/finance", "", http.StatusNotFound, "KEY_NOT_FOUND"},
+		{"delete unknown", http.MethodDelete, "/admin/vault/finance", "", http.StatusNotFound, "KEY_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		w := do(tt.method, tt.target, tt.body)
+		if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("%s: %d %s, want %d containing %s", tt.name, w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+		if strings.Contains(w.Body.String(), evk) {
+			t.Errorf("%s: response contains the viewing key", tt.name)
+		}
+	}
+
+	for _, event := range []string{"vault_key_created", "vault_key_updated", "vault_key_deleted"} {
+		if !strings.Contains(auditLog.String(), `"event":"`+event+`"`) {
+			t.Errorf("audit log missing %s", event)
+		}
+	}
+}
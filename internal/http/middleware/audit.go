@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/observability/logger"
 )
 
 // AuditLogger creates middleware for security audit logging
@@ -32,7 +34,7 @@ func (a *AuditLogger) Log() func(http.Handler) http.Handler {
 				Str("path", r.URL.Path).
 				Str("remote_addr", r.RemoteAddr).
 				Str("user_agent", r.Header.Get("User-Agent")).
-				Str("referer", r.Header.Get("Referer"))
+				Str("referer", logger.MaskEVKs(r.Header.Get("Referer")))
 
 			// Log if authentication is present
 			if r.Header.Get("Authorization") != "" {
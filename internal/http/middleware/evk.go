@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// EVKHeader is the request header carrying a viewing key
+const EVKHeader = "X-Verus-EVK"
+
+// evkAuthScheme is the Authorization scheme carrying a viewing key
+// ("Authorization: EVK zxviews...")
+const evkAuthScheme = "EVK"
+
+// EVKKey is the context key for the request's viewing key
+const EVKKey contextKey = "evk"
+
+// ViewingKey middleware reads the request's viewing key from the X-Verus-EVK
+// header, an "Authorization: EVK" header or the evk query parameter, in that
+// order, and stores it in the context for GetEVK. With rejectQuery set,
+// requests carrying a key in the query string are refused, since URLs end up
+// in access logs, Referer headers and browser history.
+func ViewingKey(rejectQuery bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queryEVK := r.URL.Query().Get("evk")
+			if rejectQuery && queryEVK != "" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error":      "INVALID_INPUT",
+					"message":    "viewing keys are not accepted in the query string; use the " + EVKHeader + " header",
+					"request_id": GetRequestID(r.Context()),
+				})
+				return
+			}
+
+			evk := strings.TrimSpace(r.Header.Get(EVKHeader))
+			if evk == "" {
+				scheme, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+				if ok && strings.EqualFold(scheme, evkAuthScheme) {
+					evk = strings.TrimSpace(credentials)
+				}
+			}
+			if evk == "" {
+				evk = queryEVK
+			}
+
+			ctx := context.WithValue(r.Context(), EVKKey, evk)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetEVK retrieves the viewing key stored by ViewingKey from context
+func GetEVK(ctx context.Context) string {
+	if evk, ok := ctx.Value(EVKKey).(string); ok {
+		return evk
+	}
+	return ""
+}
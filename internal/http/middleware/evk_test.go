@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestViewingKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		rejectQuery bool
+		query       string
+		headers     map[string]string
+		wantStatus  int
+		wantEVK     string
+	}{
+		{
+			name:       "header",
+			headers:    map[string]string{EVKHeader: "zxviewsheader"},
+			wantStatus: http.StatusOK,
+			wantEVK:    "zxviewsheader",
+		},
+		{
+			name:       "authorization scheme",
+			headers:    map[string]string{"Authorization": "EVK zxviewsauth"},
+			wantStatus: http.StatusOK,
+			wantEVK:    "zxviewsauth",
+		},
+		{
+			name:       "bearer token is not a viewing key",
+			headers:    map[string]string{"Authorization": "Bearer secret"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "query string",
+			query:      "?evk=zxviewsquery",
+			wantStatus: http.StatusOK,
+			wantEVK:    "zxviewsquery",
+		},
+		{
+			name:       "header takes precedence over query",
+			query:      "?evk=zxviewsquery",
+			headers:    map[string]string{EVKHeader: "zxviewsheader"},
+			wantStatus: http.StatusOK,
+			wantEVK:    "zxviewsheader",
+		},
+		{
+			name:        "query rejected",
+			rejectQuery: true,
+			query:       "?evk=zxviewsquery",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "header allowed when query rejected",
+			rejectQuery: true,
+			headers:     map[string]string{EVKHeader: "zxviewsheader"},
+			wantStatus:  http.StatusOK,
+			wantEVK:     "zxviewsheader",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotEVK string
+			handler := ViewingKey(tt.rejectQuery)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotEVK = GetEVK(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/c/vrsc/file/abc"+tt.query, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotEVK != tt.wantEVK {
+				t.Errorf("GetEVK() = %q, want %q", gotEVK, tt.wantEVK)
+			}
+		})
+	}
+}
@@ -2,8 +2,12 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/netip"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -56,13 +60,20 @@ func Logger(baseLogger *zerolog.Logger) func(next http.Handler) http.Handler {
 			// Get request ID from context
 			requestID := GetRequestID(r.Context())
 
-			// Create request-scoped logger
-			reqLogger := baseLogger.With().
+			// Create request-scoped logger; viewing keys in the query are masked
+			logContext := baseLogger.With().
 				Str("request_id", requestID).
 				Str("method", r.Method).
 				Str("path", r.URL.Path).
-				Str("remote_addr", r.RemoteAddr).
-				Logger()
+				Str("remote_addr", r.RemoteAddr)
+			if r.URL.RawQuery != "" {
+				logContext = logContext.Str("query", logger.MaskEVKs(r.URL.RawQuery))
+			}
+			authInfo := GetAuthInfo(r.Context())
+			if authInfo != nil {
+				logContext = logContext.Str("api_key", authInfo.Principal.Name)
+			}
+			reqLogger := logContext.Logger()
 
 			// Add logger to context
 			ctx := logger.WithContext(r.Context(), &reqLogger)
@@ -83,6 +94,17 @@ func Logger(baseLogger *zerolog.Logger) func(next http.Handler) http.Handler {
 					logEvent = reqLogger.Warn()
 				}
 
+				// Scope checks made by the route groups
+				if authInfo != nil && authInfo.Error != "" {
+					logEvent = logEvent.Str("auth_error", authInfo.Error)
+				}
+				if authInfo != nil && len(authInfo.Required) > 0 {
+					logEvent = logEvent.Strs("scopes", authInfo.Required)
+					if authInfo.Denied != "" {
+						logEvent = logEvent.Str("scope_denied", authInfo.Denied)
+					}
+				}
+
 				logEvent.
 					Int("status", status).
 					Dur("duration", duration).
@@ -198,19 +220,78 @@ func SecurityHeaders(next http.Handler) http.Handler {
 	})
 }
 
-// RealIP middleware extracts the real client IP from headers
-func RealIP(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check X-Forwarded-For header
-		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-			// Take the first IP in the list
-			r.RemoteAddr = xff
-		} else if xrip := r.Header.Get("X-Real-IP"); xrip != "" {
-			r.RemoteAddr = xrip
+// RealIP middleware replaces RemoteAddr with the client IP from
+// X-Forwarded-For or X-Real-IP, but only for requests whose peer is one of
+// the trusted proxies (IP addresses or CIDR ranges); anyone else could set
+// these headers. X-Forwarded-For is read from the right, skipping trusted
+// hops, so entries the client added itself are ignored.
+func RealIP(trustedProxies []string) func(next http.Handler) http.Handler {
+	trusted := parseTrustedProxies(trustedProxies)
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
 		}
+		return false
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, err := parseAddr(r.RemoteAddr)
+			if err != nil || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client := netip.Addr{}
+			if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+				hops := strings.Split(strings.Join(xff, ","), ",")
+				for i := len(hops) - 1; i >= 0; i-- {
+					addr, err := parseAddr(hops[i])
+					if err != nil {
+						break
+					}
+					client = addr
+					if !isTrusted(addr) {
+						break
+					}
+				}
+			} else if addr, err := parseAddr(r.Header.Get("X-Real-IP")); err == nil {
+				client = addr
+			}
+			if client.IsValid() {
+				r.RemoteAddr = client.String()
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseTrustedProxies parses IP addresses and CIDR ranges, skipping
+// invalid entries
+func parseTrustedProxies(entries []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
+}
+
+// parseAddr parses an IP address with or without a port
+func parseAddr(value string) (netip.Addr, error) {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(value)
+	return addr.Unmap(), err
 }
 
 // Timeout middleware adds a timeout to requests
@@ -225,3 +306,14 @@ func Timeout(timeout time.Duration) func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// writeJSONError writes an error response in the handlers' format
+func writeJSONError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      code,
+		"message":    message,
+		"request_id": GetRequestID(r.Context()),
+	})
+}
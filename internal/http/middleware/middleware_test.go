@@ -131,6 +131,26 @@ func TestLogger_LogsRequest(t *testing.T) {
 	}
 }
 
+func TestLogger_MasksViewingKeys(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := zerolog.New(&buf)
+
+	handler := Logger(&testLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/c/vrsc/file/abc?evk=zxviews1qsecretkey42&compression=gzip", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	logOutput := buf.String()
+	if contains(logOutput, "zxviews1qsecretkey42") {
+		t.Errorf("log output contains the viewing key: %s", logOutput)
+	}
+	if !contains(logOutput, "evk=zx****42&compression=gzip") {
+		t.Errorf("log output does not contain the masked query: %s", logOutput)
+	}
+}
+
 func TestLogger_LogsErrorStatus(t *testing.T) {
 	// Create a buffer to capture log output
 	var buf bytes.Buffer
@@ -362,80 +382,74 @@ func TestSecurityHeaders(t *testing.T) {
 	}
 }
 
-func TestRealIP_XForwardedFor(t *testing.T) {
-	// Create a test handler
-	var capturedIP string
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		capturedIP = r.RemoteAddr
-		w.WriteHeader(http.StatusOK)
-	})
-
-	// Wrap with RealIP middleware
-	wrappedHandler := RealIP(handler)
-
-	// Create test request with X-Forwarded-For header
-	req := httptest.NewRequest("GET", "/test", nil)
-	req.Header.Set("X-Forwarded-For", "192.168.1.100")
-	req.RemoteAddr = "10.0.0.1:12345"
-	rec := httptest.NewRecorder()
-
-	// Serve the request
-	wrappedHandler.ServeHTTP(rec, req)
-
-	// Verify RemoteAddr was updated
-	if capturedIP != "192.168.1.100" {
-		t.Errorf("Expected RemoteAddr to be 192.168.1.100, got %s", capturedIP)
-	}
-}
-
-func TestRealIP_XRealIP(t *testing.T) {
-	// Create a test handler
-	var capturedIP string
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		capturedIP = r.RemoteAddr
-		w.WriteHeader(http.StatusOK)
-	})
-
-	// Wrap with RealIP middleware
-	wrappedHandler := RealIP(handler)
-
-	// Create test request with X-Real-IP header
-	req := httptest.NewRequest("GET", "/test", nil)
-	req.Header.Set("X-Real-IP", "192.168.1.200")
-	req.RemoteAddr = "10.0.0.1:12345"
-	rec := httptest.NewRecorder()
-
-	// Serve the request
-	wrappedHandler.ServeHTTP(rec, req)
-
-	// Verify RemoteAddr was updated
-	if capturedIP != "192.168.1.200" {
-		t.Errorf("Expected RemoteAddr to be 192.168.1.200, got %s", capturedIP)
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "x-forwarded-for from trusted proxy",
+			remoteAddr: "10.0.0.1:12345",
+			headers:    map[string]string{"X-Forwarded-For": "192.168.1.100"},
+			want:       "192.168.1.100",
+		},
+		{
+			name:       "x-real-ip from trusted proxy",
+			remoteAddr: "10.0.0.1:12345",
+			headers:    map[string]string{"X-Real-IP": "192.168.1.200"},
+			want:       "192.168.1.200",
+		},
+		{
+			name:       "client-supplied entries are skipped",
+			remoteAddr: "10.0.0.1:12345",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.7"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "[::1]:12345",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.7, 10.0.0.2"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "untrusted peer headers ignored",
+			remoteAddr: "198.51.100.7:12345",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.9", "X-Real-IP": "203.0.113.9"},
+			want:       "198.51.100.7:12345",
+		},
+		{
+			name:       "invalid hop stops the walk",
+			remoteAddr: "10.0.0.1:12345",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.9, not-an-ip"},
+			want:       "10.0.0.1:12345",
+		},
+		{
+			name:       "no headers",
+			remoteAddr: "10.0.0.1:12345",
+			want:       "10.0.0.1:12345",
+		},
 	}
-}
 
-func TestRealIP_NoHeaders(t *testing.T) {
-	// Create a test handler
-	var capturedIP string
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		capturedIP = r.RemoteAddr
-		w.WriteHeader(http.StatusOK)
-	})
-
-	// Wrap with RealIP middleware
-	wrappedHandler := RealIP(handler)
-
-	// Create test request without headers
-	req := httptest.NewRequest("GET", "/test", nil)
-	req.RemoteAddr = "10.0.0.1:12345"
-	rec := httptest.NewRecorder()
-
-	// Serve the request
-	wrappedHandler.ServeHTTP(rec, req)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedIP string
+			handler := RealIP([]string{"10.0.0.0/8", "::1", "invalid"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				capturedIP = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
 
-	// Verify RemoteAddr was not changed
-	if capturedIP != "10.0.0.1:12345" {
-		t.Errorf("Expected RemoteAddr to be 10.0.0.1:12345, got %s", capturedIP)
+			if capturedIP != tt.want {
+				t.Errorf("RemoteAddr = %s, want %s", capturedIP, tt.want)
+			}
+		})
 	}
 }
 
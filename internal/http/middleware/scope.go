@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+)
+
+// AuthKey is the context key for the request's AuthInfo
+const AuthKey contextKey = "auth"
+
+// AuthInfo records who made a request and the scope checks it went
+// through, for the request log
+type AuthInfo struct {
+	Principal *auth.Principal
+	Required  []string // Scopes checked, in order
+	Denied    string   // Scope the request was refused for
+	Error     string   // Why a presented token was rejected
+}
+
+// Authenticate middleware identifies the caller by the API key in the
+// X-API-Key header or a Bearer token, or by a JWT Bearer token when tokens
+// is set. Requests without a recognized credential run as the anonymous
+// principal; route groups decide what that may do with RequireScope. Keys
+// for other purposes (share links, the vault) are checked by their own
+// middleware.
+func Authenticate(keys *auth.Keyring, tokens *auth.JWTVerifier, anonymous *auth.Principal) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := &AuthInfo{}
+			if principal, ok := keys.Lookup(requestAPIKey(r)); ok {
+				info.Principal = principal
+			} else if token := bearerToken(r); tokens != nil && auth.LooksLikeJWT(token) {
+				principal, err := tokens.Verify(r.Context(), token)
+				if err != nil {
+					info.Error = err.Error()
+				} else {
+					info.Principal = principal
+				}
+			}
+			if info.Principal == nil {
+				info.Principal = anonymous
+			}
+
+			ctx := context.WithValue(r.Context(), AuthKey, info)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientCertificate middleware identifies the caller by the subject of its
+// verified TLS client certificate, for listeners that require one. Subjects
+// are matched in RFC 2253 form ("CN=ops,O=Example"); certificates with
+// other subjects get no scopes.
+func ClientCertificate(subjects map[string][]string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := &AuthInfo{Principal: auth.Anonymous(nil)}
+			if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+				subject := r.TLS.VerifiedChains[0][0].Subject.String()
+				info.Principal = &auth.Principal{Name: auth.CertNamePrefix + subject, Scopes: subjects[subject]}
+			}
+
+			ctx := context.WithValue(r.Context(), AuthKey, info)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetAuthInfo retrieves the AuthInfo stored by Authenticate from context
+func GetAuthInfo(ctx context.Context) *AuthInfo {
+	if info, ok := ctx.Value(AuthKey).(*AuthInfo); ok {
+		return info
+	}
+	return nil
+}
+
+// RequireScope middleware refuses requests whose principal lacks scope, or
+// may not access the route's {chain}. Anonymous callers get 401 so that
+// clients know to send a key; authenticated ones get 403.
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !checkScope(w, r, scope) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePrivateScope middleware requires files:private for requests that
+// carry their own viewing key. Must run after ViewingKey and before
+// VaultKey: keys from the vault and share links are authorized by their own
+// credentials.
+func RequirePrivateScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetEVK(r.Context()) != "" && !checkScope(w, r, auth.ScopeFilesPrivate) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthorizeViewingKey requires files:private for a viewing key the caller
+// supplied outside the headers and query string, such as in a request body.
+// It writes the refusal and reports false when the check fails.
+func AuthorizeViewingKey(w http.ResponseWriter, r *http.Request) bool {
+	return checkScope(w, r, auth.ScopeFilesPrivate)
+}
+
+// checkScope records a scope check and writes the refusal if it fails
+func checkScope(w http.ResponseWriter, r *http.Request, scope string) bool {
+	info := GetAuthInfo(r.Context())
+	if info == nil {
+		// Authenticate isn't installed; nothing to enforce
+		return true
+	}
+	info.Required = append(info.Required, scope)
+
+	principal := info.Principal
+	if principal.Has(scope) && principal.AllowsChain(chi.URLParam(r, "chain")) {
+		return true
+	}
+	info.Denied = scope
+
+	if principal.Anonymous() {
+		if info.Error != "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="Verus Gateway", error="invalid_token"`)
+			writeJSONError(w, r, http.StatusUnauthorized, "INVALID_TOKEN", "Bearer token rejected: "+info.Error)
+			return false
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Verus Gateway"`)
+		writeJSONError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "API key with scope "+scope+" required")
+		return false
+	}
+	writeJSONError(w, r, http.StatusForbidden, "FORBIDDEN", "API key lacks scope "+scope+" or access to this chain")
+	return false
+}
+
+// requestAPIKey returns the API key sent in X-API-Key or as a Bearer token
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return bearerToken(r)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+)
+
+func TestRequireScope(t *testing.T) {
+	ring, err := auth.NewKeyring([]auth.Key{
+		{Name: "ops", Hash: auth.HashKey("ops-key"), Scopes: []string{auth.ScopeAdminCache}},
+		{Name: "reader", Hash: auth.HashKey("reader-key"), Scopes: []string{auth.ScopeFilesRead}, Chains: []string{"vrsc"}},
+		{Name: "private", Hash: auth.HashKey("private-key"), Scopes: []string{auth.ScopeFilesRead, auth.ScopeFilesPrivate}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r := chi.NewRouter()
+	r.Use(Authenticate(ring, nil, auth.Anonymous([]string{auth.ScopeFilesRead})))
+	r.With(RequireScope(auth.ScopeAdminCache)).Delete("/admin/cache", ok)
+	r.Route("/c/{chain}", func(r chi.Router) {
+		r.Use(RequireScope(auth.ScopeFilesRead))
+		r.Use(ViewingKey(false))
+		r.Use(RequirePrivateScope)
+		r.Get("/file/{txid}", ok)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"anonymous admin", http.MethodDelete, "/admin/cache", nil, http.StatusUnauthorized},
+		{"unknown key admin", http.MethodDelete, "/admin/cache", map[string]string{"X-API-Key": "nope"}, http.StatusUnauthorized},
+		{"admin key", http.MethodDelete, "/admin/cache", map[string]string{"X-API-Key": "ops-key"}, http.StatusOK},
+		{"bearer admin key", http.MethodDelete, "/admin/cache", map[string]string{"Authorization": "Bearer ops-key"}, http.StatusOK},
+		{"reader on admin", http.MethodDelete, "/admin/cache", map[string]string{"X-API-Key": "reader-key"}, http.StatusForbidden},
+		{"anonymous public file", http.MethodGet, "/c/vrsctest/file/abc", nil, http.StatusOK},
+		{"admin key lacks files:read", http.MethodGet, "/c/vrsc/file/abc", map[string]string{"X-API-Key": "ops-key"}, http.StatusForbidden},
+		{"reader allowed chain", http.MethodGet, "/c/vrsc/file/abc", map[string]string{"X-API-Key": "reader-key"}, http.StatusOK},
+		{"reader other chain", http.MethodGet, "/c/vrsctest/file/abc", map[string]string{"X-API-Key": "reader-key"}, http.StatusForbidden},
+		{"anonymous viewing key", http.MethodGet, "/c/vrsctest/file/abc", map[string]string{EVKHeader: "zxviews1"}, http.StatusUnauthorized},
+		{"private viewing key", http.MethodGet, "/c/vrsctest/file/abc", map[string]string{"X-API-Key": "private-key", EVKHeader: "zxviews1"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLogger_RecordsScopes(t *testing.T) {
+	ring, _ := auth.NewKeyring([]auth.Key{
+		{Name: "reader", Hash: auth.HashKey("reader-key"), Scopes: []string{auth.ScopeFilesRead}},
+	})
+
+	var logs bytes.Buffer
+	log := zerolog.New(&logs)
+
+	r := chi.NewRouter()
+	r.Use(Authenticate(ring, nil, auth.Anonymous(nil)))
+	r.Use(Logger(&log))
+	r.With(RequireScope(auth.ScopeAdminCache)).Delete("/admin/cache", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/cache", nil)
+	req.Header.Set("X-API-Key", "reader-key")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, want := range []string{`"api_key":"reader"`, `"scopes":["admin:cache"]`, `"scope_denied":"admin:cache"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("request log missing %s:\n%s", want, logs.String())
+		}
+	}
+	if strings.Contains(logs.String(), "reader-key") {
+		t.Error("request log contains the API key")
+	}
+}
+
+func TestAuthenticate_JWT(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k1","x":%q}]}`, base64.RawURLEncoding.EncodeToString(pub))
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, []byte(jwks), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := auth.NewJWTVerifier(auth.JWTConfig{Issuer: "https://idp.example.com", Audiences: []string{"verus-gateway"}, JWKSFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(claims string) string {
+		input := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","kid":"k1"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+		return input + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, []byte(input)))
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	admin := sign(fmt.Sprintf(`{"iss":"https://idp.example.com","aud":"verus-gateway","sub":"ops","exp":%d,"scope":"admin:cache"}`, exp))
+	expired := sign(`{"iss":"https://idp.example.com","aud":"verus-gateway","sub":"ops","exp":1,"scope":"admin:cache"}`)
+
+	ring, _ := auth.NewKeyring(nil)
+	var logs bytes.Buffer
+	log := zerolog.New(&logs)
+	r := chi.NewRouter()
+	r.Use(Authenticate(ring, tokens, auth.Anonymous([]string{auth.ScopeFilesRead})))
+	r.Use(Logger(&log))
+	r.With(RequireScope(auth.ScopeAdminCache)).Delete("/admin/cache", func(w http.ResponseWriter, r *http.Request) {})
+	r.With(RequireScope(auth.ScopeFilesRead)).Get("/chains", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		token      string
+		wantStatus int
+		wantLog    string
+	}{
+		{"valid token", http.MethodDelete, "/admin/cache", admin, http.StatusOK, `"api_key":"jwt:ops"`},
+		{"expired token", http.MethodDelete, "/admin/cache", expired, http.StatusUnauthorized, `"auth_error":"invalid token: token has expired"`},
+		{"expired token on public route", http.MethodGet, "/chains", expired, http.StatusOK, `"api_key":"anonymous"`},
+		{"token lacks scope", http.MethodGet, "/chains", admin, http.StatusForbidden, `"scope_denied":"files:read"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("request log missing %s:\n%s", tt.wantLog, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/vault"
+)
+
+// VaultKey middleware resolves ?key=<alias> to a viewing key stored in the
+// vault. The caller must present an API key (X-API-Key or a Bearer token)
+// whose ACL entry allows the alias: either a client's own key, matched by
+// its hash, or a gateway API key named like the client. Every use and refusal is written to the
+// audit log. Must run after ViewingKey, whose key it replaces.
+func VaultKey(v *vault.Vault, acl *vault.ACL, audit *zerolog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			alias := r.URL.Query().Get("key")
+			if alias == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			event := func(level zerolog.Level, name string) *zerolog.Event {
+				return audit.WithLevel(level).
+					Str("event", name).
+					Str("alias", alias).
+					Str("chain", chi.URLParam(r, "chain")).
+					Str("path", r.URL.Path).
+					Str("remote_addr", r.RemoteAddr).
+					Str("request_id", GetRequestID(r.Context()))
+			}
+
+			if GetEVK(r.Context()) != "" {
+				writeJSONError(w, r, http.StatusBadRequest, "INVALID_INPUT", "send either a viewing key or a vault key alias, not both")
+				return
+			}
+
+			client, ok := acl.Client(requestAPIKey(r))
+			if info := GetAuthInfo(r.Context()); !ok && info != nil && !info.Principal.Anonymous() {
+				client, ok = acl.ClientNamed(info.Principal.Name)
+			}
+			if !ok {
+				event(zerolog.WarnLevel, "vault_key_denied").Str("reason", "invalid api key").Msg("Vault key use denied")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="Verus Gateway"`)
+				writeJSONError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Valid API key required")
+				return
+			}
+			if !client.Allows(alias) {
+				event(zerolog.WarnLevel, "vault_key_denied").Str("client", client.Name).Str("reason", "not in acl").Msg("Vault key use denied")
+				writeJSONError(w, r, http.StatusForbidden, "FORBIDDEN", "API key may not use this vault key")
+				return
+			}
+
+			evk, err := v.Resolve(alias)
+			if err != nil {
+				event(zerolog.WarnLevel, "vault_key_denied").Str("client", client.Name).Str("reason", "unknown alias").Msg("Vault key use denied")
+				if domainErr, ok := err.(*domain.Error); ok {
+					writeJSONError(w, r, domainErr.HTTPStatus, domainErr.Code, domainErr.Message)
+				} else {
+					writeJSONError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "An internal error occurred")
+				}
+				return
+			}
+
+			event(zerolog.InfoLevel, "vault_key_used").Str("client", client.Name).Str("method", r.Method).Msg("Vault key used")
+
+			ctx := context.WithValue(r.Context(), EVKKey, evk)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
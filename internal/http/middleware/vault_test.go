@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+	"github.com/devdudeio/verus-gateway/internal/vault"
+)
+
+func TestVaultKey(t *testing.T) {
+	const financeEVK = "zxviews1qtest12345678901234567890123456789012345678901234567890123456789012345678901234567890123456"
+
+	v, err := vault.Open(vault.Config{
+		Secret: []byte("0123456789abcdef0123456789abcdef"),
+		File:   filepath.Join(t.TempDir(), "vault.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := v.Put("finance", financeEVK, ""); err != nil {
+		t.Fatal(err)
+	}
+	acl := vault.NewACL([]vault.Client{
+		{Name: "reports", KeyHash: auth.HashKey("reports-key"), Aliases: []string{"finance"}},
+		{Name: "etl", KeyHash: auth.HashKey("etl-key"), Aliases: []string{"*"}},
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		headers    map[string]string
+		wantStatus int
+		wantEVK    string
+		wantAudit  string
+	}{
+		{
+			name:       "no alias passes through",
+			headers:    map[string]string{EVKHeader: "zxviewsheader"},
+			wantStatus: http.StatusOK,
+			wantEVK:    "zxviewsheader",
+		},
+		{
+			name:       "alias resolved",
+			query:      "?key=finance",
+			headers:    map[string]string{"X-API-Key": "reports-key"},
+			wantStatus: http.StatusOK,
+			wantEVK:    financeEVK,
+			wantAudit:  "vault_key_used",
+		},
+		{
+			name:       "bearer token",
+			query:      "?key=finance",
+			headers:    map[string]string{"Authorization": "Bearer etl-key"},
+			wantStatus: http.StatusOK,
+			wantEVK:    financeEVK,
+			wantAudit:  "vault_key_used",
+		},
+		{
+			name:       "missing api key",
+			query:      "?key=finance",
+			wantStatus: http.StatusUnauthorized,
+			wantAudit:  "vault_key_denied",
+		},
+		{
+			name:       "alias not in acl",
+			query:      "?key=legal",
+			headers:    map[string]string{"X-API-Key": "reports-key"},
+			wantStatus: http.StatusForbidden,
+			wantAudit:  "vault_key_denied",
+		},
+		{
+			name:       "unknown alias",
+			query:      "?key=legal",
+			headers:    map[string]string{"X-API-Key": "etl-key"},
+			wantStatus: http.StatusNotFound,
+			wantAudit:  "vault_key_denied",
+		},
+		{
+			name:       "alias and viewing key",
+			query:      "?key=finance",
+			headers:    map[string]string{"X-API-Key": "reports-key", EVKHeader: "zxviewsheader"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var auditLog bytes.Buffer
+			audit := zerolog.New(&auditLog)
+
+			var gotEVK string
+			handler := ViewingKey(false)(VaultKey(v, acl, &audit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotEVK = GetEVK(r.Context())
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, "/c/vrsctest/file/abc"+tt.query, nil)
+			for k, val := range tt.headers {
+				req.Header.Set(k, val)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if gotEVK != tt.wantEVK {
+				t.Errorf("EVK = %q, want %q", gotEVK, tt.wantEVK)
+			}
+			if tt.wantAudit != "" && !strings.Contains(auditLog.String(), `"event":"`+tt.wantAudit+`"`) {
+				t.Errorf("audit log = %q, want %s", auditLog.String(), tt.wantAudit)
+			}
+			if strings.Contains(auditLog.String(), financeEVK) {
+				t.Error("audit log contains the viewing key")
+			}
+		})
+	}
+}
+
+func TestVaultKey_GatewayAPIKey(t *testing.T) {
+	const financeEVK = "zxviews1qtest12345678901234567890123456789012345678901234567890123456789012345678901234567890123456"
+
+	v, err := vault.Open(vault.Config{
+		Secret: []byte("0123456789abcdef0123456789abcdef"),
+		File:   filepath.Join(t.TempDir(), "vault.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := v.Put("finance", financeEVK, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The client has no key of its own; its gateway API key shares its name
+	acl := vault.NewACL([]vault.Client{{Name: "reports", Aliases: []string{"finance"}}})
+	ring, _ := auth.NewKeyring([]auth.Key{
+		{Name: "reports", Hash: auth.HashKey("reports-key"), Scopes: []string{auth.ScopeFilesRead}},
+	})
+	audit := zerolog.Nop()
+
+	var gotEVK string
+	handler := Authenticate(ring, nil, auth.Anonymous(nil))(ViewingKey(false)(VaultKey(v, acl, &audit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEVK = GetEVK(r.Context())
+	}))))
+
+	for key, wantStatus := range map[string]int{"reports-key": http.StatusOK, "": http.StatusUnauthorized} {
+		gotEVK = ""
+		req := httptest.NewRequest(http.MethodGet, "/c/vrsctest/file/abc?key=finance", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != wantStatus || (wantStatus == http.StatusOK) != (gotEVK == financeEVK) {
+			t.Errorf("key %q: status = %d, evk resolved = %v, want %d", key, w.Code, gotEVK == financeEVK, wantStatus)
+		}
+	}
+}
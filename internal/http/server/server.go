@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,25 +12,40 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/rs/zerolog"
 
+	"github.com/devdudeio/verus-gateway/internal/auth"
 	"github.com/devdudeio/verus-gateway/internal/chain"
 	"github.com/devdudeio/verus-gateway/internal/config"
 	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/events"
 	"github.com/devdudeio/verus-gateway/internal/http/handler"
 	"github.com/devdudeio/verus-gateway/internal/http/middleware"
 	"github.com/devdudeio/verus-gateway/internal/observability/metrics"
 	"github.com/devdudeio/verus-gateway/internal/service"
+	"github.com/devdudeio/verus-gateway/internal/share"
+	"github.com/devdudeio/verus-gateway/internal/vault"
 )
 
 // Server represents the HTTP server
 type Server struct {
 	router       *chi.Mux
 	httpServer   *http.Server
+	adminRouter  *chi.Mux     // Mutual TLS admin listener (nil = disabled)
+	adminServer  *http.Server // Serves adminRouter
 	chainManager *chain.Manager
 	cache        domain.Cache
 	config       *config.Config
 	version      string
 	logger       *zerolog.Logger
 	metrics      *metrics.Metrics
+	events       *events.Bus
+	shares       *share.Manager
+	vault        *vault.Vault
+	tokens       *auth.JWTVerifier
+
+	// Internal listener for /metrics, /admin, /debug and health (nil =
+	// metrics and admin are served by router)
+	internalRouter *chi.Mux
+	internalServer *http.Server
 }
 
 // Config holds server configuration
@@ -40,6 +56,10 @@ type Config struct {
 	Version      string
 	Logger       *zerolog.Logger
 	Metrics      *metrics.Metrics
+	Events       *events.Bus       // Event bus for GET /events (nil = disabled)
+	Shares       *share.Manager    // Share link manager (nil = disabled)
+	Vault        *vault.Vault      // Viewing key vault (nil = disabled)
+	Tokens       *auth.JWTVerifier // JWT bearer token verifier (nil = disabled)
 }
 
 // New creates a new HTTP server
@@ -52,6 +72,16 @@ func New(cfg Config) *Server {
 		version:      cfg.Version,
 		logger:       cfg.Logger,
 		metrics:      cfg.Metrics,
+		events:       cfg.Events,
+		shares:       cfg.Shares,
+		vault:        cfg.Vault,
+		tokens:       cfg.Tokens,
+	}
+	if cfg.Config.Server.Admin.Enabled {
+		s.adminRouter = chi.NewRouter()
+	}
+	if cfg.Config.Server.Internal.Enabled {
+		s.internalRouter = chi.NewRouter()
 	}
 
 	// Setup middleware
@@ -68,10 +98,29 @@ func New(cfg Config) *Server {
 		WriteTimeout: time.Duration(cfg.Config.Server.WriteTimeout) * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
+	if s.adminRouter != nil {
+		admin := cfg.Config.Server.Admin
+		s.adminServer = s.newListener(admin.Host, admin.Port, s.adminRouter)
+	}
+	if s.internalRouter != nil {
+		internal := cfg.Config.Server.Internal
+		s.internalServer = s.newListener(internal.Host, internal.Port, s.internalRouter)
+	}
 
 	return s
 }
 
+// newListener creates an additional HTTP server with the configured timeouts
+func (s *Server) newListener(host string, port int, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", host, port),
+		Handler:      handler,
+		ReadTimeout:  s.config.Server.ReadTimeout,
+		WriteTimeout: s.config.Server.WriteTimeout,
+		IdleTimeout:  s.config.Server.IdleTimeout,
+	}
+}
+
 // setupMiddleware configures middleware stack
 func (s *Server) setupMiddleware() {
 	// Recoverer - must be first to catch panics in other middleware
@@ -80,8 +129,12 @@ func (s *Server) setupMiddleware() {
 	// Request ID - add unique ID to each request
 	s.router.Use(middleware.RequestID)
 
-	// Real IP - extract real client IP
-	s.router.Use(middleware.RealIP)
+	// Real IP - extract real client IP from headers set by trusted proxies
+	s.router.Use(middleware.RealIP(s.config.Security.TrustedProxies))
+
+	// Authenticate - identify the API key or JWT; route groups check its scopes
+	authenticate := middleware.Authenticate(s.keyring(), s.tokens, auth.Anonymous(s.config.Security.AnonymousScopes))
+	s.router.Use(authenticate)
 
 	// Logger - log all requests with structured logging
 	s.router.Use(middleware.Logger(s.logger))
@@ -91,9 +144,6 @@ func (s *Server) setupMiddleware() {
 		s.router.Use(middleware.Metrics(s.metrics))
 	}
 
-	// Timeout - add request timeout
-	s.router.Use(middleware.Timeout(time.Duration(s.config.Server.ReadTimeout) * time.Second))
-
 	// Security headers
 	s.router.Use(middleware.SecurityHeaders)
 
@@ -102,8 +152,8 @@ func (s *Server) setupMiddleware() {
 		s.router.Use(cors.Handler(cors.Options{
 			AllowedOrigins:   s.config.Security.CORS.AllowedOrigins,
 			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD"},
-			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
-			ExposedHeaders:   []string{"X-Request-ID", "Content-Disposition"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-API-Key", middleware.EVKHeader},
+			ExposedHeaders:   []string{"X-Request-ID", "Content-Disposition", "X-Verus-Block-Height", "X-Verus-Confirmations"},
 			AllowCredentials: false,
 			MaxAge:           300,
 		}))
@@ -111,49 +161,302 @@ func (s *Server) setupMiddleware() {
 
 	// Compress responses
 	s.router.Use(chimiddleware.Compress(5))
+
+	// The admin listener identifies callers by their client certificate
+	if s.adminRouter != nil {
+		subjects := make(map[string][]string, len(s.config.Server.Admin.Clients))
+		for _, c := range s.config.Server.Admin.Clients {
+			subjects[c.Subject] = c.Scopes
+		}
+
+		s.adminRouter.Use(middleware.Recoverer(s.logger))
+		s.adminRouter.Use(middleware.RequestID)
+		s.adminRouter.Use(middleware.ClientCertificate(subjects))
+		s.adminRouter.Use(middleware.Logger(s.logger))
+		if s.metrics != nil {
+			s.adminRouter.Use(middleware.Metrics(s.metrics))
+		}
+		s.adminRouter.Use(middleware.SecurityHeaders)
+	}
+
+	// The internal listener has no proxy in front and authenticates like
+	// the main one
+	if s.internalRouter != nil {
+		s.internalRouter.Use(middleware.Recoverer(s.logger))
+		s.internalRouter.Use(middleware.RequestID)
+		s.internalRouter.Use(authenticate)
+		s.internalRouter.Use(middleware.Logger(s.logger))
+		if s.metrics != nil {
+			s.internalRouter.Use(middleware.Metrics(s.metrics))
+		}
+		s.internalRouter.Use(middleware.SecurityHeaders)
+	}
 }
 
 // setupRoutes configures all HTTP routes
 func (s *Server) setupRoutes() {
 	// Create services
-	fileService := service.NewFileService(s.chainManager, s.cache)
+	fileService := service.NewFileService(s.chainManager, s.cache, s.metrics)
+	if err := fileService.RegisterSignatures(s.config.Detection.Signatures); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to register custom file signatures")
+	}
+
+	// Bind encrypted cache entries to an HMAC of the viewing key
+	if s.config.Cache.KeySecret != "" {
+		fileService.SetCacheKeySecret([]byte(s.config.Cache.KeySecret))
+	} else {
+		s.logger.Warn().Msg("cache.key_secret not set; encrypted cache entries will not survive restarts")
+	}
+
+	// Invalidate cached files from blocks orphaned by a reorg
+	s.chainManager.OnBlock(fileService.HandleBlockEvent)
+
+	// Publish chain, cache and file events
+	fileService.SetEvents(s.events)
+	s.publishChainEvents()
 
 	// Create handlers
+	cachePolicy := handler.NewCachePolicy(s.config.HTTPCache)
 	fileHandler := handler.NewFileHandler(fileService)
+	fileHandler.SetEvents(s.events)
+	fileHandler.SetCachePolicy(cachePolicy)
+	siteHandler := handler.NewSiteHandler(fileService, s.config.Sites)
+	siteHandler.SetCachePolicy(cachePolicy)
+	siteHandler.SetEvents(s.events)
+	fileHandler.SetShares(s.shares)
+	shareHandler := handler.NewShareHandler(fileService, s.shares, s.config.Sharing.BaseURL)
+	shareHandler.SetCachePolicy(cachePolicy)
 	adminHandler := handler.NewAdminHandler(fileService, s.chainManager, s.metrics, s.version)
 
-	// Health endpoints (no prefix)
-	s.router.Get("/health", adminHandler.Health)
-	s.router.Get("/ready", adminHandler.Ready)
-	s.router.Get("/metrics", adminHandler.PrometheusMetrics)
-	s.router.Get("/chains", adminHandler.ListChains)
-
-	// Chain-specific API endpoints - ALL API calls must include chain
-	s.router.Route("/c/{chain}", func(r chi.Router) {
-		r.Get("/file/{txid}", fileHandler.GetFile)
-		r.Head("/file/{txid}", fileHandler.HeadFile)
-		r.Get("/meta/{txid}", fileHandler.GetMeta)
+	// Admin endpoints
+	adminRoutes := func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeAdminCache))
+			r.Get("/cache/stats", adminHandler.GetCacheStats)
+			r.Delete("/cache", adminHandler.ClearCache)
+			r.Delete("/cache/{key}", adminHandler.DeleteCacheEntry)
+		})
+
+		// Node -blocknotify hook; security.blocknotify_token acts as a
+		// key with the admin:chains scope
+		r.With(middleware.RequireScope(auth.ScopeAdminChains)).Post("/chains/{chain}/blocknotify", adminHandler.BlockNotify)
+
+		// Share links are revoked with the scope that issues them
+		if s.shares != nil {
+			r.With(middleware.RequireScope(auth.ScopeSharesWrite)).Delete("/shares/{id}", shareHandler.Revoke)
+		}
+
+		// Viewing key vault; stored keys are never returned
+		if s.vault != nil {
+			vaultHandler := handler.NewVaultHandler(s.vault, s.logger)
+			r.Route("/vault", func(r chi.Router) {
+				r.Use(middleware.RequireScope(auth.ScopeAdminVault))
+				r.Get("/", vaultHandler.List)
+				r.Get("/{alias}", vaultHandler.Get)
+				r.Put("/{alias}", vaultHandler.Put)
+				r.Delete("/{alias}", vaultHandler.Delete)
+			})
+		}
+	}
+
+	// Endpoints of the internal and admin listeners
+	metricsCfg := s.config.Observability.Metrics
+	internalRoutes := func(r chi.Router) {
+		// Profiling; long-running, so outside the request timeout
+		r.With(middleware.RequireScope(auth.ScopeAdminDebug)).Mount("/debug", chimiddleware.Profiler())
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Timeout(s.config.Server.ReadTimeout))
+			r.Get("/health", adminHandler.Health)
+			r.Get("/ready", adminHandler.Ready)
+			if metricsCfg.Enabled {
+				r.With(middleware.RequireScope(auth.ScopeMetrics)).Get(metricsCfg.Path, adminHandler.PrometheusMetrics)
+			}
+			r.Route("/admin", adminRoutes)
+		})
+	}
+
+	// Event stream; long-lived, so it is registered outside the request timeout
+	if s.events != nil {
+		eventsHandler := handler.NewEventsHandler(s.events, s.config.Events.Heartbeat)
+		s.router.With(middleware.RequireScope(auth.ScopeEventsRead)).Get("/events", eventsHandler.Stream)
+	}
+
+	s.router.Group(func(r chi.Router) {
+		// Timeout - add request timeout
+		r.Use(middleware.Timeout(time.Duration(s.config.Server.ReadTimeout) * time.Second))
+
+		// Health endpoints (no prefix)
+		r.Get("/health", adminHandler.Health)
+		r.Get("/ready", adminHandler.Ready)
+		r.With(middleware.RequireScope(auth.ScopeFilesRead)).Get("/chains", adminHandler.ListChains)
+
+		// Chain-specific API endpoints - ALL API calls must include chain
+		r.Route("/c/{chain}", func(r chi.Router) {
+			r.Use(middleware.RequireScope(auth.ScopeFilesRead))
+
+			// Viewing keys from headers or, unless disabled, the query string;
+			// decrypting with one requires files:private
+			r.Use(middleware.ViewingKey(s.config.Security.RejectQueryEVK))
+			r.Use(middleware.RequirePrivateScope)
+
+			// Viewing keys from the vault by alias (?key=), per API key ACL
+			if s.vault != nil {
+				r.Use(middleware.VaultKey(s.vault, s.vaultACL(), s.logger))
+			}
+
+			r.Get("/file/{txid}", fileHandler.GetFile)
+			r.Post("/file/{txid}", fileHandler.PostFile)
+			r.Head("/file/{txid}", fileHandler.HeadFile)
+			r.Get("/file/{txid}/entries", fileHandler.ListEntries)
+			r.Get("/file/{txid}/entries/*", fileHandler.GetEntry)
+			r.Get("/meta/{txid}", fileHandler.GetMeta)
+			r.Get("/dir/{txid}", fileHandler.GetDir)
+			r.Get("/dir/{txid}/*", fileHandler.GetDir)
+			r.Get("/site/{txid}", siteHandler.GetSite)
+			r.Get("/site/{txid}/*", siteHandler.GetSite)
+
+			// Share links to encrypted files; issuing requires shares:write
+			if s.shares != nil {
+				r.With(middleware.RequireScope(auth.ScopeSharesWrite)).Post("/share", shareHandler.Create)
+			}
+		})
+
 	})
 
-	// Admin endpoints
-	s.router.Route("/admin", func(r chi.Router) {
-		// TODO: Add authentication middleware in Phase 11
-		r.Get("/cache/stats", adminHandler.GetCacheStats)
-		r.Delete("/cache", adminHandler.ClearCache)
-		r.Delete("/cache/{key}", adminHandler.DeleteCacheEntry)
+	if s.internalRouter != nil {
+		s.internalRouter.Group(internalRoutes)
+	}
+	if s.adminRouter != nil {
+		s.adminRouter.Group(internalRoutes)
+	}
+}
+
+// keyring builds the API keyring from the configured keys and the
+// blocknotify token. Keys are validated with the configuration, so a
+// failure leaves only anonymous access.
+func (s *Server) keyring() *auth.Keyring {
+	keys := make([]auth.Key, 0, len(s.config.Security.APIKeys)+1)
+	for _, k := range s.config.Security.APIKeys {
+		keys = append(keys, auth.Key{Name: k.Name, Hash: k.KeyHash, Scopes: k.Scopes, Chains: k.Chains})
+	}
+	if token := s.config.Security.BlockNotifyToken; token != "" {
+		keys = append(keys, auth.Key{Name: "blocknotify", Hash: auth.HashKey(token), Scopes: []string{auth.ScopeAdminChains}})
+	}
+
+	ring, err := auth.NewKeyring(keys)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Invalid API keys; only anonymous access is available")
+		ring, _ = auth.NewKeyring(nil)
+	}
+	return ring
+}
+
+// vaultACL builds the vault ACL from the configured clients
+func (s *Server) vaultACL() *vault.ACL {
+	clients := make([]vault.Client, 0, len(s.config.Vault.Clients))
+	for _, c := range s.config.Vault.Clients {
+		clients = append(clients, vault.Client{Name: c.Name, KeyHash: c.KeyHash, Aliases: c.Aliases})
+	}
+	return vault.NewACL(clients)
+}
+
+// publishChainEvents forwards tip changes, reorgs and health transitions
+// from the chain manager to the event bus
+func (s *Server) publishChainEvents() {
+	if s.events == nil {
+		return
+	}
+
+	s.chainManager.OnBlock(func(event chain.BlockEvent) {
+		if len(event.Orphaned) > 0 {
+			orphaned := make([]map[string]interface{}, 0, len(event.Orphaned))
+			for _, block := range event.Orphaned {
+				orphaned = append(orphaned, map[string]interface{}{"height": block.Height, "hash": block.Hash})
+			}
+			s.events.Publish(events.TopicReorg, event.ChainID, map[string]interface{}{
+				"height":   event.Tip.Height,
+				"hash":     event.Tip.Hash,
+				"orphaned": orphaned,
+			})
+		}
+		s.events.Publish(events.TopicBlock, event.ChainID, map[string]interface{}{
+			"height": event.Tip.Height,
+			"hash":   event.Tip.Hash,
+		})
+	})
+
+	s.chainManager.OnHealth(func(event chain.HealthEvent) {
+		data := map[string]interface{}{"healthy": event.Healthy}
+		if event.Err != nil {
+			data["error"] = event.Err.Error()
+		}
+		s.events.Publish(events.TopicChainHealth, event.ChainID, data)
 	})
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server and, if enabled, the internal and admin
+// listeners. It returns when any of them stops.
 func (s *Server) Start() error {
-	fmt.Printf("Starting HTTP server on %s\n", s.httpServer.Addr)
-	return s.httpServer.ListenAndServe()
+	// Load certificates first so a bad one fails before anything listens
+	if s.config.Server.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(s.config.Server.TLS, s.config.Server.TLS.CertFile, s.config.Server.TLS.KeyFile, s.logger)
+		if err != nil {
+			return err
+		}
+		s.httpServer.TLSConfig = tlsConfig
+	}
+	if s.adminServer != nil {
+		tlsConfig, err := newAdminTLSConfig(s.config.Server, s.logger)
+		if err != nil {
+			return err
+		}
+		s.adminServer.TLSConfig = tlsConfig
+	}
+
+	errs := make(chan error, 3)
+	serve := func(name string, srv *http.Server) {
+		if srv.TLSConfig != nil {
+			fmt.Printf("Starting %s on %s (TLS)\n", name, srv.Addr)
+			errs <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		fmt.Printf("Starting %s on %s\n", name, srv.Addr)
+		errs <- srv.ListenAndServe()
+	}
+
+	if s.adminServer != nil {
+		go serve("admin server", s.adminServer)
+	}
+	if s.internalServer != nil {
+		go serve("internal server", s.internalServer)
+	}
+	go serve("HTTP server", s.httpServer)
+	return <-errs
 }
 
-// Shutdown gracefully shuts down the HTTP server
+// Shutdown gracefully shuts down all listeners. They drain in parallel,
+// so each has the whole of ctx's deadline.
 func (s *Server) Shutdown(ctx context.Context) error {
 	fmt.Println("Shutting down HTTP server...")
-	return s.httpServer.Shutdown(ctx)
+
+	servers := []*http.Server{s.httpServer}
+	for _, srv := range []*http.Server{s.internalServer, s.adminServer} {
+		if srv != nil {
+			servers = append(servers, srv)
+		}
+	}
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() { errs <- srv.Shutdown(ctx) }()
+	}
+
+	var err error
+	for range servers {
+		err = errors.Join(err, <-errs)
+	}
+	return err
 }
 
 // Router returns the Chi router (useful for testing)
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+	"github.com/devdudeio/verus-gateway/internal/cache"
+	"github.com/devdudeio/verus-gateway/internal/chain"
+	"github.com/devdudeio/verus-gateway/internal/config"
+	"github.com/devdudeio/verus-gateway/internal/share"
+	"github.com/devdudeio/verus-gateway/internal/vault"
+)
+
+// newTestServer creates a server for cfg with an unreachable chain and a
+// filesystem cache; options set optional dependencies
+func newTestServer(t *testing.T, cfg *config.Config, options ...func(*Config)) *Server {
+	t.Helper()
+	cfg.Chains = config.ChainsConfig{
+		Chains: map[string]config.ChainConfig{
+			"test": {Name: "Test", Enabled: true, RPCURL: "http://127.0.0.1:1", RPCTimeout: time.Second},
+		},
+	}
+	manager, err := chain.NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { manager.Close() })
+
+	fsCache, err := cache.NewFilesystemCache(cache.FilesystemCacheConfig{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fsCache.Close() })
+
+	logger := zerolog.Nop()
+	serverCfg := Config{ChainManager: manager, Cache: fsCache, Config: cfg, Version: "test", Logger: &logger}
+	for _, option := range options {
+		option(&serverCfg)
+	}
+	return New(serverCfg)
+}
+
+// listenerConfig enables the internal listener; the ops key may use the
+// admin and profiling endpoints
+func listenerConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			Host:         "127.0.0.1",
+			Port:         8080,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  time.Minute,
+			Internal:     config.InternalListenerConfig{Enabled: true, Host: "127.0.0.1", Port: 9090},
+		},
+		Security: config.SecurityConfig{
+			AnonymousScopes: []string{auth.ScopeFilesRead, auth.ScopeMetrics},
+			APIKeys: []config.APIKeyConfig{
+				{Name: "ops", KeyHash: auth.HashKey("ops-key"), Scopes: []string{auth.ScopeAdminCache, auth.ScopeAdminDebug}},
+			},
+		},
+		Observability: config.ObservabilityConfig{
+			Metrics: config.MetricsConfig{Enabled: true, Path: "/metrics"},
+		},
+	}
+}
+
+func status(h http.Handler, path, key string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestServer_InternalListener(t *testing.T) {
+	s := newTestServer(t, listenerConfig())
+	if s.internalRouter == nil || s.internalServer == nil {
+		t.Fatal("internal listener not created")
+	}
+	if s.internalServer.Addr != "127.0.0.1:9090" {
+		t.Errorf("internal listener address = %s", s.internalServer.Addr)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		path    string
+		key     string
+		want    int
+	}{
+		{"public health", s.router, "/health", "", http.StatusOK},
+		{"public metrics", s.router, "/metrics", "", http.StatusNotFound},
+		{"public admin", s.router, "/admin/cache/stats", "ops-key", http.StatusNotFound},
+		{"public pprof", s.router, "/debug/pprof/", "ops-key", http.StatusNotFound},
+		{"internal health", s.internalRouter, "/health", "", http.StatusOK},
+		{"internal metrics", s.internalRouter, "/metrics", "", http.StatusOK},
+		{"internal admin anonymous", s.internalRouter, "/admin/cache/stats", "", http.StatusUnauthorized},
+		{"internal admin", s.internalRouter, "/admin/cache/stats", "ops-key", http.StatusOK},
+		{"internal pprof anonymous", s.internalRouter, "/debug/pprof/", "", http.StatusUnauthorized},
+		{"internal pprof", s.internalRouter, "/debug/pprof/", "ops-key", http.StatusOK},
+		{"internal files", s.internalRouter, "/c/test/file/abc", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status(tt.handler, tt.path, tt.key); got != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_InternalListenerDisabled(t *testing.T) {
+	// Without the internal listener, admin and metrics endpoints are not
+	// served at all rather than moving to the public listener
+	cfg := listenerConfig()
+	cfg.Server.Internal.Enabled = false
+	s := newTestServer(t, cfg)
+	if s.internalRouter != nil || s.internalServer != nil {
+		t.Fatal("internal listener created while disabled")
+	}
+
+	for _, path := range []string{"/metrics", "/admin/cache/stats", "/debug/pprof/"} {
+		if got := status(s.router, path, "ops-key"); got != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, got)
+		}
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	s := newTestServer(t, listenerConfig())
+	s.httpServer.Addr, s.internalServer.Addr = "127.0.0.1:0", "127.0.0.1:0"
+
+	started := make(chan error, 1)
+	go func() { started <- s.Start() }()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	begin := time.Now()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("Shutdown() took %s", elapsed)
+	}
+	if err := <-started; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Start() error = %v, want ErrServerClosed", err)
+	}
+	for name, srv := range map[string]*http.Server{"public": s.httpServer, "internal": s.internalServer} {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("%s listener still usable after Shutdown: %v", name, err)
+		}
+	}
+}
+
+func TestServer_ShareAndVaultScopes(t *testing.T) {
+	shares, err := share.NewManager(share.Config{Secret: []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyVault, err := vault.Open(vault.Config{
+		Secret: []byte("0123456789abcdef0123456789abcdef"),
+		File:   filepath.Join(t.TempDir(), "vault.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := listenerConfig()
+	cfg.Security.APIKeys = []config.APIKeyConfig{
+		{Name: "reader", KeyHash: auth.HashKey("reader-key"), Scopes: []string{auth.ScopeFilesRead, auth.ScopeFilesPrivate}},
+		{Name: "issuer", KeyHash: auth.HashKey("issuer-key"), Scopes: []string{auth.ScopeFilesRead, auth.ScopeSharesWrite}},
+		{Name: "vault-admin", KeyHash: auth.HashKey("vault-key"), Scopes: []string{auth.ScopeAdminVault}},
+	}
+	s := newTestServer(t, cfg, func(c *Config) {
+		c.Shares = shares
+		c.Vault = keyVault
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		path    string
+		key     string
+		want    int
+	}{
+		{"anonymous issue", s.router, http.MethodPost, "/c/test/share", "", http.StatusUnauthorized},
+		{"reader issue", s.router, http.MethodPost, "/c/test/share", "reader-key", http.StatusForbidden},
+		{"issuer issue", s.router, http.MethodPost, "/c/test/share", "issuer-key", http.StatusBadRequest},
+		{"anonymous revoke", s.internalRouter, http.MethodDelete, "/admin/shares/abc", "", http.StatusUnauthorized},
+		{"vault admin revoke", s.internalRouter, http.MethodDelete, "/admin/shares/abc", "vault-key", http.StatusForbidden},
+		{"issuer revoke", s.internalRouter, http.MethodDelete, "/admin/shares/abc", "issuer-key", http.StatusBadRequest},
+		{"anonymous vault", s.internalRouter, http.MethodGet, "/admin/vault/", "", http.StatusUnauthorized},
+		{"issuer vault", s.internalRouter, http.MethodGet, "/admin/vault/", "issuer-key", http.StatusForbidden},
+		{"vault admin vault", s.internalRouter, http.MethodGet, "/admin/vault/", "vault-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_CORSPreflight(t *testing.T) {
+	cfg := listenerConfig()
+	cfg.Security.CORS = config.CORSConfig{Enabled: true, AllowedOrigins: []string{"https://app.example.com"}}
+	s := newTestServer(t, cfg)
+
+	for _, header := range []string{"X-API-Key", "Authorization", "X-Verus-EVK"} {
+		t.Run(header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/c/test/file/abc", nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			req.Header.Set("Access-Control-Request-Headers", header)
+			rec := httptest.NewRecorder()
+			s.router.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+				t.Errorf("Access-Control-Allow-Origin = %q", got)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Headers"); !strings.EqualFold(got, header) {
+				t.Errorf("Access-Control-Allow-Headers = %q, want %s", got, header)
+			}
+		})
+	}
+}
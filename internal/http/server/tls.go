@@ -0,0 +1,145 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/config"
+)
+
+// certCheckInterval limits how often certificate files are checked for
+// changes during handshakes
+const certCheckInterval = 5 * time.Second
+
+// certReloader serves a certificate and reloads it when its files change,
+// so renewed certificates are picked up without a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *zerolog.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	stamp   string // Modification times and sizes of the loaded files
+	checked time.Time
+	now     func() time.Time
+}
+
+// newCertReloader loads a certificate and key
+func newCertReloader(certFile, keyFile string, logger *zerolog.Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, logger: logger, now: time.Now}
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(stamp); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checked) >= certCheckInterval {
+		r.checked = now
+		r.reloadIfChanged()
+	}
+	return r.cert, nil
+}
+
+// reloadIfChanged reloads the certificate if its files changed. A failed
+// reload keeps the previous certificate; renewals commonly replace the two
+// files one after the other, so it is retried on the next check.
+func (r *certReloader) reloadIfChanged() {
+	stamp, err := r.fileStamp()
+	if err != nil || stamp == r.stamp {
+		return
+	}
+	if err := r.load(stamp); err != nil {
+		r.logger.Warn().Err(err).Str("cert_file", r.certFile).Msg("Failed to reload TLS certificate; keeping the previous one")
+		return
+	}
+	r.logger.Info().Str("cert_file", r.certFile).Msg("TLS certificate reloaded")
+}
+
+// load reads the certificate and key
+func (r *certReloader) load(stamp string) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	r.cert = &cert
+	r.stamp = stamp
+	return nil
+}
+
+// fileStamp identifies the current contents of the certificate files.
+// os.Stat follows symlinks, so swapped Kubernetes secret mounts are noticed.
+func (r *certReloader) fileStamp() (string, error) {
+	stamp := ""
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read TLS certificate: %w", err)
+		}
+		stamp += fmt.Sprintf("%d:%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp, nil
+}
+
+// newTLSConfig builds the TLS configuration for a listener
+func newTLSConfig(cfg config.TLSConfig, certFile, keyFile string, logger *zerolog.Logger) (*tls.Config, error) {
+	version, err := cfg.Version()
+	if err != nil {
+		return nil, err
+	}
+	ciphers, err := cfg.Ciphers()
+	if err != nil {
+		return nil, err
+	}
+	certs, err := newCertReloader(certFile, keyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:     version,
+		CipherSuites:   ciphers,
+		GetCertificate: certs.GetCertificate,
+	}, nil
+}
+
+// newAdminTLSConfig builds the TLS configuration for the admin listener,
+// which requires client certificates issued by the configured CA
+func newAdminTLSConfig(cfg config.ServerConfig, logger *zerolog.Logger) (*tls.Config, error) {
+	certFile, keyFile := cfg.Admin.CertFile, cfg.Admin.KeyFile
+	if certFile == "" {
+		certFile, keyFile = cfg.TLS.CertFile, cfg.TLS.KeyFile
+	}
+	tlsConfig, err := newTLSConfig(cfg.TLS, certFile, keyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	caPEM, err := os.ReadFile(cfg.Admin.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("admin client CA file %s contains no certificates", cfg.Admin.ClientCAFile)
+	}
+
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+	"github.com/devdudeio/verus-gateway/internal/config"
+	"github.com/devdudeio/verus-gateway/internal/http/middleware"
+)
+
+// testCert is a generated certificate and its key
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert creates a certificate signed by parent (nil = self-signed CA)
+func newTestCert(t *testing.T, subject pkix.Name, parent *testCert, client bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.ExtKeyUsage = nil // Unrestricted, so it can issue client and server certificates
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write stores the certificate and key as PEM files
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	keyDER, _ := x509.MarshalECPrivateKey(c.key)
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if keyFile != "" {
+		if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	first := newTestCert(t, pkix.Name{CommonName: "first"}, nil, false)
+	first.write(t, certFile, keyFile)
+
+	logger := zerolog.Nop()
+	r, err := newCertReloader(certFile, keyFile, &logger)
+	if err != nil {
+		t.Fatalf("newCertReloader() error = %v", err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	current := func() string {
+		cert, _ := r.GetCertificate(nil)
+		parsed, _ := x509.ParseCertificate(cert.Certificate[0])
+		return parsed.Subject.CommonName
+	}
+	touch := func() {
+		stamp := now.Add(time.Minute)
+		os.Chtimes(certFile, stamp, stamp)
+		os.Chtimes(keyFile, stamp, stamp)
+	}
+
+	// A renewal that has replaced only the certificate so far keeps serving
+	// the previous pair
+	second := newTestCert(t, pkix.Name{CommonName: "second"}, nil, false)
+	second.write(t, certFile, "")
+	touch()
+	now = now.Add(certCheckInterval)
+	if got := current(); got != "first" {
+		t.Errorf("certificate after partial renewal = %s, want first", got)
+	}
+
+	// Once both files are replaced, the next check picks them up
+	second.write(t, certFile, keyFile)
+	now = now.Add(time.Second)
+	if got := current(); got != "first" {
+		t.Errorf("certificate before the check interval = %s, want first", got)
+	}
+	now = now.Add(certCheckInterval)
+	if got := current(); got != "second" {
+		t.Errorf("certificate after renewal = %s, want second", got)
+	}
+}
+
+func TestAdminTLS_ClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, pkix.Name{CommonName: "Admin CA"}, nil, false)
+	otherCA := newTestCert(t, pkix.Name{CommonName: "Other CA"}, nil, false)
+	serverCert := newTestCert(t, pkix.Name{CommonName: "gateway"}, ca, false)
+	ops := newTestCert(t, pkix.Name{CommonName: "ops", Organization: []string{"Example"}}, ca, true)
+	intern := newTestCert(t, pkix.Name{CommonName: "intern", Organization: []string{"Example"}}, ca, true)
+	stranger := newTestCert(t, pkix.Name{CommonName: "ops", Organization: []string{"Example"}}, otherCA, true)
+
+	cfg := config.ServerConfig{
+		TLS: config.TLSConfig{
+			CertFile:   filepath.Join(dir, "tls.crt"),
+			KeyFile:    filepath.Join(dir, "tls.key"),
+			MinVersion: "1.3",
+		},
+		Admin: config.AdminListenerConfig{ClientCAFile: filepath.Join(dir, "ca.crt")},
+	}
+	serverCert.write(t, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	ca.write(t, cfg.Admin.ClientCAFile, "")
+
+	logger := zerolog.Nop()
+	tlsConfig, err := newAdminTLSConfig(cfg, &logger)
+	if err != nil {
+		t.Fatalf("newAdminTLSConfig() error = %v", err)
+	}
+
+	subjects := map[string][]string{"CN=ops,O=Example": {auth.ScopeAdminCache}}
+	handler := middleware.ClientCertificate(subjects)(middleware.RequireScope(auth.ScopeAdminCache)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: handler, ErrorLog: log.New(io.Discard, "", 0)}
+	go srv.Serve(ln)
+	defer srv.Close()
+	url := "https://" + ln.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(client *testCert) (int, error) {
+		tlsClient := &tls.Config{RootCAs: roots}
+		if client != nil {
+			tlsClient.Certificates = []tls.Certificate{client.tlsCertificate()}
+		}
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsClient}}
+		resp, err := c.Get(url)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	if status, err := get(ops); err != nil || status != http.StatusOK {
+		t.Errorf("mapped subject: status = %d, err = %v, want 200", status, err)
+	}
+	if status, err := get(intern); err != nil || status != http.StatusForbidden {
+		t.Errorf("unmapped subject: status = %d, err = %v, want 403", status, err)
+	}
+	if _, err := get(stranger); err == nil {
+		t.Error("certificate from another CA was accepted")
+	}
+	if _, err := get(nil); err == nil {
+		t.Error("connection without a client certificate was accepted")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -107,3 +108,13 @@ func MaskSensitiveData(data string) string {
 	}
 	return data[:2] + "****" + data[len(data)-2:]
 }
+
+// reEVK matches Sapling extended viewing keys and share tokens, which stand
+// in for them
+var reEVK = regexp.MustCompile(`zxviews[0-9a-zA-Z]+|vgs1\.[0-9A-Za-z_-]+\.[0-9A-Za-z_-]+`)
+
+// MaskEVKs masks every viewing key and share token in s (e.g. a query
+// string, URL or error message) with MaskSensitiveData
+func MaskEVKs(s string) string {
+	return reEVK.ReplaceAllStringFunc(s, MaskSensitiveData)
+}
@@ -353,6 +353,50 @@ func TestMaskSensitiveData(t *testing.T) {
 	}
 }
 
+func TestMaskEVKs(t *testing.T) {
+	evk := "zxviews1qwe0g2ly9dq4x5tddsj8xqnz"
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "query string",
+			input:    "evk=" + evk + "&compression=gzip",
+			expected: "evk=zx****nz&compression=gzip",
+		},
+		{
+			name:     "error message",
+			input:    "rpc error -8: invalid viewing key " + evk,
+			expected: "rpc error -8: invalid viewing key zx****nz",
+		},
+		{
+			name:     "mixed case key",
+			input:    "evk=zxviews1qwe0G2LY9dq4x5tddsj8XQnz&compression=gzip",
+			expected: "evk=zx****nz&compression=gzip",
+		},
+		{
+			name:     "share token",
+			input:    "token=vgs1.eyJpZCI6IjEifQ.sig-_0&compression=gzip",
+			expected: "token=vg****_0&compression=gzip",
+		},
+		{
+			name:     "no viewing key",
+			input:    "/c/vrsc/file/abc?compression=gzip",
+			expected: "/c/vrsc/file/abc?compression=gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := MaskEVKs(tt.input); result != tt.expected {
+				t.Errorf("MaskEVKs(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestNew_TextFormatNoColor(t *testing.T) {
 	tempDir := t.TempDir()
 	logFile := filepath.Join(tempDir, "test-nocolor.log")
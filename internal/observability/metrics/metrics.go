@@ -20,6 +20,9 @@ type Metrics struct {
 	CacheItems      prometheus.Gauge
 	CacheOperations *prometheus.CounterVec
 
+	// Cache entries removed because their content became invalid
+	CacheInvalidations *prometheus.CounterVec
+
 	// RPC Metrics
 	RPCRequestsTotal   *prometheus.CounterVec
 	RPCRequestDuration *prometheus.HistogramVec
@@ -113,6 +116,14 @@ func New(namespace string) *Metrics {
 			},
 			[]string{"operation", "status"},
 		),
+		CacheInvalidations: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "cache_invalidations_total",
+				Help:      "Total number of cache entries invalidated",
+			},
+			[]string{"chain", "reason"},
+		),
 
 		// RPC Metrics
 		RPCRequestsTotal: promauto.NewCounterVec(
@@ -170,7 +181,7 @@ func New(namespace string) *Metrics {
 				Name:      "decompressions_total",
 				Help:      "Total number of decompression operations",
 			},
-			[]string{"status"},
+			[]string{"format", "status"},
 		),
 	}
 
@@ -208,6 +219,12 @@ func (m *Metrics) UpdateCacheStats(sizeBytes, items int64) {
 	m.CacheItems.Set(float64(items))
 }
 
+// RecordCacheInvalidation records a cache entry removed for a reason such
+// as a chain reorg
+func (m *Metrics) RecordCacheInvalidation(chain, reason string) {
+	m.CacheInvalidations.WithLabelValues(chain, reason).Inc()
+}
+
 // RecordRPCRequest records an RPC request metric
 func (m *Metrics) RecordRPCRequest(chain, method, status string, duration float64) {
 	m.RPCRequestsTotal.WithLabelValues(chain, method, status).Inc()
@@ -230,7 +247,7 @@ func (m *Metrics) RecordDecryption(chain, status string) {
 	m.DecryptionsTotal.WithLabelValues(chain, status).Inc()
 }
 
-// RecordDecompression records a decompression operation
-func (m *Metrics) RecordDecompression(status string) {
-	m.DecompressionTotal.WithLabelValues(status).Inc()
+// RecordDecompression records a decompression operation for a codec format
+func (m *Metrics) RecordDecompression(format, status string) {
+	m.DecompressionTotal.WithLabelValues(format, status).Inc()
 }
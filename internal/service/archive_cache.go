@@ -0,0 +1,98 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/devdudeio/verus-gateway/internal/storage"
+)
+
+// archiveCache keeps recently used parsed archives in memory, bounded by
+// their total size, so serving each entry does not re-read the archive
+type archiveCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // front = most recently used
+	items    map[string]*list.Element
+}
+
+// archiveCacheItem is a cached archive and its key
+type archiveCacheItem struct {
+	key     string
+	archive *storage.Archive
+}
+
+// newArchiveCache creates an archive cache holding up to maxBytes of archives
+func newArchiveCache(maxBytes int64) *archiveCache {
+	return &archiveCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns a cached archive
+func (c *archiveCache) Get(key string) (*storage.Archive, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*archiveCacheItem).archive, true
+}
+
+// Set caches an archive, evicting the least recently used ones as needed.
+// Archives larger than the whole cache are not stored.
+func (c *archiveCache) Set(key string, archive *storage.Archive) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if archive.Size() > c.maxBytes {
+		return
+	}
+
+	if elem, ok := c.items[key]; ok {
+		c.size -= elem.Value.(*archiveCacheItem).archive.Size()
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+
+	for c.size+archive.Size() > c.maxBytes && c.order.Len() > 0 {
+		c.removeElement(c.order.Back())
+	}
+
+	c.items[key] = c.order.PushFront(&archiveCacheItem{key: key, archive: archive})
+	c.size += archive.Size()
+}
+
+// Delete removes a cached archive
+func (c *archiveCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Clear removes all cached archives
+func (c *archiveCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.size = 0
+}
+
+// removeElement removes an element; the caller must hold the lock
+func (c *archiveCache) removeElement(elem *list.Element) {
+	item := elem.Value.(*archiveCacheItem)
+	c.order.Remove(elem)
+	delete(c.items, item.key)
+	c.size -= item.archive.Size()
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/storage"
+)
+
+// newTestArchive creates an archive of roughly size bytes
+func newTestArchive(t *testing.T, size int) *storage.Archive {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "data", Method: zip.Store})
+	w.Write(bytes.Repeat([]byte("x"), size))
+	zw.Close()
+
+	archive, err := storage.OpenArchive(buf.Bytes(), storage.ArchiveConfig{})
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	return archive
+}
+
+func TestArchiveCache_Eviction(t *testing.T) {
+	a := newTestArchive(t, 1000)
+	cache := newArchiveCache(3 * a.Size())
+
+	cache.Set("a", a)
+	cache.Set("b", newTestArchive(t, 1000))
+	cache.Set("c", newTestArchive(t, 1000))
+
+	// Touch "a" so "b" becomes least recently used
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+
+	cache.Set("d", newTestArchive(t, 1000))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+}
+
+func TestArchiveCache_TooLarge(t *testing.T) {
+	cache := newArchiveCache(100)
+	cache.Set("big", newTestArchive(t, 1000))
+
+	if _, ok := cache.Get("big"); ok {
+		t.Error("archives larger than the cache should not be stored")
+	}
+}
+
+func TestArchiveCache_Clear(t *testing.T) {
+	cache := newArchiveCache(1 << 20)
+	cache.Set("a", newTestArchive(t, 10))
+	cache.Clear()
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected cache to be empty after Clear")
+	}
+}
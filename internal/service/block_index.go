@@ -0,0 +1,140 @@
+package service
+
+import "sync"
+
+// blockIndex maps recent blocks to the cache keys of files mined in them, so
+// entries can be invalidated when a block is orphaned. Blocks below the
+// chain's reorg depth are pruned.
+type blockIndex struct {
+	mu     sync.Mutex
+	blocks map[string]*indexedBlock // chainID:hash
+}
+
+// indexedBlock is a block and the cache keys that depend on it
+type indexedBlock struct {
+	chainID string
+	height  int64
+	keys    map[string]struct{}
+}
+
+// newBlockIndex creates an empty block index
+func newBlockIndex() *blockIndex {
+	return &blockIndex{blocks: make(map[string]*indexedBlock)}
+}
+
+// Add records that cacheKey holds content from the given block
+func (b *blockIndex) Add(chainID string, height int64, hash, cacheKey string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := chainID + ":" + hash
+	block, ok := b.blocks[id]
+	if !ok {
+		block = &indexedBlock{chainID: chainID, height: height, keys: make(map[string]struct{})}
+		b.blocks[id] = block
+	}
+	block.keys[cacheKey] = struct{}{}
+}
+
+// Take removes a block from the index and returns its cache keys
+func (b *blockIndex) Take(chainID, hash string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := chainID + ":" + hash
+	block, ok := b.blocks[id]
+	if !ok {
+		return nil
+	}
+	delete(b.blocks, id)
+
+	keys := make([]string, 0, len(block.keys))
+	for key := range block.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Prune drops a chain's blocks below height
+func (b *blockIndex) Prune(chainID string, height int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, block := range b.blocks {
+		if block.chainID == chainID && block.height < height {
+			delete(b.blocks, id)
+		}
+	}
+}
+
+// Clear removes all blocks
+func (b *blockIndex) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blocks = make(map[string]*indexedBlock)
+}
+
+// maxPendingEntries bounds the cache entries awaiting confirmation; entries
+// beyond it are still promoted in memory when read
+const maxPendingEntries = 10000
+
+// pendingIndex tracks cache entries written while their transaction was in
+// the mempool, so they can be updated once it is mined
+type pendingIndex struct {
+	mu      sync.Mutex
+	entries map[string]map[string]string // chainID -> cache key -> txid
+	count   int
+}
+
+// newPendingIndex creates an empty pending index
+func newPendingIndex() *pendingIndex {
+	return &pendingIndex{entries: make(map[string]map[string]string)}
+}
+
+// Add records an unconfirmed cache entry
+func (p *pendingIndex) Add(chainID, txid, cacheKey string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys, ok := p.entries[chainID]
+	if !ok {
+		keys = make(map[string]string)
+		p.entries[chainID] = keys
+	}
+	if _, ok := keys[cacheKey]; ok || p.count >= maxPendingEntries {
+		return
+	}
+	keys[cacheKey] = txid
+	p.count++
+}
+
+// Entries returns a chain's unconfirmed cache entries (cache key -> txid)
+func (p *pendingIndex) Entries(chainID string) map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := make(map[string]string, len(p.entries[chainID]))
+	for key, txid := range p.entries[chainID] {
+		entries[key] = txid
+	}
+	return entries
+}
+
+// Remove drops an entry
+func (p *pendingIndex) Remove(chainID, cacheKey string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.entries[chainID][cacheKey]; ok {
+		delete(p.entries[chainID], cacheKey)
+		p.count--
+	}
+}
+
+// Clear removes all entries
+func (p *pendingIndex) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = make(map[string]map[string]string)
+	p.count = 0
+}
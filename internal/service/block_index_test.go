@@ -0,0 +1,33 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBlockIndex(t *testing.T) {
+	index := newBlockIndex()
+	index.Add("vrsc", 100, "aa", "vrsc:tx1")
+	index.Add("vrsc", 100, "aa", "vrsc:tx1:z=gzip")
+	index.Add("vrsc", 90, "bb", "vrsc:tx2")
+	index.Add("vrsctest", 100, "aa", "vrsctest:tx3")
+
+	keys := index.Take("vrsc", "aa")
+	sort.Strings(keys)
+	if want := []string{"vrsc:tx1", "vrsc:tx1:z=gzip"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Take() = %v, want %v", keys, want)
+	}
+	if keys := index.Take("vrsc", "aa"); keys != nil {
+		t.Errorf("second Take() = %v, want nil", keys)
+	}
+
+	// Pruning only affects the given chain
+	index.Prune("vrsc", 95)
+	if keys := index.Take("vrsc", "bb"); keys != nil {
+		t.Errorf("Take() after Prune = %v, want nil", keys)
+	}
+	if keys := index.Take("vrsctest", "aa"); len(keys) != 1 {
+		t.Errorf("Take() on other chain = %v, want one key", keys)
+	}
+}
@@ -2,36 +2,128 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/devdudeio/verus-gateway/internal/chain"
+	"github.com/devdudeio/verus-gateway/internal/config"
 	"github.com/devdudeio/verus-gateway/internal/crypto"
 	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/events"
+	"github.com/devdudeio/verus-gateway/internal/observability/metrics"
 	"github.com/devdudeio/verus-gateway/internal/storage"
+	"github.com/devdudeio/verus-gateway/pkg/verusrpc"
 )
 
+// archiveCacheSize is the total size of parsed archives kept in memory
+const archiveCacheSize = 256 * 1024 * 1024 // 256MB
+
+// chunkFetchConcurrency is how many chunks of a chunked file are fetched at once
+const chunkFetchConcurrency = 4
+
 // FileService handles file retrieval, decryption, and processing
 type FileService struct {
 	chainManager *chain.Manager
 	cache        domain.Cache
+	metrics      *metrics.Metrics
 	decompressor *storage.Decompressor
 	detector     *storage.Detector
+	transformer  *storage.ImageTransformer
+	archives     *archiveCache
+	blocks       *blockIndex
+	pending      *pendingIndex
+	events       *events.Bus
+
+	// Chains whose block lookups or tip queries are failing, so the
+	// failure is logged once rather than on every request
+	lookupFailures chainFailures
+	heightFailures chainFailures
+
+	// keySecret keys the EVK digests in cache keys of encrypted files
+	keySecret []byte
 }
 
-// NewFileService creates a new file service
+// NewFileService creates a new file service (metrics may be nil)
 func NewFileService(
 	chainManager *chain.Manager,
 	cache domain.Cache,
+	m *metrics.Metrics,
 ) *FileService {
 	return &FileService{
 		chainManager: chainManager,
 		cache:        cache,
+		metrics:      m,
+		// Fallback limit; the chain's configured limits take precedence
 		decompressor: storage.NewDecompressor(storage.DecompressorConfig{
 			MaxSize: 100 * 1024 * 1024, // 100MB
 		}),
-		detector: storage.NewDetector(),
+		detector:    storage.NewDetector(),
+		transformer: storage.NewImageTransformer(storage.ImageTransformerConfig{}),
+		archives:    newArchiveCache(archiveCacheSize),
+		blocks:      newBlockIndex(),
+		pending:     newPendingIndex(),
+		keySecret:   randomKeySecret(),
+	}
+}
+
+// SetCacheKeySecret sets the secret viewing keys are hashed with in cache
+// keys. Without one, a random secret is used and cached encrypted files are
+// not found again after a restart.
+func (s *FileService) SetCacheKeySecret(secret []byte) {
+	if len(secret) > 0 {
+		s.keySecret = secret
+	}
+}
+
+// randomKeySecret returns a per-process cache key secret
+func randomKeySecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate cache key secret: %v", err))
+	}
+	return secret
+}
+
+// SetEvents sets the bus cache purges are published to (nil disables them)
+func (s *FileService) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// RegisterSignatures adds custom file signatures to type detection. Earlier
+// signatures take precedence over later ones and over the built-in table.
+func (s *FileService) RegisterSignatures(sigs []config.SignatureConfig) error {
+	for i := len(sigs) - 1; i >= 0; i-- {
+		sig := storage.Signature{
+			MIME:      sigs[i].MIME,
+			Extension: sigs[i].Extension,
+			Parent:    sigs[i].Parent,
+		}
+		for _, p := range sigs[i].Patterns {
+			magic, mask, err := p.Decode()
+			if err != nil {
+				return fmt.Errorf("signature for %s: %w", sigs[i].MIME, err)
+			}
+			sig.Patterns = append(sig.Patterns, storage.SignaturePattern{
+				Offset: p.Offset,
+				Magic:  magic,
+				Mask:   mask,
+			})
+		}
+		if err := s.detector.RegisterSignature(sig); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // GetFile retrieves a file by TXID and EVK, with caching
@@ -41,11 +133,19 @@ func (s *FileService) GetFile(ctx context.Context, req *domain.FileRequest) (*do
 		return nil, err
 	}
 
+	// Resized image variants are derived from the original file
+	if req.Transform != nil {
+		return s.getTransformed(ctx, req)
+	}
+
 	// Check cache first if enabled
 	if req.UseCache && s.cache != nil {
-		cacheKey := req.CacheKey()
-		if cached, err := s.cache.Get(ctx, cacheKey); err == nil {
-			return cached, nil
+		if cached := s.getCached(ctx, req); cached != nil {
+			s.refreshBlock(ctx, req, cached)
+			if err := s.checkConfirmations(req, cached.Metadata); err != nil {
+				return nil, err
+			}
+			return s.resolveManifest(req, cached)
 		}
 	}
 
@@ -55,20 +155,44 @@ func (s *FileService) GetFile(ctx context.Context, req *domain.FileRequest) (*do
 		return nil, err
 	}
 
+	// Look up the containing block while the payload is fetched
+	blockCh := make(chan *domain.BlockInfo, 1)
+	go func() {
+		blockCh <- s.lookupBlock(ctx, req.ChainID, req.TXID)
+	}()
+
 	// Create decryptor with the client
 	decryptor := crypto.NewDecryptor(client)
 
+	limits := s.getLimits(req.ChainID)
+
 	// Decrypt data from blockchain
-	encryptedData, err := decryptor.DecryptData(ctx, req.TXID, req.EVK)
+	encryptedData, err := s.fetchRaw(ctx, decryptor, limits, req.TXID, req.EVK)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decompress if needed
-	data, err := s.decompressor.Decompress(encryptedData)
+	// Decompress if needed, with the requested codec or a detected one
+	decompressor := s.decompressor.WithMaxSize(limits.MaxDecompressedSize)
+	data, compression, err := decompressor.DecompressFormat(encryptedData, req.Compression)
+	if compression != "" {
+		s.recordDecompression(compression, err)
+	}
 	if err != nil {
+		if errors.Is(err, domain.ErrFileTooLarge) {
+			// Never fall back to serving a payload that inflates past the limit
+			if domainErr, ok := err.(*domain.Error); ok {
+				domainErr.WithDetail("txid", req.TXID)
+			}
+			return nil, err
+		}
+		if req.Compression != "" {
+			// The caller named the codec, so failing to apply it is an error
+			return nil, err
+		}
 		// Non-fatal: return encrypted data if decompression fails
 		data = encryptedData
+		compression = ""
 	}
 
 	// Detect file type
@@ -76,6 +200,15 @@ func (s *FileService) GetFile(ctx context.Context, req *domain.FileRequest) (*do
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect file type: %w", err)
 	}
+	metadata.Compressed = compression != ""
+	metadata.Compression = compression
+	setBlock(metadata, <-blockCh)
+	if err := s.checkConfirmations(req, metadata); err != nil {
+		return nil, err
+	}
+
+	// Content below the confirmation threshold is never cached
+	cacheable := req.UseCache && s.cache != nil && !metadata.Unconfirmed
 
 	// Create file object
 	file := &domain.File{
@@ -86,24 +219,602 @@ func (s *FileService) GetFile(ctx context.Context, req *domain.FileRequest) (*do
 		RetrievedAt: time.Now(),
 	}
 
-	// Cache the file if caching is enabled
+	// A manifest is cached as-is and resolved to the chunked file it describes
+	if manifest, ok, err := domain.ParseChunkManifest(data); ok {
+		if err != nil {
+			return nil, err
+		}
+		if cacheable {
+			s.cacheFile(req.CacheKey(s.keySecret), file)
+		}
+		return s.chunkedFile(req, manifest, metadata)
+	}
+
+	// Keep the stored gzip bytes around so gzip-accepting clients can get
+	// them as-is, described by the decompressed metadata
+	var gzipFile *domain.File
+	if compression == storage.FormatGzip {
+		gzipFile = &domain.File{
+			TXID:            req.TXID,
+			ChainID:         req.ChainID,
+			Content:         encryptedData,
+			ContentEncoding: "gzip",
+			Metadata:        metadata,
+			RetrievedAt:     file.RetrievedAt,
+		}
+	}
+
+	// Cache both forms if caching is enabled
+	if cacheable {
+		s.cacheFile(req.CacheKey(s.keySecret), file)
+		if gzipFile != nil {
+			s.cacheFile(req.GzipCacheKey(s.keySecret), gzipFile)
+		}
+	}
+
+	if req.AcceptGzip && gzipFile != nil {
+		return gzipFile, nil
+	}
+
+	return file, nil
+}
+
+// getTransformed returns a resized variant of a JPEG, PNG or GIF image.
+// Variants are cached under their own keys.
+func (s *FileService) getTransformed(ctx context.Context, req *domain.FileRequest) (*domain.File, error) {
+	variantReq := *req
+	variantReq.AcceptGzip = false
+
 	if req.UseCache && s.cache != nil {
-		cacheKey := req.CacheKey()
-		// Fire and forget - don't fail the request if caching fails
-		go func() {
-			// Use background context since original might be canceled
-			cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-
-			if err := s.cache.Set(cacheCtx, cacheKey, file, 24*time.Hour); err != nil {
-				fmt.Printf("[WARN] Failed to cache file %s: %v\n", req.TXID, err)
+		if cached := s.getCached(ctx, &variantReq); cached != nil {
+			cached.Variant = req.Transform.Key()
+			s.refreshBlock(ctx, req, cached)
+			if err := s.checkConfirmations(req, cached.Metadata); err != nil {
+				return nil, err
 			}
-		}()
+			return cached, nil
+		}
+	}
+
+	originalReq := variantReq
+	originalReq.Transform = nil
+
+	original, err := s.GetFile(ctx, &originalReq)
+	if err != nil {
+		return nil, err
+	}
+	if original.Manifest != nil {
+		return nil, domain.NewUnsupportedFormatError("chunked files cannot be transformed").
+			WithDetail("txid", req.TXID)
+	}
+	if !storage.IsTransformableImage(original.Metadata.ContentType) {
+		return nil, domain.NewUnsupportedFormatError("only JPEG, PNG and GIF images can be transformed").
+			WithDetail("txid", req.TXID).
+			WithDetail("content_type", original.Metadata.ContentType)
+	}
+
+	limits := s.getLimits(req.ChainID)
+	data, contentType, err := s.transformer.WithMaxInputPixels(limits.MaxImagePixels).Transform(original.Content, req.Transform)
+	if err != nil {
+		if domainErr, ok := err.(*domain.Error); ok {
+			return nil, domainErr.WithDetail("txid", req.TXID)
+		}
+		return nil, err
+	}
+
+	// The variant's format may differ from the original's
+	extension := s.detector.DetectExtension(data)
+	filename := original.Metadata.Filename
+	if filename != "" {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + extension
+	}
+
+	file := &domain.File{
+		TXID:    req.TXID,
+		ChainID: req.ChainID,
+		Content: data,
+		Metadata: &domain.FileMetadata{
+			Filename:    filename,
+			Size:        int64(len(data)),
+			ContentType: contentType,
+			Extension:   extension,
+			Encrypted:   original.Metadata.Encrypted,
+			CreatedAt:   original.Metadata.CreatedAt,
+			Block:       original.Metadata.Block,
+			Unconfirmed: original.Metadata.Unconfirmed,
+			Properties:  s.detector.ExtractProperties(data, contentType),
+		},
+		Variant:     req.Transform.Key(),
+		RetrievedAt: time.Now(),
+	}
+
+	if req.UseCache && s.cache != nil && !file.Metadata.Unconfirmed {
+		s.cacheFile(variantReq.CacheKey(s.keySecret), file)
 	}
 
 	return file, nil
 }
 
+// fetchRaw retrieves the data stored in a transaction, enforcing the raw
+// size limit. The RPC client refuses to buffer responses larger than the
+// limit allows, so oversized payloads are rejected before being read fully.
+func (s *FileService) fetchRaw(ctx context.Context, decryptor *crypto.Decryptor, limits config.LimitsConfig, txid, evk string) ([]byte, error) {
+	data, err := decryptor.DecryptData(ctx, txid, evk)
+	if err != nil {
+		if errors.Is(err, verusrpc.ErrResponseTooLarge) {
+			return nil, domain.NewFileTooLargeError("raw", limits.MaxRawSize).WithDetail("txid", txid)
+		}
+		return nil, err
+	}
+
+	if limits.MaxRawSize > 0 && int64(len(data)) > limits.MaxRawSize {
+		return nil, domain.NewFileTooLargeError("raw", limits.MaxRawSize).
+			WithDetail("txid", txid).
+			WithDetail("size", len(data))
+	}
+
+	return data, nil
+}
+
+// resolveManifest returns the chunked file described by a cached manifest,
+// or the cached file itself if it is not a manifest
+func (s *FileService) resolveManifest(req *domain.FileRequest, file *domain.File) (*domain.File, error) {
+	if file.ContentEncoding != "" {
+		return file, nil
+	}
+
+	manifest, ok, err := domain.ParseChunkManifest(file.Content)
+	if !ok {
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.chunkedFile(req, manifest, file.Metadata)
+}
+
+// chunkedFile builds the file described by a chunk manifest. The file takes
+// its block information from the manifest's own metadata.
+func (s *FileService) chunkedFile(req *domain.FileRequest, manifest *domain.ChunkManifest, source *domain.FileMetadata) (*domain.File, error) {
+	limits := s.getLimits(req.ChainID)
+	if limits.MaxDecompressedSize > 0 && manifest.Size > limits.MaxDecompressedSize {
+		return nil, domain.NewFileTooLargeError("decompressed", limits.MaxDecompressedSize).
+			WithDetail("txid", req.TXID).
+			WithDetail("size", manifest.Size)
+	}
+
+	filename := manifest.Filename
+	if filename == "" {
+		filename = req.Filename
+	}
+
+	metadata := &domain.FileMetadata{
+		Filename:    filename,
+		Size:        manifest.Size,
+		ContentType: manifest.ContentType,
+		Extension:   strings.TrimPrefix(filepath.Ext(filename), "."),
+		Encrypted:   req.EVK != "",
+	}
+	if source != nil {
+		metadata.CreatedAt = source.CreatedAt
+		metadata.Block = source.Block
+		metadata.Unconfirmed = source.Unconfirmed
+	}
+	if metadata.ContentType == "" {
+		metadata.ContentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	if metadata.ContentType == "" {
+		metadata.ContentType = "application/octet-stream"
+	}
+
+	return &domain.File{
+		TXID:        req.TXID,
+		ChainID:     req.ChainID,
+		Metadata:    metadata,
+		Manifest:    manifest,
+		RetrievedAt: time.Now(),
+	}, nil
+}
+
+// StreamChunks writes length bytes of a chunked file, starting at offset, to
+// w. Chunks are fetched concurrently, verified against the manifest and
+// written in order.
+func (s *FileService) StreamChunks(ctx context.Context, req *domain.FileRequest, manifest *domain.ChunkManifest, w io.Writer, offset, length int64) error {
+	spans := manifest.Spans(offset, length)
+	if len(spans) == 0 {
+		return nil
+	}
+
+	client, err := s.getClient(req.ChainID)
+	if err != nil {
+		return err
+	}
+	decryptor := crypto.NewDecryptor(client)
+	limits := s.getLimits(req.ChainID)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type chunkResult struct {
+		data []byte
+		err  error
+	}
+
+	// Each span gets its own result channel so chunks are written in order;
+	// the semaphore bounds how many chunks are held in memory at once
+	results := make([]chan chunkResult, len(spans))
+	for i := range results {
+		results[i] = make(chan chunkResult, 1)
+	}
+	sem := make(chan struct{}, chunkFetchConcurrency)
+
+	go func() {
+		for i, span := range spans {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int, chunk domain.Chunk) {
+				data, err := s.fetchChunk(ctx, decryptor, limits, req, chunk)
+				results[i] <- chunkResult{data: data, err: err}
+			}(i, manifest.Chunks[span.Index])
+		}
+	}()
+
+	for i, span := range spans {
+		var res chunkResult
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		<-sem
+
+		if res.err != nil {
+			return res.err
+		}
+		if _, err := w.Write(res.data[span.Offset : span.Offset+span.Length]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchChunk retrieves a single chunk, from cache if possible, and verifies
+// its size and hash
+func (s *FileService) fetchChunk(ctx context.Context, decryptor *crypto.Decryptor, limits config.LimitsConfig, req *domain.FileRequest, chunk domain.Chunk) ([]byte, error) {
+	chunkReq := &domain.FileRequest{TXID: chunk.TXID, EVK: req.EVK, ChainID: req.ChainID}
+	cacheKey := chunkReq.CacheKey(s.keySecret) + ":chunk"
+
+	useCache := req.UseCache && s.cache != nil
+	if useCache {
+		if cached, err := s.cache.Get(ctx, cacheKey); err == nil && cached != nil {
+			if verifyChunk(chunk, cached.Content) == nil {
+				return cached.Content, nil
+			}
+		}
+	}
+
+	data, err := s.fetchRaw(ctx, decryptor, limits, chunk.TXID, req.EVK)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChunk(chunk, data); err != nil {
+		return nil, err
+	}
+
+	if useCache {
+		s.cacheFile(cacheKey, &domain.File{
+			TXID:    chunk.TXID,
+			ChainID: req.ChainID,
+			Content: data,
+			Metadata: &domain.FileMetadata{
+				Size:      int64(len(data)),
+				Hash:      chunk.SHA256,
+				Encrypted: req.EVK != "",
+			},
+		})
+	}
+
+	return data, nil
+}
+
+// verifyChunk checks chunk data against the manifest entry
+func verifyChunk(chunk domain.Chunk, data []byte) error {
+	if int64(len(data)) != chunk.Size {
+		return domain.NewChunkIntegrityError(chunk.TXID,
+			fmt.Sprintf("size is %d bytes, manifest says %d", len(data), chunk.Size))
+	}
+
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), chunk.SHA256) {
+		return domain.NewChunkIntegrityError(chunk.TXID, "sha256 mismatch")
+	}
+
+	return nil
+}
+
+// recordDecompression records a decompression attempt for a codec format
+func (s *FileService) recordDecompression(format string, err error) {
+	if s.metrics == nil {
+		return
+	}
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	s.metrics.RecordDecompression(format, status)
+}
+
+// lookupBlock returns the block containing a transaction, or nil if it
+// cannot be determined (e.g. the node has no transaction index)
+func (s *FileService) lookupBlock(ctx context.Context, chainID, txid string) *domain.BlockInfo {
+	block, err := s.chainManager.TransactionBlock(ctx, chainID, txid)
+	if err != nil {
+		if s.lookupFailures.fail(chainID) {
+			fmt.Printf("[WARN] Failed to look up block for %s on %s (logged once until a lookup succeeds): %v\n", txid, chainID, err)
+		}
+		return nil
+	}
+	s.lookupFailures.ok(chainID)
+	return block
+}
+
+// refreshBlock brings the block information of a cached file up to date.
+// Confirmed files get a current confirmation count from the chain tip;
+// files cached while unconfirmed are looked up again.
+func (s *FileService) refreshBlock(ctx context.Context, req *domain.FileRequest, file *domain.File) {
+	metadata := file.Metadata
+	if metadata == nil || metadata.Block == nil {
+		return
+	}
+
+	if !metadata.Block.Confirmed() {
+		if block := s.lookupBlock(ctx, req.ChainID, req.TXID); block != nil {
+			setBlock(metadata, block)
+		}
+		return
+	}
+
+	height, err := s.chainManager.BlockHeight(ctx, req.ChainID)
+	if err != nil {
+		if s.heightFailures.fail(req.ChainID) {
+			fmt.Printf("[WARN] Failed to get block height for %s (logged once until the node is reachable): %v\n", req.ChainID, err)
+		}
+		return
+	}
+	s.heightFailures.ok(req.ChainID)
+	metadata.Block.Confirmations = metadata.Block.ConfirmationsAt(height)
+}
+
+// chainFailures tracks chains with an ongoing failure
+type chainFailures struct {
+	mu     sync.Mutex
+	chains map[string]bool
+}
+
+// fail marks the chain as failing and reports whether it was working before
+func (f *chainFailures) fail(chainID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.chains[chainID] {
+		return false
+	}
+	if f.chains == nil {
+		f.chains = make(map[string]bool)
+	}
+	f.chains[chainID] = true
+	return true
+}
+
+// ok marks the chain as working again
+func (f *chainFailures) ok(chainID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.chains, chainID)
+}
+
+// checkConfirmations applies the chain's confirmation policy to a file. Below
+// the threshold it returns an error, or marks the file Unconfirmed when the
+// chain is configured to serve such content uncached.
+func (s *FileService) checkConfirmations(req *domain.FileRequest, metadata *domain.FileMetadata) error {
+	chainInfo, err := s.chainManager.GetChainInfo(req.ChainID)
+	if err != nil || chainInfo.Config.MinConfirmations <= 0 {
+		return nil
+	}
+	cfg := chainInfo.Config
+
+	// Without block information the transaction counts as unconfirmed
+	var confirmations int64
+	if metadata.Block != nil {
+		confirmations = metadata.Block.Confirmations
+	}
+
+	metadata.Unconfirmed = confirmations < cfg.MinConfirmations
+	if !metadata.Unconfirmed || cfg.UnconfirmedPolicy == config.UnconfirmedServe {
+		return nil
+	}
+
+	return unconfirmedError(req, confirmations, cfg)
+}
+
+// requireConfirmed rejects a file served under the "serve" policy while
+// unconfirmed. Archive, site and directory responses are cached as
+// immutable, so they always require the minimum confirmations.
+func (s *FileService) requireConfirmed(req *domain.FileRequest, metadata *domain.FileMetadata) error {
+	if !metadata.Unconfirmed {
+		return nil
+	}
+
+	chainInfo, err := s.chainManager.GetChainInfo(req.ChainID)
+	if err != nil {
+		return err
+	}
+
+	var confirmations int64
+	if metadata.Block != nil {
+		confirmations = metadata.Block.Confirmations
+	}
+	return unconfirmedError(req, confirmations, chainInfo.Config)
+}
+
+// unconfirmedError builds the error for a transaction below the threshold
+func unconfirmedError(req *domain.FileRequest, confirmations int64, cfg config.ChainConfig) error {
+	status := cfg.UnconfirmedStatus
+	if status == 0 {
+		status = http.StatusTooEarly
+	}
+	return domain.NewUnconfirmedError(req.TXID, confirmations, cfg.MinConfirmations, status)
+}
+
+// setBlock records a file's block information and creation time
+func setBlock(metadata *domain.FileMetadata, block *domain.BlockInfo) {
+	if block == nil {
+		return
+	}
+	metadata.Block = block
+	if block.Confirmed() {
+		createdAt := block.Time
+		metadata.CreatedAt = &createdAt
+	}
+}
+
+// getCached looks up a cached file for the request. Gzip-accepting requests
+// prefer the gzip form and fall back to the identity form.
+func (s *FileService) getCached(ctx context.Context, req *domain.FileRequest) *domain.File {
+	keys := []string{req.CacheKey(s.keySecret)}
+	if req.AcceptGzip {
+		keys = []string{req.GzipCacheKey(s.keySecret), req.CacheKey(s.keySecret)}
+	}
+
+	for _, key := range keys {
+		cached, err := s.cache.Get(ctx, key)
+		if err != nil || cached == nil {
+			continue
+		}
+		// Entries cached before a reorg was detected are not in the block
+		// index; drop them when their block turns out to be orphaned
+		if block := blockOf(cached); block != nil && s.chainManager.BlockOrphaned(req.ChainID, block.Height, block.Hash) {
+			s.invalidateCached(ctx, req.ChainID, key, fmt.Sprintf("block %s at height %d orphaned", block.Hash, block.Height))
+			continue
+		}
+		cached.TXID = req.TXID
+		cached.ChainID = req.ChainID
+		return cached
+	}
+
+	return nil
+}
+
+// cacheFile stores a file in the cache without blocking the request
+func (s *FileService) cacheFile(cacheKey string, file *domain.File) {
+	// Fire and forget - don't fail the request if caching fails
+	go func() {
+		// Use background context since original might be canceled
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// Indexed first so a reorg detected during the write still finds
+		// the entry; GetFile re-checks blocks on read as well
+		if block := blockOf(file); block != nil {
+			s.blocks.Add(s.chainID(file.ChainID), block.Height, block.Hash, cacheKey)
+		} else if file.Metadata != nil && file.Metadata.Block != nil {
+			s.pending.Add(s.chainID(file.ChainID), file.TXID, cacheKey)
+		}
+
+		if err := s.cache.Set(cacheCtx, cacheKey, file, 24*time.Hour); err != nil {
+			fmt.Printf("[WARN] Failed to cache file %s: %v\n", file.TXID, err)
+		}
+	}()
+}
+
+// HandleBlockEvent invalidates cache entries holding content from blocks
+// orphaned by a reorg and promotes entries cached while their transaction
+// was in the mempool. It is registered with chain.Manager.OnBlock.
+func (s *FileService) HandleBlockEvent(event chain.BlockEvent) {
+	if s.cache == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, block := range event.Orphaned {
+		for _, key := range s.blocks.Take(event.ChainID, block.Hash) {
+			s.invalidateCached(ctx, event.ChainID, key, fmt.Sprintf("block %s at height %d orphaned", block.Hash, block.Height))
+		}
+	}
+
+	// Blocks deeper than the tracked window are no longer checked
+	if chainInfo, err := s.chainManager.GetChainInfo(event.ChainID); err == nil {
+		s.blocks.Prune(event.ChainID, event.Tip.Height-int64(chainInfo.ReorgDepth())+1)
+	}
+
+	s.promotePending(ctx, event.ChainID)
+}
+
+// promotePending stores the containing block in cache entries written while
+// their transaction was in the mempool, once it has been mined
+func (s *FileService) promotePending(ctx context.Context, chainID string) {
+	for key, txid := range s.pending.Entries(chainID) {
+		cached, err := s.cache.Get(ctx, key)
+		if err != nil || cached == nil || cached.Metadata == nil {
+			// Expired or evicted
+			s.pending.Remove(chainID, key)
+			continue
+		}
+
+		block := s.lookupBlock(ctx, chainID, txid)
+		if block == nil || !block.Confirmed() {
+			continue
+		}
+		s.pending.Remove(chainID, key)
+
+		setBlock(cached.Metadata, block)
+		s.blocks.Add(chainID, block.Height, block.Hash, key)
+		if err := s.cache.Set(ctx, key, cached, 24*time.Hour); err != nil {
+			fmt.Printf("[WARN] Failed to update cached file %s: %v\n", txid, err)
+		}
+	}
+}
+
+// invalidateCached removes a cache entry whose content is no longer on chain
+func (s *FileService) invalidateCached(ctx context.Context, chainID, key, reason string) {
+	s.archives.Delete(key)
+	if err := s.cache.Delete(ctx, key); err != nil {
+		fmt.Printf("[WARN] Failed to invalidate cache entry %s: %v\n", key, err)
+		return
+	}
+
+	fmt.Printf("[INFO] Invalidated cache entry %s: %s\n", key, reason)
+	if s.metrics != nil {
+		s.metrics.RecordCacheInvalidation(s.chainID(chainID), "reorg")
+	}
+	s.events.Publish(events.TopicCachePurge, s.chainID(chainID), map[string]interface{}{
+		"key":    key,
+		"reason": "reorg",
+	})
+}
+
+// blockOf returns the confirmed block a file was mined in, or nil
+func blockOf(file *domain.File) *domain.BlockInfo {
+	if file.Metadata == nil || file.Metadata.Block == nil || !file.Metadata.Block.Confirmed() {
+		return nil
+	}
+	return file.Metadata.Block
+}
+
+// chainID resolves an empty chain ID to the default chain
+func (s *FileService) chainID(chainID string) string {
+	if chainID == "" {
+		return s.chainManager.GetDefaultChainID()
+	}
+	return chainID
+}
+
 // GetMetadata retrieves only the metadata for a file (without full content)
 func (s *FileService) GetMetadata(ctx context.Context, req *domain.FileRequest) (*domain.FileMetadata, error) {
 	// For now, we need to fetch the full file to get metadata
@@ -116,6 +827,100 @@ func (s *FileService) GetMetadata(ctx context.Context, req *domain.FileRequest)
 	return file.Metadata, nil
 }
 
+// GetDirectory retrieves and parses the directory manifest stored in a
+// transaction. The manifest is cached like any other file.
+func (s *FileService) GetDirectory(ctx context.Context, req *domain.FileRequest) (*domain.DirectoryManifest, error) {
+	manifestReq := *req
+	manifestReq.AcceptGzip = false
+
+	file, err := s.GetFile(ctx, &manifestReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireConfirmed(req, file.Metadata); err != nil {
+		return nil, err
+	}
+	if file.Manifest != nil {
+		return nil, domain.NewInvalidManifestError("transaction holds a chunked file, not a directory manifest").
+			WithDetail("txid", req.TXID)
+	}
+
+	manifest, err := domain.ParseDirectoryManifest(file.Content)
+	if err != nil {
+		if domainErr, ok := err.(*domain.Error); ok {
+			return nil, domainErr.WithDetail("txid", req.TXID)
+		}
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// GetArchive retrieves the ZIP or tar archive stored in a transaction and
+// indexes its entries. Compressed tarballs (e.g. tar.gz) are decompressed
+// first. Parsed archives are kept in memory so each entry request does not
+// re-read the archive.
+func (s *FileService) GetArchive(ctx context.Context, req *domain.FileRequest) (*storage.Archive, error) {
+	archiveReq := *req
+	archiveReq.AcceptGzip = false
+
+	if err := archiveReq.Validate(); err != nil {
+		return nil, err
+	}
+
+	key := archiveReq.CacheKey(s.keySecret)
+	if archiveReq.UseCache {
+		if archive, ok := s.archives.Get(key); ok {
+			return archive, nil
+		}
+	}
+
+	file, err := s.GetFile(ctx, &archiveReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireConfirmed(req, file.Metadata); err != nil {
+		return nil, err
+	}
+	if file.Manifest != nil {
+		return nil, domain.NewUnsupportedFormatError("chunked files cannot be opened as archives").
+			WithDetail("txid", req.TXID)
+	}
+	if !storage.IsArchiveType(file.Metadata.ContentType) {
+		return nil, domain.NewUnsupportedFormatError("file is not a ZIP or tar archive").
+			WithDetail("txid", req.TXID).
+			WithDetail("content_type", file.Metadata.ContentType)
+	}
+
+	archive, err := storage.OpenArchive(file.Content, storage.ArchiveConfig{
+		MaxEntrySize: s.getLimits(req.ChainID).MaxDecompressedSize,
+	})
+	if err != nil {
+		if domainErr, ok := err.(*domain.Error); ok {
+			return nil, domainErr.WithDetail("txid", req.TXID)
+		}
+		return nil, err
+	}
+	if _, err := archive.SiteConfig(); err != nil {
+		fmt.Printf("[WARN] Ignoring site config of %s: %v\n", req.TXID, err)
+	}
+
+	if archiveReq.UseCache {
+		s.archives.Set(key, archive)
+	}
+
+	return archive, nil
+}
+
+// getLimits returns the effective file size limits for a chain
+func (s *FileService) getLimits(chainID string) config.LimitsConfig {
+	chainInfo, err := s.chainManager.GetChainInfo(chainID)
+	if err != nil {
+		return config.LimitsConfig{}
+	}
+	return chainInfo.Limits
+}
+
 // getClient retrieves the RPC client for a chain
 func (s *FileService) getClient(chainID string) (crypto.RPCClient, error) {
 	if chainID == "" {
@@ -141,7 +946,18 @@ func (s *FileService) ClearCache(ctx context.Context) error {
 	if s.cache == nil {
 		return fmt.Errorf("cache not configured")
 	}
-	return s.cache.Clear(ctx)
+	s.archives.Clear()
+	s.blocks.Clear()
+	s.pending.Clear()
+	if err := s.cache.Clear(ctx); err != nil {
+		return err
+	}
+
+	s.events.Publish(events.TopicCachePurge, "", map[string]interface{}{
+		"scope":  "all",
+		"reason": "admin",
+	})
+	return nil
 }
 
 // GetCacheStats returns cache statistics
@@ -157,5 +973,15 @@ func (s *FileService) DeleteFromCache(ctx context.Context, cacheKey string) erro
 	if s.cache == nil {
 		return fmt.Errorf("cache not configured")
 	}
-	return s.cache.Delete(ctx, cacheKey)
+	if err := s.cache.Delete(ctx, cacheKey); err != nil {
+		return err
+	}
+
+	// Keys start with the chain ID
+	chainID, _, _ := strings.Cut(cacheKey, ":")
+	s.events.Publish(events.TopicCachePurge, chainID, map[string]interface{}{
+		"key":    cacheKey,
+		"reason": "admin",
+	})
+	return nil
 }
@@ -1,13 +1,27 @@
 package service
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/devdudeio/verus-gateway/internal/chain"
+	"github.com/devdudeio/verus-gateway/internal/config"
 	"github.com/devdudeio/verus-gateway/internal/domain"
+	"github.com/devdudeio/verus-gateway/internal/events"
 )
 
 // Mock cache implementation
@@ -68,14 +82,14 @@ func newTestFileService(cache domain.Cache, chainMgr *chain.Manager) *FileServic
 		// Create empty chain manager for tests that don't need it
 		chainMgr = &chain.Manager{}
 	}
-	return NewFileService(chainMgr, cache)
+	return NewFileService(chainMgr, cache, nil)
 }
 
 func TestNewFileService(t *testing.T) {
 	cache := &mockCache{}
 	chainMgr := &chain.Manager{}
 
-	service := NewFileService(chainMgr, cache)
+	service := NewFileService(chainMgr, cache, nil)
 
 	if service == nil {
 		t.Fatal("NewFileService returned nil")
@@ -101,7 +115,7 @@ func TestNewFileService(t *testing.T) {
 func TestNewFileService_NilCache(t *testing.T) {
 	chainMgr := &chain.Manager{}
 
-	service := NewFileService(chainMgr, nil)
+	service := NewFileService(chainMgr, nil, nil)
 
 	if service == nil {
 		t.Fatal("NewFileService returned nil")
@@ -112,6 +126,33 @@ func TestNewFileService_NilCache(t *testing.T) {
 	}
 }
 
+func TestRegisterSignatures(t *testing.T) {
+	service := NewFileService(&chain.Manager{}, nil, nil)
+
+	err := service.RegisterSignatures([]config.SignatureConfig{
+		{MIME: "application/x-first", Extension: "one", Patterns: []config.SignaturePatternConfig{{Magic: "56 52 53 43"}}},
+		{MIME: "application/x-second", Extension: "two", Patterns: []config.SignaturePatternConfig{{Magic: "5652"}}},
+	})
+	if err != nil {
+		t.Fatalf("RegisterSignatures() error = %v", err)
+	}
+
+	// Earlier signatures take precedence
+	if got := service.detector.DetectMIME([]byte("VRSC\x00\x01")); got != "application/x-first" {
+		t.Errorf("DetectMIME() = %q, want %q", got, "application/x-first")
+	}
+	if got := service.detector.DetectExtension([]byte("VRxx\x00\x01")); got != "two" {
+		t.Errorf("DetectExtension() = %q, want %q", got, "two")
+	}
+
+	err = service.RegisterSignatures([]config.SignatureConfig{
+		{MIME: "application/x-bad", Patterns: []config.SignaturePatternConfig{{Magic: "zz"}}},
+	})
+	if err == nil {
+		t.Error("RegisterSignatures() expected error for invalid hex")
+	}
+}
+
 func TestClearCache(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -252,6 +293,11 @@ func TestDeleteFromCache(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			service := newTestFileService(tt.cache, nil)
+			bus := events.NewBus(1)
+			sub := bus.Subscribe(events.Filter{Topics: []string{events.TopicCachePurge}})
+			defer sub.Close()
+			service.SetEvents(bus)
+
 			err := service.DeleteFromCache(context.Background(), tt.cacheKey)
 
 			if tt.wantError && err == nil {
@@ -261,6 +307,11 @@ func TestDeleteFromCache(t *testing.T) {
 			if !tt.wantError && err != nil {
 				t.Errorf("expected no error, got %v", err)
 			}
+
+			// A purge event is published only for successful deletions
+			if published := len(sub.Events()) == 1; published == tt.wantError {
+				t.Errorf("purge event published = %v, want %v", published, !tt.wantError)
+			}
 		})
 	}
 }
@@ -318,3 +369,840 @@ func TestGetMetadata_InvalidRequest(t *testing.T) {
 		t.Error("expected validation error, got nil")
 	}
 }
+
+func TestGetFile_CacheLookupPrefersGzip(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name         string
+		acceptGzip   bool
+		cached       map[string]*domain.File
+		wantEncoding string
+		wantLookups  []string
+	}{
+		{
+			name:       "gzip form hit",
+			acceptGzip: true,
+			cached: map[string]*domain.File{
+				"vrsctest:" + txid + ":gzip": {Content: []byte("gz"), ContentEncoding: "gzip"},
+				"vrsctest:" + txid:           {Content: []byte("plain")},
+			},
+			wantEncoding: "gzip",
+			wantLookups:  []string{"vrsctest:" + txid + ":gzip"},
+		},
+		{
+			name:       "falls back to identity form",
+			acceptGzip: true,
+			cached: map[string]*domain.File{
+				"vrsctest:" + txid: {Content: []byte("plain")},
+			},
+			wantEncoding: "",
+			wantLookups:  []string{"vrsctest:" + txid + ":gzip", "vrsctest:" + txid},
+		},
+		{
+			name:       "identity client never sees gzip form",
+			acceptGzip: false,
+			cached: map[string]*domain.File{
+				"vrsctest:" + txid + ":gzip": {Content: []byte("gz"), ContentEncoding: "gzip"},
+				"vrsctest:" + txid:           {Content: []byte("plain")},
+			},
+			wantEncoding: "",
+			wantLookups:  []string{"vrsctest:" + txid},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lookups []string
+			cache := &mockCache{
+				getFunc: func(ctx context.Context, key string) (*domain.File, error) {
+					lookups = append(lookups, key)
+					if f, ok := tt.cached[key]; ok {
+						return f, nil
+					}
+					return nil, domain.ErrCacheMiss
+				},
+			}
+
+			service := newTestFileService(cache, nil)
+			file, err := service.GetFile(context.Background(), &domain.FileRequest{
+				TXID:       txid,
+				ChainID:    "vrsctest",
+				UseCache:   true,
+				AcceptGzip: tt.acceptGzip,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if file.ContentEncoding != tt.wantEncoding {
+				t.Errorf("ContentEncoding = %q, want %q", file.ContentEncoding, tt.wantEncoding)
+			}
+			if file.TXID != txid || file.ChainID != "vrsctest" {
+				t.Errorf("cached file identity not restored: txid=%q chain=%q", file.TXID, file.ChainID)
+			}
+			if len(lookups) != len(tt.wantLookups) {
+				t.Fatalf("lookups = %v, want %v", lookups, tt.wantLookups)
+			}
+			for i := range lookups {
+				if lookups[i] != tt.wantLookups[i] {
+					t.Errorf("lookup[%d] = %q, want %q", i, lookups[i], tt.wantLookups[i])
+				}
+			}
+		})
+	}
+}
+
+// testEVK is a syntactically valid viewing key for tests
+const testEVK = "zxviews1q0duytgcqqqqpqre26wkl45gvwwwd706xw608hucmvfalr8rgq93rrg27zzp4j7r2rqd8dlsjg7uw7hghtsabcdef"
+
+// newRPCChainManager creates a chain manager for "vrsctest" backed by a
+// stand-in RPC server that answers decryptdata with payload
+func newRPCChainManager(t *testing.T, payload []byte, limits config.LimitsConfig) *chain.Manager {
+	t.Helper()
+	return newRPCChainManagerFunc(t, func(string) []byte { return payload }, limits)
+}
+
+// Block fixture served by the test RPC server: every transaction is mined
+// in testBlockHeight, and the chain tip is testTipHeight
+const (
+	testBlockHash   = "0000000000000000000000000000000000000000000000000000000000000abc"
+	testBlockHeight = 100
+	testBlockTime   = 1700000000
+	testTipHeight   = 105
+)
+
+// newRPCChainManagerFunc creates a chain manager whose RPC server answers
+// decryptdata with the payload returned by lookup for the requested txid,
+// and block queries from the block fixture
+func newRPCChainManagerFunc(t *testing.T, lookup func(txid string) []byte, limits config.LimitsConfig) *chain.Manager {
+	t.Helper()
+	return newRPCChainManagerWith(t, lookup, limits, nil)
+}
+
+// newRPCChainManagerWith is newRPCChainManagerFunc with a hook to adjust the
+// chain configuration
+func newRPCChainManagerWith(t *testing.T, lookup func(txid string) []byte, limits config.LimitsConfig, configure func(*config.ChainConfig)) *chain.Manager {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rpcReq struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&rpcReq)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch rpcReq.Method {
+		case "getrawtransaction":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"blockhash":"%s","height":%d,"confirmations":%d}}`,
+				testBlockHash, testBlockHeight, testTipHeight-testBlockHeight+1)
+			return
+		case "getblockheader":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"hash":"%s","height":%d,"confirmations":%d,"time":%d}}`,
+				testBlockHash, testBlockHeight, testTipHeight-testBlockHeight+1, testBlockTime)
+			return
+		case "getblockcount":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%d}`, testTipHeight)
+			return
+		case "getblockhash":
+			var height int64
+			_ = json.Unmarshal(rpcReq.Params[0], &height)
+			hash := fmt.Sprintf("%064x", height)
+			if height == testBlockHeight {
+				hash = testBlockHash
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"%s"}`, hash)
+			return
+		}
+
+		var params struct {
+			TXID string `json:"txid"`
+		}
+		if len(rpcReq.Params) > 0 {
+			_ = json.Unmarshal(rpcReq.Params[0], &params)
+		}
+
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":[{"objectdata":"%s"}]}`, hex.EncodeToString(lookup(params.TXID)))
+	}))
+	t.Cleanup(server.Close)
+
+	chainCfg := config.ChainConfig{
+		Name:        "Test",
+		Enabled:     true,
+		RPCURL:      server.URL,
+		RPCUser:     "user",
+		RPCPassword: "pass",
+		RPCTimeout:  5 * time.Second,
+		MaxRetries:  1,
+		RetryDelay:  time.Millisecond,
+	}
+	if configure != nil {
+		configure(&chainCfg)
+	}
+
+	mgr, err := chain.NewManager(&config.Config{
+		Limits: limits,
+		Chains: config.ChainsConfig{
+			Default: "vrsctest",
+			Chains:  map[string]config.ChainConfig{"vrsctest": chainCfg},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create chain manager: %v", err)
+	}
+	t.Cleanup(func() { _ = mgr.Close() })
+
+	return mgr
+}
+
+func TestGetFile_SizeLimits(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	plain := bytes.Repeat([]byte("x"), 4096)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(plain)
+	gw.Close()
+
+	tests := []struct {
+		name          string
+		payload       []byte
+		limits        config.LimitsConfig
+		wantLimitType string
+	}{
+		{
+			name:    "within limits",
+			payload: gz.Bytes(),
+			limits:  config.LimitsConfig{MaxRawSize: 8192, MaxDecompressedSize: 8192},
+		},
+		{
+			name:          "raw payload too large",
+			payload:       plain,
+			limits:        config.LimitsConfig{MaxRawSize: 1024},
+			wantLimitType: "raw",
+		},
+		{
+			name:          "rpc response refused before buffering",
+			payload:       bytes.Repeat([]byte("y"), 100*1024),
+			limits:        config.LimitsConfig{MaxRawSize: 1024},
+			wantLimitType: "raw",
+		},
+		{
+			name:          "decompressed size too large",
+			payload:       gz.Bytes(),
+			limits:        config.LimitsConfig{MaxRawSize: 8192, MaxDecompressedSize: 1024},
+			wantLimitType: "decompressed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestFileService(nil, newRPCChainManager(t, tt.payload, tt.limits))
+
+			file, err := service.GetFile(context.Background(), &domain.FileRequest{
+				TXID:    txid,
+				ChainID: "vrsctest",
+				EVK:     testEVK,
+			})
+
+			if tt.wantLimitType == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !bytes.Equal(file.Content, plain) {
+					t.Error("content does not match decompressed payload")
+				}
+				return
+			}
+
+			var domainErr *domain.Error
+			if !errors.As(err, &domainErr) {
+				t.Fatalf("expected domain error, got %v", err)
+			}
+			if domainErr.HTTPStatus != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want 413", domainErr.HTTPStatus)
+			}
+			if domainErr.Details["limit_type"] != tt.wantLimitType {
+				t.Errorf("limit_type = %v, want %s", domainErr.Details["limit_type"], tt.wantLimitType)
+			}
+		})
+	}
+}
+
+// chunkedFixture builds a manifest splitting content into chunks of chunkSize
+// bytes, with chunk txids derived from their index
+func chunkedFixture(content []byte, chunkSize int) (*domain.ChunkManifest, map[string][]byte) {
+	manifest := &domain.ChunkManifest{
+		Type:     domain.ChunkManifestType,
+		Filename: "report.pdf",
+		Size:     int64(len(content)),
+	}
+	chunks := make(map[string][]byte)
+
+	for i := 0; i*chunkSize < len(content); i++ {
+		data := content[i*chunkSize : min((i+1)*chunkSize, len(content))]
+		txid := fmt.Sprintf("%064x", i+1)
+		sum := sha256.Sum256(data)
+		manifest.Chunks = append(manifest.Chunks, domain.Chunk{
+			TXID:   txid,
+			Size:   int64(len(data)),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+		chunks[txid] = data
+	}
+
+	return manifest, chunks
+}
+
+func TestGetFile_ChunkManifest(t *testing.T) {
+	manifestTXID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	manifest, chunks := chunkedFixture(bytes.Repeat([]byte("0123456789"), 10), 32)
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	chunks[manifestTXID] = manifestJSON
+
+	tests := []struct {
+		name    string
+		limits  config.LimitsConfig
+		wantErr error
+	}{
+		{name: "manifest resolved"},
+		{name: "assembled size over limit", limits: config.LimitsConfig{MaxDecompressedSize: 50}, wantErr: domain.ErrFileTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newRPCChainManagerFunc(t, func(txid string) []byte { return chunks[txid] }, tt.limits)
+			service := newTestFileService(nil, mgr)
+
+			file, err := service.GetFile(context.Background(), &domain.FileRequest{
+				TXID:    manifestTXID,
+				ChainID: "vrsctest",
+				EVK:     testEVK,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if file.Manifest == nil {
+				t.Fatal("expected manifest to be resolved")
+			}
+			if file.Metadata.Size != manifest.Size {
+				t.Errorf("size = %d, want %d", file.Metadata.Size, manifest.Size)
+			}
+			if file.Metadata.ContentType != "application/pdf" {
+				t.Errorf("content type = %q, want application/pdf", file.Metadata.ContentType)
+			}
+		})
+	}
+}
+
+func TestStreamChunks(t *testing.T) {
+	content := []byte("the quick brown fox jumps over the lazy dog, again and again and again")
+	manifest, chunks := chunkedFixture(content, 8)
+
+	tests := []struct {
+		name    string
+		offset  int64
+		length  int64
+		corrupt string
+		wantErr error
+	}{
+		{name: "whole file", offset: 0, length: int64(len(content))},
+		{name: "range within one chunk", offset: 2, length: 4},
+		{name: "range across chunks", offset: 5, length: 30},
+		{name: "tail", offset: int64(len(content)) - 3, length: 3},
+		{name: "corrupt chunk", offset: 0, length: int64(len(content)), corrupt: manifest.Chunks[2].TXID, wantErr: domain.ErrChunkIntegrity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newRPCChainManagerFunc(t, func(txid string) []byte {
+				if txid == tt.corrupt {
+					return bytes.ToUpper(chunks[txid])
+				}
+				return chunks[txid]
+			}, config.LimitsConfig{})
+			service := newTestFileService(nil, mgr)
+
+			var out bytes.Buffer
+			err := service.StreamChunks(context.Background(), &domain.FileRequest{
+				ChainID: "vrsctest",
+				EVK:     testEVK,
+			}, manifest, &out, tt.offset, tt.length)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := content[tt.offset : tt.offset+tt.length]
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("got %q, want %q", out.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestGetDirectory(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	entryTXID := "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr error
+	}{
+		{
+			name:    "directory manifest",
+			payload: `{"type":"verus-gateway/directory/v1","files":{"docs/a.md":{"txid":"` + entryTXID + `"}}}`,
+		},
+		{
+			name:    "not a manifest",
+			payload: "plain text file",
+			wantErr: domain.ErrInvalidManifest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestFileService(nil, newRPCChainManager(t, []byte(tt.payload), config.LimitsConfig{}))
+
+			manifest, err := service.GetDirectory(context.Background(), &domain.FileRequest{
+				TXID:    txid,
+				ChainID: "vrsctest",
+				EVK:     testEVK,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, ok := manifest.Lookup("docs/a.md"); !ok {
+				t.Error("expected docs/a.md in manifest")
+			}
+		})
+	}
+}
+
+func TestGetArchive(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	// A gzip-compressed tarball, as produced by tar -czf
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	tw.WriteHeader(&tar.Header{Name: "site/index.html", Mode: 0o644, Size: 5, Typeflag: tar.TypeReg})
+	tw.Write([]byte("hello"))
+	tw.Close()
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(tarBuf.Bytes())
+	gw.Close()
+
+	var calls atomic.Int32
+	mgr := newRPCChainManagerFunc(t, func(string) []byte {
+		calls.Add(1)
+		return gz.Bytes()
+	}, config.LimitsConfig{})
+	service := newTestFileService(nil, mgr)
+
+	req := &domain.FileRequest{TXID: txid, ChainID: "vrsctest", EVK: testEVK, UseCache: true}
+
+	for i := 0; i < 2; i++ {
+		archive, err := service.GetArchive(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := archive.ReadFile("site/index.html")
+		if err != nil || string(data) != "hello" {
+			t.Fatalf("ReadFile() = %q, %v", data, err)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("RPC called %d times, want 1 (parsed archive should be reused)", got)
+	}
+}
+
+func TestGetArchive_NotArchive(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	service := newTestFileService(nil, newRPCChainManager(t, []byte("plain text, not an archive"), config.LimitsConfig{}))
+
+	_, err := service.GetArchive(context.Background(), &domain.FileRequest{
+		TXID:    txid,
+		ChainID: "vrsctest",
+		EVK:     testEVK,
+	})
+	if !errors.Is(err, domain.ErrUnsupportedFormat) {
+		t.Fatalf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestGetFile_Transform(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 80, 40)))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		limits  config.LimitsConfig
+		wantErr error
+	}{
+		{name: "png resized", payload: img.Bytes()},
+		{name: "not an image", payload: []byte("plain text"), wantErr: domain.ErrUnsupportedFormat},
+		{name: "too many pixels", payload: img.Bytes(), limits: config.LimitsConfig{MaxImagePixels: 100}, wantErr: domain.ErrFileTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestFileService(nil, newRPCChainManager(t, tt.payload, tt.limits))
+
+			transform := &domain.ImageTransform{Width: 20}
+			transform.Normalize()
+			file, err := service.GetFile(context.Background(), &domain.FileRequest{
+				TXID:      txid,
+				ChainID:   "vrsctest",
+				EVK:       testEVK,
+				Transform: transform,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cfg, err := png.DecodeConfig(bytes.NewReader(file.Content))
+			if err != nil {
+				t.Fatalf("result is not a png: %v", err)
+			}
+			if cfg.Width != 20 || cfg.Height != 10 {
+				t.Errorf("size = %dx%d, want 20x10", cfg.Width, cfg.Height)
+			}
+			if file.Variant != transform.Key() {
+				t.Errorf("variant = %q, want %q", file.Variant, transform.Key())
+			}
+		})
+	}
+}
+
+func TestGetFile_BlockInfo(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	mgr := newRPCChainManager(t, []byte("hello world"), config.LimitsConfig{})
+
+	tests := []struct {
+		name   string
+		cached *domain.BlockInfo // nil = cache miss
+	}{
+		{name: "fresh fetch"},
+		{name: "cached confirmations refreshed", cached: &domain.BlockInfo{
+			Height: testBlockHeight, Hash: testBlockHash, Time: time.Unix(testBlockTime, 0).UTC(), Confirmations: 1,
+		}},
+		{name: "cached while unconfirmed", cached: &domain.BlockInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &mockCache{
+				getFunc: func(ctx context.Context, key string) (*domain.File, error) {
+					if tt.cached == nil {
+						return nil, errors.New("cache miss")
+					}
+					return &domain.File{
+						Content:  []byte("hello world"),
+						Metadata: &domain.FileMetadata{ContentType: "text/plain", Block: tt.cached},
+					}, nil
+				},
+			}
+			service := newTestFileService(cache, mgr)
+
+			file, err := service.GetFile(context.Background(), &domain.FileRequest{
+				TXID:     txid,
+				ChainID:  "vrsctest",
+				EVK:      testEVK,
+				UseCache: true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			block := file.Metadata.Block
+			if block == nil {
+				t.Fatal("expected block info")
+			}
+			if block.Hash != testBlockHash || block.Height != testBlockHeight {
+				t.Errorf("block = %+v, want height %d hash %s", block, testBlockHeight, testBlockHash)
+			}
+			if want := int64(testTipHeight - testBlockHeight + 1); block.Confirmations != want {
+				t.Errorf("confirmations = %d, want %d", block.Confirmations, want)
+			}
+			if tt.cached == nil || !tt.cached.Confirmed() {
+				if file.Metadata.CreatedAt == nil || !file.Metadata.CreatedAt.Equal(time.Unix(testBlockTime, 0)) {
+					t.Errorf("CreatedAt = %v, want block time", file.Metadata.CreatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestGetFile_MinConfirmations(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	confirmations := int64(testTipHeight - testBlockHeight + 1)
+
+	tests := []struct {
+		name            string
+		minConf         int64
+		policy          string
+		status          int
+		wantStatus      int
+		wantUnconfirmed bool
+		wantCached      bool
+	}{
+		{name: "no minimum", wantCached: true},
+		{name: "minimum met", minConf: confirmations, wantCached: true},
+		{name: "rejected with default status", minConf: confirmations + 1, wantStatus: http.StatusTooEarly},
+		{name: "rejected with conflict status", minConf: confirmations + 1, status: http.StatusConflict, wantStatus: http.StatusConflict},
+		{name: "served uncached", minConf: confirmations + 1, policy: config.UnconfirmedServe, wantUnconfirmed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newRPCChainManagerWith(t, func(string) []byte { return []byte("hello world") }, config.LimitsConfig{},
+				func(c *config.ChainConfig) {
+					c.MinConfirmations = tt.minConf
+					c.UnconfirmedPolicy = tt.policy
+					c.UnconfirmedStatus = tt.status
+				})
+
+			// Caching is asynchronous
+			setCalls := make(chan struct{}, 1)
+			cache := &mockCache{
+				setFunc: func(ctx context.Context, key string, file *domain.File, ttl time.Duration) error {
+					setCalls <- struct{}{}
+					return nil
+				},
+			}
+			service := newTestFileService(cache, mgr)
+
+			file, err := service.GetFile(context.Background(), &domain.FileRequest{
+				TXID:     txid,
+				ChainID:  "vrsctest",
+				EVK:      testEVK,
+				UseCache: true,
+			})
+			if tt.wantStatus != 0 {
+				var domainErr *domain.Error
+				if !errors.As(err, &domainErr) {
+					t.Fatalf("expected domain error, got %v", err)
+				}
+				if !errors.Is(err, domain.ErrUnconfirmed) || domainErr.HTTPStatus != tt.wantStatus {
+					t.Errorf("error = %v (status %d), want insufficient confirmations (status %d)", err, domainErr.HTTPStatus, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if file.Metadata.Unconfirmed != tt.wantUnconfirmed {
+				t.Errorf("Unconfirmed = %v, want %v", file.Metadata.Unconfirmed, tt.wantUnconfirmed)
+			}
+			cached := false
+			select {
+			case <-setCalls:
+				cached = true
+			case <-time.After(100 * time.Millisecond):
+			}
+			if cached != tt.wantCached {
+				t.Errorf("cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestHandleBlockEvent_InvalidatesOrphaned(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	mgr := newRPCChainManager(t, []byte("hello world"), config.LimitsConfig{})
+
+	setCalls := make(chan string, 1)
+	deleted := make(chan string, 1)
+	cache := &mockCache{
+		setFunc: func(ctx context.Context, key string, file *domain.File, ttl time.Duration) error {
+			setCalls <- key
+			return nil
+		},
+		deleteFunc: func(ctx context.Context, key string) error {
+			deleted <- key
+			return nil
+		},
+	}
+	service := newTestFileService(cache, mgr)
+
+	req := &domain.FileRequest{TXID: txid, ChainID: "vrsctest", EVK: testEVK, UseCache: true}
+	if _, err := service.GetFile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var key string
+	select {
+	case key = <-setCalls:
+	case <-time.After(time.Second):
+		t.Fatal("file was not cached")
+	}
+
+	// A reorg elsewhere leaves the entry alone
+	service.HandleBlockEvent(chain.BlockEvent{
+		ChainID:  "vrsctest",
+		Tip:      chain.BlockRef{Height: testTipHeight, Hash: "tip"},
+		Orphaned: []chain.BlockRef{{Height: testBlockHeight + 1, Hash: "other"}},
+	})
+	select {
+	case got := <-deleted:
+		t.Fatalf("unexpected invalidation of %s", got)
+	default:
+	}
+
+	service.HandleBlockEvent(chain.BlockEvent{
+		ChainID:  "vrsctest",
+		Tip:      chain.BlockRef{Height: testTipHeight, Hash: "tip"},
+		Orphaned: []chain.BlockRef{{Height: testBlockHeight, Hash: testBlockHash}},
+	})
+	select {
+	case got := <-deleted:
+		if got != key {
+			t.Errorf("invalidated %s, want %s", got, key)
+		}
+	default:
+		t.Error("cache entry from orphaned block was not invalidated")
+	}
+}
+
+func TestGetFile_OrphanedCacheEntry(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	mgr := newRPCChainManager(t, []byte("hello world"), config.LimitsConfig{})
+	if _, err := mgr.SyncBlocks(context.Background(), "vrsctest"); err != nil {
+		t.Fatalf("SyncBlocks() error = %v", err)
+	}
+
+	deleted := make(chan string, 1)
+	cache := &mockCache{
+		getFunc: func(ctx context.Context, key string) (*domain.File, error) {
+			return &domain.File{
+				Content: []byte("orphaned content"),
+				Metadata: &domain.FileMetadata{
+					ContentType: "text/plain",
+					Block:       &domain.BlockInfo{Height: testBlockHeight, Hash: "stale", Confirmations: 3},
+				},
+			}, nil
+		},
+		deleteFunc: func(ctx context.Context, key string) error {
+			deleted <- key
+			return nil
+		},
+	}
+	service := newTestFileService(cache, mgr)
+
+	req := &domain.FileRequest{TXID: txid, ChainID: "vrsctest", EVK: testEVK, UseCache: true}
+	file, err := service.GetFile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(file.Content) != "hello world" || file.Metadata.Block.Hash != testBlockHash {
+		t.Errorf("got %q from block %s, want content refetched from the best chain", file.Content, file.Metadata.Block.Hash)
+	}
+	select {
+	case got := <-deleted:
+		if got != req.CacheKey(service.keySecret) {
+			t.Errorf("invalidated %s, want %s", got, req.CacheKey(service.keySecret))
+		}
+	default:
+		t.Error("orphaned cache entry was not invalidated")
+	}
+}
+
+func TestHandleBlockEvent_PromotesPending(t *testing.T) {
+	txid := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	key := "vrsctest:" + txid
+	mgr := newRPCChainManager(t, []byte("hello world"), config.LimitsConfig{})
+
+	var saved *domain.File
+	cache := &mockCache{
+		getFunc: func(ctx context.Context, key string) (*domain.File, error) {
+			// Cached while the transaction was in the mempool
+			return &domain.File{
+				Content:  []byte("hello world"),
+				Metadata: &domain.FileMetadata{ContentType: "text/plain", Block: &domain.BlockInfo{}},
+			}, nil
+		},
+		setFunc: func(ctx context.Context, key string, file *domain.File, ttl time.Duration) error {
+			saved = file
+			return nil
+		},
+	}
+	service := newTestFileService(cache, mgr)
+	service.pending.Add("vrsctest", txid, key)
+
+	service.HandleBlockEvent(chain.BlockEvent{
+		ChainID: "vrsctest",
+		Tip:     chain.BlockRef{Height: testTipHeight, Hash: "tip"},
+	})
+
+	if saved == nil || saved.Metadata.Block == nil || saved.Metadata.Block.Hash != testBlockHash {
+		t.Fatalf("cached entry not promoted: %+v", saved)
+	}
+	if saved.Metadata.CreatedAt == nil {
+		t.Error("CreatedAt not set from block time")
+	}
+	if entries := service.pending.Entries("vrsctest"); len(entries) != 0 {
+		t.Errorf("pending entries = %v, want none", entries)
+	}
+	if keys := service.blocks.Take("vrsctest", testBlockHash); len(keys) != 1 || keys[0] != key {
+		t.Errorf("block index keys = %v, want [%s]", keys, key)
+	}
+}
+
+func TestChainFailures(t *testing.T) {
+	var f chainFailures
+
+	if !f.fail("vrsctest") {
+		t.Error("first failure not reported")
+	}
+	if f.fail("vrsctest") {
+		t.Error("repeated failure reported again")
+	}
+	if !f.fail("VRSC") {
+		t.Error("failure on another chain not reported")
+	}
+
+	// A recovery re-arms the warning
+	f.ok("vrsctest")
+	if !f.fail("vrsctest") {
+		t.Error("failure after recovery not reported")
+	}
+}
@@ -0,0 +1,380 @@
+// Package share issues and redeems signed, expiring share links that grant
+// access to an encrypted file without revealing its viewing key.
+package share
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// tokenPrefix starts every share token and versions its format
+const tokenPrefix = "vgs1."
+
+// Default lifetimes
+const (
+	DefaultTTL    = 24 * time.Hour
+	DefaultMaxTTL = 30 * 24 * time.Hour
+)
+
+// Errors returned when a token can't be redeemed
+var (
+	ErrInvalidToken = errors.New("share token is invalid")
+	ErrTokenExpired = errors.New("share link has expired")
+	ErrTokenRevoked = errors.New("share link has been revoked")
+	ErrIPNotAllowed = errors.New("share link is not valid from this address")
+	ErrLimitReached = errors.New("share link download limit reached")
+)
+
+// tokenError wraps a redemption error in a domain error for the response
+func tokenError(err error) *domain.Error {
+	switch err {
+	case ErrTokenExpired:
+		return domain.NewError("TOKEN_EXPIRED", err.Error(), http.StatusUnauthorized, err)
+	case ErrTokenRevoked:
+		return domain.NewError("TOKEN_REVOKED", err.Error(), http.StatusUnauthorized, err)
+	case ErrIPNotAllowed:
+		return domain.NewError("FORBIDDEN", err.Error(), http.StatusForbidden, err)
+	case ErrLimitReached:
+		return domain.NewError("DOWNLOAD_LIMIT_REACHED", err.Error(), http.StatusForbidden, err)
+	default:
+		return domain.NewError("INVALID_TOKEN", ErrInvalidToken.Error(), http.StatusUnauthorized, ErrInvalidToken)
+	}
+}
+
+// Config holds share link configuration
+type Config struct {
+	// Secret seals viewing keys and signs tokens
+	Secret []byte
+
+	// DefaultTTL applies when a link is issued without a lifetime
+	DefaultTTL time.Duration
+
+	// MaxTTL bounds link lifetimes; revocations are kept this long
+	MaxTTL time.Duration
+
+	// StateFile persists revocations and download counts (empty = memory only)
+	StateFile string
+}
+
+// Options describe a share link to issue
+type Options struct {
+	Chain   string
+	TXID    string
+	EVK     string
+	TTL     time.Duration // 0 = default
+	MaxUses int           // 0 = unlimited
+	IPRange string        // CIDR or single address (empty = any)
+}
+
+// Grant is the access a share token carries
+type Grant struct {
+	ID        string
+	Chain     string
+	TXID      string
+	EVK       string
+	ExpiresAt time.Time
+	MaxUses   int
+	IPRange   string
+
+	counted bool // A download was reserved for this grant
+}
+
+// claims is the signed token payload. The viewing key is sealed; the other
+// fields are readable but can't be changed without invalidating the MAC.
+type claims struct {
+	ID        string `json:"id"`
+	Chain     string `json:"c"`
+	TXID      string `json:"t"`
+	SealedEVK string `json:"k"`
+	ExpiresAt int64  `json:"e"`
+	MaxUses   int    `json:"n,omitempty"`
+	IPRange   string `json:"ip,omitempty"`
+}
+
+// Manager issues and redeems share tokens
+type Manager struct {
+	aead       cipher.AEAD
+	macKey     []byte
+	store      *Store
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	now        func() time.Time
+}
+
+// NewManager creates a share link manager
+func NewManager(cfg Config) (*Manager, error) {
+	if len(cfg.Secret) < 32 {
+		return nil, fmt.Errorf("share secret must be at least 32 bytes")
+	}
+	if cfg.DefaultTTL <= 0 {
+		cfg.DefaultTTL = DefaultTTL
+	}
+	if cfg.MaxTTL <= 0 {
+		cfg.MaxTTL = DefaultMaxTTL
+	}
+
+	block, err := aes.NewCipher(deriveKey(cfg.Secret, "seal"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := NewStore(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		aead:       aead,
+		macKey:     deriveKey(cfg.Secret, "sign"),
+		store:      store,
+		defaultTTL: cfg.DefaultTTL,
+		maxTTL:     cfg.MaxTTL,
+		now:        time.Now,
+	}, nil
+}
+
+// deriveKey derives a purpose-specific 32-byte key from the secret
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("verus-gateway share " + purpose))
+	return mac.Sum(nil)
+}
+
+// Issue creates a share token for opts
+func (m *Manager) Issue(opts Options) (string, *Grant, error) {
+	if opts.EVK == "" {
+		return "", nil, domain.NewInvalidInputError("evk", "a viewing key is required")
+	}
+	if opts.TTL < 0 || opts.TTL > m.maxTTL {
+		return "", nil, domain.NewInvalidInputError("ttl", fmt.Sprintf("must be between 0 and %s", m.maxTTL))
+	}
+	if opts.TTL == 0 {
+		opts.TTL = m.defaultTTL
+	}
+	if opts.MaxUses < 0 {
+		return "", nil, domain.NewInvalidInputError("max_downloads", "must not be negative")
+	}
+	if opts.IPRange != "" {
+		prefix, err := parseIPRange(opts.IPRange)
+		if err != nil {
+			return "", nil, domain.NewInvalidInputError("allowed_ips", "must be an IP address or CIDR range")
+		}
+		opts.IPRange = prefix.String()
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	c := claims{
+		ID:        hex.EncodeToString(id),
+		Chain:     opts.Chain,
+		TXID:      strings.ToLower(opts.TXID),
+		ExpiresAt: m.now().Add(opts.TTL).Unix(),
+		MaxUses:   opts.MaxUses,
+		IPRange:   opts.IPRange,
+	}
+
+	sealed, err := m.seal(opts.EVK, c.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	c.SealedEVK = sealed
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", nil, err
+	}
+	signed := tokenPrefix + base64.RawURLEncoding.EncodeToString(payload)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(m.sign(signed)), grantFrom(c, opts.EVK), nil
+}
+
+// Redeem verifies a token for a file and client address, reserves a
+// download and returns its grant. Callers that fail to serve the file hand
+// the download back with Release. Errors are *domain.Error wrapping one of
+// the Err variables.
+func (m *Manager) Redeem(token, chainID, txid, clientIP string) (*Grant, error) {
+	return m.redeem(token, chainID, txid, clientIP, false)
+}
+
+// Resume is Redeem for a request that continues a download, such as a
+// Range request past the first byte. Once a link has been downloaded it
+// doesn't reserve another download, so resumed and partial fetches aren't
+// locked out by the limit; before that, it counts like Redeem.
+func (m *Manager) Resume(token, chainID, txid, clientIP string) (*Grant, error) {
+	return m.redeem(token, chainID, txid, clientIP, true)
+}
+
+// redeem implements Redeem and Resume
+func (m *Manager) redeem(token, chainID, txid, clientIP string, resume bool) (*Grant, error) {
+	c, err := m.verify(token)
+	if err != nil {
+		return nil, tokenError(err)
+	}
+
+	if c.Chain != chainID || !strings.EqualFold(c.TXID, txid) {
+		return nil, tokenError(ErrInvalidToken)
+	}
+	if !m.now().Before(time.Unix(c.ExpiresAt, 0)) {
+		return nil, tokenError(ErrTokenExpired)
+	}
+	if m.store.Revoked(c.ID) {
+		return nil, tokenError(ErrTokenRevoked)
+	}
+	if c.IPRange != "" {
+		prefix, err := parseIPRange(c.IPRange)
+		addr, addrErr := parseClientIP(clientIP)
+		if err != nil || addrErr != nil || !prefix.Contains(addr) {
+			return nil, tokenError(ErrIPNotAllowed)
+		}
+	}
+	counted := false
+	if c.MaxUses > 0 && !(resume && m.store.Used(c.ID)) {
+		if !m.store.Use(c.ID, c.MaxUses, time.Unix(c.ExpiresAt, 0)) {
+			return nil, tokenError(ErrLimitReached)
+		}
+		counted = true
+	}
+
+	evk, err := m.open(c.SealedEVK, c.ID)
+	if err != nil {
+		if counted {
+			m.store.Release(c.ID)
+		}
+		return nil, tokenError(ErrInvalidToken)
+	}
+
+	grant := grantFrom(*c, evk)
+	grant.counted = counted
+	return grant, nil
+}
+
+// Release returns a download reserved by Redeem, so failed fetches don't
+// count against the link's download limit
+func (m *Manager) Release(grant *Grant) {
+	if grant.counted {
+		m.store.Release(grant.ID)
+	}
+}
+
+// Revoke invalidates a token by ID. The revocation is kept for the maximum
+// link lifetime, after which the token has expired anyway.
+func (m *Manager) Revoke(id string) error {
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+		return domain.NewInvalidInputError("id", "must be a share token id")
+	}
+	return m.store.Revoke(id, m.now().Add(m.maxTTL))
+}
+
+// verify checks a token's signature and decodes its claims
+func (m *Manager) verify(token string) (*claims, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	dot := strings.LastIndexByte(token, '.')
+	if dot <= len(tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	signed, sig := token[:dot], token[dot+1:]
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, m.sign(signed)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(signed[len(tokenPrefix):])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	return &c, nil
+}
+
+// sign returns the HMAC of a token's signed part
+func (m *Manager) sign(signed string) []byte {
+	mac := hmac.New(sha256.New, m.macKey)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+// seal encrypts a viewing key, bound to the token ID
+func (m *Manager) seal(evk, id string) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(m.aead.Seal(nonce, nonce, []byte(evk), []byte(id))), nil
+}
+
+// open reverses seal
+func (m *Manager) open(sealed, id string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(data) < m.aead.NonceSize() {
+		return "", ErrInvalidToken
+	}
+	evk, err := m.aead.Open(nil, data[:m.aead.NonceSize()], data[m.aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", err
+	}
+	return string(evk), nil
+}
+
+// grantFrom builds a grant from verified claims
+func grantFrom(c claims, evk string) *Grant {
+	return &Grant{
+		ID:        c.ID,
+		Chain:     c.Chain,
+		TXID:      c.TXID,
+		EVK:       evk,
+		ExpiresAt: time.Unix(c.ExpiresAt, 0).UTC(),
+		MaxUses:   c.MaxUses,
+		IPRange:   c.IPRange,
+	}
+}
+
+// parseIPRange parses a CIDR range or a single address
+func parseIPRange(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// parseClientIP parses a client address as set by the RealIP middleware:
+// "ip" or "ip:port"
+func parseClientIP(value string) (netip.Addr, error) {
+	value = strings.TrimSpace(value)
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	return addr.Unmap(), err
+}
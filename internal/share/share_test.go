@@ -0,0 +1,197 @@
+package share
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+const (
+	testSecret = "0123456789abcdef0123456789abcdef"
+	testTXID   = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testEVK    = "zxviews1qtestviewingkey"
+)
+
+func newTestManager(t *testing.T, stateFile string) *Manager {
+	t.Helper()
+	m, err := NewManager(Config{Secret: []byte(testSecret), StateFile: stateFile})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	return m
+}
+
+// redeemErr returns the sentinel error a failed redemption wraps
+func redeemErr(err error) error {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Err
+	}
+	return err
+}
+
+func TestManager_IssueAndRedeem(t *testing.T) {
+	m := newTestManager(t, "")
+
+	token, issued, err := m.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if strings.Contains(token, testEVK) || !strings.HasPrefix(token, tokenPrefix) {
+		t.Errorf("token %q exposes the viewing key or lacks its prefix", token)
+	}
+	if d := time.Until(issued.ExpiresAt); d < 23*time.Hour || d > DefaultTTL {
+		t.Errorf("ExpiresAt in %s, want the default TTL", d)
+	}
+
+	grant, err := m.Redeem(token, "vrsctest", testTXID, "203.0.113.7:51234")
+	if err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+	if grant.EVK != testEVK || grant.TXID != testTXID || grant.ID != issued.ID {
+		t.Errorf("Redeem() = %+v, want the issued grant", grant)
+	}
+
+	// Tokens are bound to their chain and file
+	if _, err := m.Redeem(token, "vrsc", testTXID, ""); redeemErr(err) != ErrInvalidToken {
+		t.Errorf("Redeem() on another chain error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := m.Redeem(token, "vrsctest", strings.Repeat("f", 64), ""); redeemErr(err) != ErrInvalidToken {
+		t.Errorf("Redeem() for another txid error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestManager_RedeemRejectsTampering(t *testing.T) {
+	m := newTestManager(t, "")
+	token, _, _ := m.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK})
+
+	other, err := NewManager(Config{Secret: []byte("another secret of at least 32 bytes")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, _, _ := other.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK})
+
+	dot := strings.LastIndexByte(token, '.')
+	tests := map[string]string{
+		"empty":          "",
+		"wrong prefix":   "vgs0." + token[len(tokenPrefix):],
+		"no signature":   token[:dot],
+		"bad signature":  token[:dot+1] + "AAAA",
+		"other secret":   foreign,
+		"changed claims": tokenPrefix + "e30" + token[dot:],
+	}
+	for name, tampered := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := m.Redeem(tampered, "vrsctest", testTXID, ""); redeemErr(err) != ErrInvalidToken {
+				t.Errorf("Redeem() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestManager_RedeemConstraints(t *testing.T) {
+	m := newTestManager(t, "")
+
+	// Expiry
+	token, _, _ := m.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK, TTL: time.Minute})
+	m.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := m.Redeem(token, "vrsctest", testTXID, ""); redeemErr(err) != ErrTokenExpired {
+		t.Errorf("Redeem() after expiry error = %v, want ErrTokenExpired", err)
+	}
+	m.now = time.Now
+
+	// IP range
+	token, _, _ = m.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK, IPRange: "203.0.113.0/24"})
+	if _, err := m.Redeem(token, "vrsctest", testTXID, "203.0.113.9"); err != nil {
+		t.Errorf("Redeem() inside range error = %v", err)
+	}
+	if _, err := m.Redeem(token, "vrsctest", testTXID, "198.51.100.1:443"); redeemErr(err) != ErrIPNotAllowed {
+		t.Errorf("Redeem() outside range error = %v, want ErrIPNotAllowed", err)
+	}
+
+	// Download limit
+	token, _, _ = m.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK, MaxUses: 2})
+	var grant *Grant
+	for i := 0; i < 2; i++ {
+		var err error
+		if grant, err = m.Redeem(token, "vrsctest", testTXID, ""); err != nil {
+			t.Fatalf("Redeem() #%d error = %v", i+1, err)
+		}
+	}
+	if _, err := m.Redeem(token, "vrsctest", testTXID, ""); redeemErr(err) != ErrLimitReached {
+		t.Errorf("Redeem() past limit error = %v, want ErrLimitReached", err)
+	}
+
+	// A released download (failed fetch) can be used again
+	m.Release(grant)
+	if _, err := m.Redeem(token, "vrsctest", testTXID, ""); err != nil {
+		t.Errorf("Redeem() after Release error = %v", err)
+	}
+	if _, err := m.Redeem(token, "vrsctest", testTXID, ""); redeemErr(err) != ErrLimitReached {
+		t.Errorf("Redeem() past limit after Release error = %v, want ErrLimitReached", err)
+	}
+
+	// Resuming needs no further download once one was counted
+	resumed, err := m.Resume(token, "vrsctest", testTXID, "")
+	if err != nil {
+		t.Fatalf("Resume() past limit error = %v", err)
+	}
+	m.Release(resumed)
+	if _, err := m.Redeem(token, "vrsctest", testTXID, ""); redeemErr(err) != ErrLimitReached {
+		t.Errorf("Redeem() after releasing a resume error = %v, want ErrLimitReached", err)
+	}
+
+	// A resume of a link not yet downloaded counts as its download
+	token, _, _ = m.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK, MaxUses: 1})
+	if _, err := m.Resume(token, "vrsctest", testTXID, ""); err != nil {
+		t.Fatalf("Resume() first download error = %v", err)
+	}
+	if _, err := m.Redeem(token, "vrsctest", testTXID, ""); redeemErr(err) != ErrLimitReached {
+		t.Errorf("Redeem() after a counted resume error = %v, want ErrLimitReached", err)
+	}
+}
+
+func TestManager_IssueValidation(t *testing.T) {
+	m := newTestManager(t, "")
+
+	tests := map[string]Options{
+		"missing evk":  {Chain: "vrsctest", TXID: testTXID},
+		"ttl too long": {Chain: "vrsctest", TXID: testTXID, EVK: testEVK, TTL: DefaultMaxTTL + time.Hour},
+		"negative max": {Chain: "vrsctest", TXID: testTXID, EVK: testEVK, MaxUses: -1},
+		"bad ip range": {Chain: "vrsctest", TXID: testTXID, EVK: testEVK, IPRange: "10.0.0.0/33"},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := m.Issue(opts); !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("Issue() error = %v, want invalid input", err)
+			}
+		})
+	}
+}
+
+func TestManager_RevokePersists(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "shares.json")
+	m := newTestManager(t, stateFile)
+
+	token, grant, _ := m.Issue(Options{Chain: "vrsctest", TXID: testTXID, EVK: testEVK, MaxUses: 1})
+	if err := m.Revoke(grant.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := m.Redeem(token, "vrsctest", testTXID, ""); redeemErr(err) != ErrTokenRevoked {
+		t.Errorf("Redeem() after revoke error = %v, want ErrTokenRevoked", err)
+	}
+
+	// A restarted gateway still refuses the token
+	restarted := newTestManager(t, stateFile)
+	if _, err := restarted.Redeem(token, "vrsctest", testTXID, ""); redeemErr(err) != ErrTokenRevoked {
+		t.Errorf("Redeem() after restart error = %v, want ErrTokenRevoked", err)
+	}
+
+	if err := m.Revoke("not-an-id"); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Revoke() with bad id error = %v, want invalid input", err)
+	}
+}
@@ -0,0 +1,178 @@
+package share
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store records revoked tokens and download counts. With a path it is
+// persisted as JSON after every change, so revocations survive restarts;
+// entries are dropped once the token they refer to has expired.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	state storeState
+	now   func() time.Time
+}
+
+// storeState is the persisted form of a Store
+type storeState struct {
+	// Revoked maps token IDs to when the revocation can be forgotten
+	Revoked map[string]int64 `json:"revoked"`
+
+	// Uses maps token IDs to their download counts
+	Uses map[string]useCount `json:"uses"`
+}
+
+// useCount is a token's download count
+type useCount struct {
+	Count     int   `json:"count"`
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// NewStore creates a store, loading existing state from path (empty = memory
+// only)
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		state: storeState{
+			Revoked: make(map[string]int64),
+			Uses:    make(map[string]useCount),
+		},
+		now: time.Now,
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read share state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to parse share state: %w", err)
+	}
+	if s.state.Revoked == nil {
+		s.state.Revoked = make(map[string]int64)
+	}
+	if s.state.Uses == nil {
+		s.state.Uses = make(map[string]useCount)
+	}
+
+	return s, nil
+}
+
+// Revoke records a revocation until the given time
+func (s *Store) Revoke(id string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Revoked[id] = until.Unix()
+	return s.save()
+}
+
+// Revoked reports whether a token has been revoked
+func (s *Store) Revoked(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.state.Revoked[id]
+	return ok
+}
+
+// Use counts a download of a token limited to maxUses, returning false
+// without counting once the limit is reached
+func (s *Store) Use(id string, maxUses int, expiresAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uses := s.state.Uses[id]
+	if uses.Count >= maxUses {
+		return false
+	}
+	s.state.Uses[id] = useCount{Count: uses.Count + 1, ExpiresAt: expiresAt.Unix()}
+
+	if err := s.save(); err != nil {
+		fmt.Printf("[WARN] Failed to persist share state: %v\n", err)
+	}
+	return true
+}
+
+// Used reports whether a download of a token has been counted
+func (s *Store) Used(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.Uses[id].Count > 0
+}
+
+// Release returns a download counted by Use, for one that failed before
+// anything was served
+func (s *Store) Release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uses, ok := s.state.Uses[id]
+	if !ok || uses.Count == 0 {
+		return
+	}
+	uses.Count--
+	s.state.Uses[id] = uses
+
+	if err := s.save(); err != nil {
+		fmt.Printf("[WARN] Failed to persist share state: %v\n", err)
+	}
+}
+
+// save prunes expired entries and writes the state to disk. The caller must
+// hold the lock.
+func (s *Store) save() error {
+	now := s.now().Unix()
+	for id, until := range s.state.Revoked {
+		if until <= now {
+			delete(s.state.Revoked, id)
+		}
+	}
+	for id, uses := range s.state.Uses {
+		if uses.ExpiresAt <= now {
+			delete(s.state.Uses, id)
+		}
+	}
+
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so a crash never leaves a truncated file
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".share-state-*")
+	if err != nil {
+		return fmt.Errorf("failed to write share state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write share state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write share state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write share state: %w", err)
+	}
+
+	return nil
+}
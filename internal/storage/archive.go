@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// Archive format names
+const (
+	ArchiveZip = "zip"
+	ArchiveTar = "tar"
+)
+
+// ArchiveEntry describes a regular file inside an archive
+type ArchiveEntry struct {
+	Name           string    `json:"name"`
+	Size           int64     `json:"size"`
+	CompressedSize int64     `json:"compressed_size,omitempty"`
+	Modified       time.Time `json:"modified,omitempty"`
+}
+
+// SiteConfigFile is an optional file in the archive root with settings for
+// serving the archive as a website
+const SiteConfigFile = ".verus-site.json"
+
+// maxSiteConfigSize bounds the SiteConfigFile parsed when an archive is opened
+const maxSiteConfigSize = 64 * 1024
+
+// SiteConfig holds the settings from an archive's SiteConfigFile
+type SiteConfig struct {
+	IndexFile   string `json:"index_file"`
+	SPAFallback *bool  `json:"spa_fallback"`
+	CSP         string `json:"csp"`
+}
+
+// ArchiveConfig holds limits for reading archives
+type ArchiveConfig struct {
+	MaxEntries   int   // Maximum number of entries (default: 10000)
+	MaxEntrySize int64 // Maximum uncompressed entry size (default: 100MB)
+}
+
+// Archive is an indexed ZIP or tar archive held in memory. Entries are read
+// on demand, so serving one entry does not read the whole archive.
+type Archive struct {
+	format       string
+	content      []byte
+	entries      []ArchiveEntry
+	index        map[string]int
+	zipFiles     map[string]*zip.File
+	tarOffsets   map[string]int64
+	maxEntrySize int64
+	site         *SiteConfig
+	siteErr      error
+}
+
+// DetectArchiveFormat returns the archive format of content, or an empty
+// string if content is not a supported archive
+func DetectArchiveFormat(content []byte) string {
+	if bytes.HasPrefix(content, []byte("PK\x03\x04")) || bytes.HasPrefix(content, []byte("PK\x05\x06")) {
+		return ArchiveZip
+	}
+	if isTar(content) {
+		return ArchiveTar
+	}
+	return ""
+}
+
+// IsArchiveType reports whether a MIME type, as reported by Detector, is a
+// supported archive format
+func IsArchiveType(contentType string) bool {
+	switch contentType {
+	case "application/zip", "application/x-tar":
+		return true
+	}
+	return false
+}
+
+// isTar checks for the ustar magic in the first header block
+func isTar(content []byte) bool {
+	return len(content) >= 262 && bytes.Equal(content[257:262], []byte("ustar"))
+}
+
+// OpenArchive indexes a ZIP or tar archive. Entries with unsafe names
+// (absolute paths or ".." elements) and non-regular files are skipped.
+func OpenArchive(content []byte, cfg ArchiveConfig) (*Archive, error) {
+	if cfg.MaxEntries == 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.MaxEntrySize == 0 {
+		cfg.MaxEntrySize = 100 * 1024 * 1024 // 100MB default
+	}
+
+	a := &Archive{
+		format:       DetectArchiveFormat(content),
+		content:      content,
+		index:        make(map[string]int),
+		maxEntrySize: cfg.MaxEntrySize,
+	}
+
+	var err error
+	switch a.format {
+	case ArchiveZip:
+		err = a.indexZip(cfg.MaxEntries)
+	case ArchiveTar:
+		err = a.indexTar(cfg.MaxEntries)
+	default:
+		return nil, domain.NewInvalidInputError("archive", "content is not a ZIP or tar archive")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(a.entries, func(i, j int) bool { return a.entries[i].Name < a.entries[j].Name })
+	for i, entry := range a.entries {
+		a.index[entry.Name] = i
+	}
+
+	a.site, a.siteErr = a.readSiteConfig()
+
+	return a, nil
+}
+
+// readSiteConfig parses the archive's SiteConfigFile, if it has one
+func (a *Archive) readSiteConfig() (*SiteConfig, error) {
+	entry, ok := a.Lookup(SiteConfigFile)
+	if !ok {
+		return nil, nil
+	}
+	if entry.Size > maxSiteConfigSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", SiteConfigFile, maxSiteConfigSize)
+	}
+
+	data, err := a.ReadFile(SiteConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	var site SiteConfig
+	if err := json.Unmarshal(data, &site); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SiteConfigFile, err)
+	}
+	return &site, nil
+}
+
+// indexZip reads the ZIP central directory
+func (a *Archive) indexZip(maxEntries int) error {
+	zr, err := zip.NewReader(bytes.NewReader(a.content), int64(len(a.content)))
+	if err != nil {
+		return domain.NewInvalidInputError("archive", fmt.Sprintf("invalid zip archive: %v", err))
+	}
+	if len(zr.File) > maxEntries {
+		return domain.NewInvalidInputError("archive", fmt.Sprintf("archive has too many entries (max %d)", maxEntries))
+	}
+
+	a.zipFiles = make(map[string]*zip.File)
+	for _, f := range zr.File {
+		name, ok := cleanArchiveName(f.Name)
+		if !ok || !f.Mode().IsRegular() {
+			continue
+		}
+		if _, exists := a.zipFiles[name]; exists {
+			continue
+		}
+
+		a.zipFiles[name] = f
+		a.entries = append(a.entries, ArchiveEntry{
+			Name:           name,
+			Size:           int64(f.UncompressedSize64),
+			CompressedSize: int64(f.CompressedSize64),
+			Modified:       f.Modified,
+		})
+	}
+
+	return nil
+}
+
+// indexTar walks the tar headers, recording where each file's data starts.
+// Tar data is stored uncompressed, so entries are later read by slicing.
+func (a *Archive) indexTar(maxEntries int) error {
+	r := bytes.NewReader(a.content)
+	tr := tar.NewReader(r)
+
+	a.tarOffsets = make(map[string]int64)
+	for count := 0; ; count++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return domain.NewInvalidInputError("archive", fmt.Sprintf("invalid tar archive: %v", err))
+		}
+		if count >= maxEntries {
+			return domain.NewInvalidInputError("archive", fmt.Sprintf("archive has too many entries (max %d)", maxEntries))
+		}
+
+		name, ok := cleanArchiveName(hdr.Name)
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if _, exists := a.tarOffsets[name]; exists {
+			continue
+		}
+
+		// The reader is positioned at the start of the entry's data
+		offset := int64(len(a.content)) - int64(r.Len())
+		if offset+hdr.Size > int64(len(a.content)) {
+			return domain.NewInvalidInputError("archive", "tar entry extends past end of archive")
+		}
+
+		a.tarOffsets[name] = offset
+		a.entries = append(a.entries, ArchiveEntry{
+			Name:     name,
+			Size:     hdr.Size,
+			Modified: hdr.ModTime,
+		})
+	}
+
+	return nil
+}
+
+// cleanArchiveName normalizes an entry name, rejecting names that would
+// escape the archive root
+func cleanArchiveName(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) {
+		return "", false
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." {
+			return "", false
+		}
+	}
+
+	name = path.Clean(name)
+	if name == "." || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// Format returns the archive format
+func (a *Archive) Format() string {
+	return a.format
+}
+
+// Size returns the size of the archive in bytes
+func (a *Archive) Size() int64 {
+	return int64(len(a.content))
+}
+
+// SiteConfig returns the settings from the archive's SiteConfigFile, parsed
+// when the archive was opened. It returns nil if the archive has none, and
+// nil with the error if the file is invalid.
+func (a *Archive) SiteConfig() (*SiteConfig, error) {
+	return a.site, a.siteErr
+}
+
+// Entries returns the archive's files sorted by name
+func (a *Archive) Entries() []ArchiveEntry {
+	return a.entries
+}
+
+// Lookup returns the entry with the given name
+func (a *Archive) Lookup(name string) (ArchiveEntry, bool) {
+	i, ok := a.index[strings.TrimPrefix(path.Clean("/"+name), "/")]
+	if !ok {
+		return ArchiveEntry{}, false
+	}
+	return a.entries[i], true
+}
+
+// ReadFile returns the contents of an entry. Entries larger than the
+// configured limit, or whose data expands beyond their declared size, are
+// rejected.
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+	entry, ok := a.Lookup(name)
+	if !ok {
+		return nil, domain.NewNotFoundError("archive entry", name)
+	}
+	if entry.Size > a.maxEntrySize {
+		return nil, domain.NewFileTooLargeError("decompressed", a.maxEntrySize).WithDetail("entry", entry.Name)
+	}
+
+	if a.format == ArchiveTar {
+		offset := a.tarOffsets[entry.Name]
+		return a.content[offset : offset+entry.Size], nil
+	}
+
+	rc, err := a.zipFiles[entry.Name].Open()
+	if err != nil {
+		return nil, domain.NewDecompressionError(fmt.Sprintf("failed to open entry %s: %v", entry.Name, err))
+	}
+	defer func() { _ = rc.Close() }()
+
+	// Read at most one byte more than declared so lying headers are caught
+	var out bytes.Buffer
+	out.Grow(int(entry.Size))
+	lim := &limitedWriter{W: &out, N: entry.Size + 1}
+	if _, err := io.Copy(lim, rc); err != nil && !errors.Is(err, errSizeLimitExceeded) {
+		return nil, domain.NewDecompressionError(fmt.Sprintf("failed to read entry %s: %v", entry.Name, err))
+	}
+	if int64(out.Len()) != entry.Size {
+		return nil, domain.NewDecompressionError(fmt.Sprintf("entry %s does not match its declared size", entry.Name))
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// buildZip creates a ZIP archive from name/content pairs
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// buildTar creates a tar archive from name/content pairs
+func buildTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenArchive(t *testing.T) {
+	files := map[string]string{
+		"index.html":         "<h1>hi</h1>",
+		"css/site.css":       "body{}",
+		"./js/app.js":        "console.log(1)",
+		"../../etc/passwd":   "root",
+		"/abs/path.txt":      "abs",
+		"docs\\windows.txt":  "win",
+		"a/../../escape.txt": "nope",
+	}
+
+	tests := []struct {
+		name       string
+		content    []byte
+		wantFormat string
+	}{
+		{"zip", buildZip(t, files), ArchiveZip},
+		{"tar", buildTar(t, files), ArchiveTar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := OpenArchive(tt.content, ArchiveConfig{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if archive.Format() != tt.wantFormat {
+				t.Errorf("format = %q, want %q", archive.Format(), tt.wantFormat)
+			}
+
+			var names []string
+			for _, entry := range archive.Entries() {
+				names = append(names, entry.Name)
+			}
+			want := []string{"css/site.css", "docs/windows.txt", "index.html", "js/app.js"}
+			if len(names) != len(want) {
+				t.Fatalf("entries = %v, want %v", names, want)
+			}
+			for i := range want {
+				if names[i] != want[i] {
+					t.Errorf("entry %d = %q, want %q", i, names[i], want[i])
+				}
+			}
+
+			data, err := archive.ReadFile("css/site.css")
+			if err != nil {
+				t.Fatalf("ReadFile() error: %v", err)
+			}
+			if string(data) != "body{}" {
+				t.Errorf("ReadFile() = %q, want %q", data, "body{}")
+			}
+
+			if _, err := archive.ReadFile("../../etc/passwd"); !errors.Is(err, domain.ErrNotFound) {
+				t.Errorf("expected traversal entry to be missing, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenArchive_Limits(t *testing.T) {
+	content := buildZip(t, map[string]string{
+		"a.txt":   "aaaa",
+		"big.txt": string(bytes.Repeat([]byte("x"), 2048)),
+	})
+
+	if _, err := OpenArchive(content, ArchiveConfig{MaxEntries: 1}); err == nil {
+		t.Error("expected error for too many entries")
+	}
+
+	archive, err := OpenArchive(content, ArchiveConfig{MaxEntrySize: 1024})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := archive.ReadFile("big.txt"); !errors.Is(err, domain.ErrFileTooLarge) {
+		t.Errorf("expected file too large error, got %v", err)
+	}
+	if _, err := archive.ReadFile("a.txt"); err != nil {
+		t.Errorf("unexpected error reading small entry: %v", err)
+	}
+}
+
+func TestOpenArchive_NotArchive(t *testing.T) {
+	if _, err := OpenArchive([]byte("just some text"), ArchiveConfig{}); err == nil {
+		t.Error("expected error for non-archive content")
+	}
+}
+
+func TestArchive_SiteConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		wantCSP  string
+		wantNone bool
+		wantErr  bool
+	}{
+		{"valid", `{"index_file":"home.html","csp":"default-src 'none'"}`, "default-src 'none'", false, false},
+		{"absent", "", "", true, false},
+		{"invalid", `{"csp":`, "", true, true},
+		{"too large", `{"csp":"` + strings.Repeat("x", maxSiteConfigSize) + `"}`, "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"index.html": "<h1>hi</h1>"}
+			if tt.config != "" {
+				files[SiteConfigFile] = tt.config
+			}
+			archive, err := OpenArchive(buildZip(t, files), ArchiveConfig{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			site, err := archive.SiteConfig()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SiteConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (site == nil) != tt.wantNone {
+				t.Fatalf("SiteConfig() = %+v, want none: %v", site, tt.wantNone)
+			}
+			if site != nil && site.CSP != tt.wantCSP {
+				t.Errorf("CSP = %q, want %q", site.CSP, tt.wantCSP)
+			}
+		})
+	}
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"zip", buildZip(t, map[string]string{"a": "b"}), ArchiveZip},
+		{"tar", buildTar(t, map[string]string{"a": "b"}), ArchiveTar},
+		{"text", []byte("hello"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectArchiveFormat(tt.content); got != tt.want {
+				t.Errorf("DetectArchiveFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec decompresses a single compression format
+type Codec interface {
+	// Name returns the format name reported in metadata and metrics
+	Name() string
+
+	// Match reports whether content starts with the format's magic bytes.
+	// Formats without a reliable signature always return false and are only
+	// used when selected explicitly by name.
+	Match(content []byte) bool
+
+	// NewReader returns a reader over the decompressed stream. maxSize is the
+	// decompressed size limit, for codecs that can bound their own memory use.
+	NewReader(r io.Reader, maxSize int64) (io.ReadCloser, error)
+}
+
+// Compression format names
+const (
+	FormatGzip    = "gzip"
+	FormatZlib    = "zlib"
+	FormatDeflate = "deflate"
+	FormatBzip2   = "bzip2"
+	FormatZstd    = "zstd"
+	FormatBrotli  = "brotli"
+)
+
+// formatAliases maps alternative format names to their canonical name
+var formatAliases = map[string]string{
+	"gz":     FormatGzip,
+	"x-gzip": FormatGzip,
+	"bz2":    FormatBzip2,
+	"zst":    FormatZstd,
+	"br":     FormatBrotli,
+}
+
+// DefaultCodecs returns the built-in codecs in detection order
+func DefaultCodecs() []Codec {
+	return []Codec{
+		gzipCodec{},
+		zstdCodec{},
+		bzip2Codec{},
+		zlibCodec{},
+		deflateCodec{},
+		brotliCodec{},
+	}
+}
+
+// gzipCodec handles gzip (RFC 1952)
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return FormatGzip }
+
+func (gzipCodec) Match(content []byte) bool {
+	return len(content) >= 2 && content[0] == 0x1F && content[1] == 0x8B
+}
+
+func (gzipCodec) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// zstdCodec handles Zstandard frames (RFC 8878)
+type zstdCodec struct{}
+
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+func (zstdCodec) Name() string { return FormatZstd }
+
+func (zstdCodec) Match(content []byte) bool {
+	return bytes.HasPrefix(content, zstdMagic)
+}
+
+func (zstdCodec) NewReader(r io.Reader, maxSize int64) (io.ReadCloser, error) {
+	opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+	if maxSize > 0 {
+		opts = append(opts, zstd.WithDecoderMaxMemory(uint64(maxSize)))
+	}
+
+	dec, err := zstd.NewReader(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return dec.IOReadCloser(), nil
+}
+
+// bzip2Codec handles bzip2 streams
+type bzip2Codec struct{}
+
+func (bzip2Codec) Name() string { return FormatBzip2 }
+
+func (bzip2Codec) Match(content []byte) bool {
+	// "BZh" followed by the block size digit 1-9
+	return len(content) >= 4 && bytes.HasPrefix(content, []byte("BZh")) &&
+		content[3] >= '1' && content[3] <= '9'
+}
+
+func (bzip2Codec) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) {
+	return io.NopCloser(bzip2.NewReader(r)), nil
+}
+
+// zlibCodec handles zlib-wrapped deflate (RFC 1950)
+type zlibCodec struct{}
+
+func (zlibCodec) Name() string { return FormatZlib }
+
+func (zlibCodec) Match(content []byte) bool {
+	if len(content) < 2 {
+		return false
+	}
+	cmf, flg := content[0], content[1]
+
+	// Deflate method, window <= 32K, no preset dictionary, valid check bits
+	return cmf&0x0F == 8 && cmf>>4 <= 7 && flg&0x20 == 0 &&
+		(uint16(cmf)<<8|uint16(flg))%31 == 0
+}
+
+func (zlibCodec) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+// deflateCodec handles raw deflate (RFC 1951), which has no signature
+type deflateCodec struct{}
+
+func (deflateCodec) Name() string { return FormatDeflate }
+
+func (deflateCodec) Match([]byte) bool { return false }
+
+func (deflateCodec) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+// brotliCodec handles brotli (RFC 7932), which has no signature
+type brotliCodec struct{}
+
+func (brotliCodec) Name() string { return FormatBrotli }
+
+func (brotliCodec) Match([]byte) bool { return false }
+
+func (brotliCodec) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) {
+	return io.NopCloser(brotli.NewReader(r)), nil
+}
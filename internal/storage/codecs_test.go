@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// bzip2Hello is "hello bzip2 world" compressed with bzip2 -9
+var bzip2Hello = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x1f, 0x4e,
+	0x70, 0xba, 0x00, 0x00, 0x03, 0x19, 0x80, 0x40, 0x00, 0x10, 0x00, 0x16,
+	0x64, 0xd0, 0x90, 0x20, 0x00, 0x31, 0x00, 0xd0, 0x01, 0x4c, 0x03, 0x46,
+	0x96, 0xa1, 0x85, 0xd1, 0xdc, 0x8f, 0x13, 0xa0, 0xf0, 0xbb, 0x92, 0x29,
+	0xc2, 0x84, 0x80, 0xfa, 0x73, 0x85, 0xd0,
+}
+
+// compressWith compresses data using the writer returned by newWriter
+func compressWith(t *testing.T, data []byte, newWriter func(io.Writer) io.WriteCloser) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("failed to write compressed data: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close compressor: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gzipWriter(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+func zlibWriter(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+func flateWriter(w io.Writer) io.WriteCloser {
+	fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+	return fw
+}
+func brotliWriter(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }
+func zstdWriter(w io.Writer) io.WriteCloser {
+	zw, _ := zstd.NewWriter(w)
+	return zw
+}
+
+func TestDecompressor_DecompressFormat(t *testing.T) {
+	original := []byte("hello world, this is compressed data! hello world, this is compressed data!")
+
+	tests := []struct {
+		name       string
+		content    []byte
+		format     string
+		wantFormat string
+		want       []byte
+	}{
+		{"gzip detected", compressWith(t, original, gzipWriter), "", FormatGzip, original},
+		{"zstd detected", compressWith(t, original, zstdWriter), "", FormatZstd, original},
+		{"zlib detected", compressWith(t, original, zlibWriter), "", FormatZlib, original},
+		{"bzip2 detected", bzip2Hello, "", FormatBzip2, []byte("hello bzip2 world")},
+		{"raw deflate explicit", compressWith(t, original, flateWriter), "deflate", FormatDeflate, original},
+		{"brotli explicit", compressWith(t, original, brotliWriter), "brotli", FormatBrotli, original},
+		{"brotli alias", compressWith(t, original, brotliWriter), "br", FormatBrotli, original},
+		{"zstd alias", compressWith(t, original, zstdWriter), "ZST", FormatZstd, original},
+		{"uncompressed", original, "", "", original},
+		{"text with zlib header", []byte("x^2 + y^2"), "", "", []byte("x^2 + y^2")},
+	}
+
+	d := NewDecompressor(DecompressorConfig{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, format, err := d.DecompressFormat(tt.content, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			if !bytes.Equal(output, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, output)
+			}
+		})
+	}
+}
+
+func TestDecompressor_DecompressFormat_SizeLimit(t *testing.T) {
+	original := bytes.Repeat([]byte("x"), 10000)
+
+	tests := []struct {
+		name    string
+		content []byte
+		format  string
+	}{
+		{"gzip", compressWith(t, original, gzipWriter), ""},
+		{"zstd", compressWith(t, original, zstdWriter), ""},
+		{"zlib", compressWith(t, original, zlibWriter), ""},
+		{"deflate", compressWith(t, original, flateWriter), "deflate"},
+		{"brotli", compressWith(t, original, brotliWriter), "brotli"},
+	}
+
+	d := NewDecompressor(DecompressorConfig{MaxSize: 1000})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := d.DecompressFormat(tt.content, tt.format)
+			if err == nil {
+				t.Fatal("expected error due to size limit, got nil")
+			}
+
+			if !errors.Is(err, domain.ErrFileTooLarge) {
+				t.Errorf("expected file too large error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecompressor_DecompressFormat_Unsupported(t *testing.T) {
+	d := NewDecompressor(DecompressorConfig{})
+
+	_, _, err := d.DecompressFormat([]byte("data"), "lzma")
+	if err == nil {
+		t.Fatal("expected error for unsupported format, got nil")
+	}
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) || domainErr.Code != "INVALID_INPUT" {
+		t.Errorf("expected invalid input error, got %v", err)
+	}
+}
+
+func TestDecompressor_DecompressFormat_InvalidZlib(t *testing.T) {
+	d := NewDecompressor(DecompressorConfig{})
+	content := []byte("x^2 + y^2")
+
+	// Named explicitly, content that doesn't inflate is an error
+	_, format, err := d.DecompressFormat(content, FormatZlib)
+	if !errors.Is(err, domain.ErrDecompressionFailed) {
+		t.Errorf("expected decompression error, got %v", err)
+	}
+	if format != FormatZlib {
+		t.Errorf("format = %q, want %q", format, FormatZlib)
+	}
+}
+
+func TestDecompressor_Detect(t *testing.T) {
+	d := NewDecompressor(DecompressorConfig{})
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"gzip", []byte{0x1F, 0x8B, 0x08}, FormatGzip},
+		{"zstd", []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00}, FormatZstd},
+		{"bzip2", []byte("BZh91AY&SY"), FormatBzip2},
+		{"zlib default", []byte{0x78, 0x9C}, FormatZlib},
+		{"zlib best", []byte{0x78, 0xDA}, FormatZlib},
+		{"bzip2 bad block size", []byte("BZh0"), ""},
+		{"zlib bad checksum", []byte{0x78, 0x9D}, ""},
+		{"zlib not deflate", []byte{0x77, 0x09}, ""},
+		{"zlib window too large", []byte{0x88, 0x1C}, ""},
+		{"zlib preset dictionary", []byte{0x78, 0x20}, ""},
+		{"plain text", []byte("hello world"), ""},
+		{"empty", []byte{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if codec := d.Detect(tt.content); codec != nil {
+				got = codec.Name()
+			}
+			if got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// upperCodec is a test codec that upper-cases content prefixed with "UP:"
+type upperCodec struct{}
+
+func (upperCodec) Name() string { return "upper" }
+
+func (upperCodec) Match(content []byte) bool { return bytes.HasPrefix(content, []byte("UP:")) }
+
+func (upperCodec) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(bytes.ToUpper(data[3:]))), nil
+}
+
+func TestDecompressor_Register(t *testing.T) {
+	d := NewDecompressor(DecompressorConfig{})
+	d.Register(upperCodec{})
+
+	output, format, err := d.DecompressFormat([]byte("UP:hello"), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if format != "upper" || string(output) != "HELLO" {
+		t.Errorf("got format %q output %q", format, output)
+	}
+
+	if _, ok := d.Lookup("upper"); !ok {
+		t.Error("expected registered codec to be found by name")
+	}
+}
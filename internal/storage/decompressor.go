@@ -2,21 +2,26 @@ package storage
 
 import (
 	"bytes"
-	"compress/gzip"
 	"fmt"
 	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/devdudeio/verus-gateway/internal/domain"
 )
 
-// Decompressor implements file decompression
+// Decompressor implements file decompression using a registry of codecs
 type Decompressor struct {
 	maxSize int64 // Maximum decompressed size to prevent zip bombs
+	codecs  []Codec
+	byName  map[string]Codec
 }
 
 // DecompressorConfig holds configuration for the decompressor
 type DecompressorConfig struct {
-	MaxSize int64 // Maximum decompressed size (default: 100MB)
+	MaxSize int64   // Maximum decompressed size (default: 100MB)
+	Codecs  []Codec // Codecs in detection order (default: DefaultCodecs())
 }
 
 // NewDecompressor creates a new decompressor
@@ -25,44 +30,124 @@ func NewDecompressor(cfg DecompressorConfig) *Decompressor {
 	if cfg.MaxSize == 0 {
 		cfg.MaxSize = 100 * 1024 * 1024 // 100MB default
 	}
+	if cfg.Codecs == nil {
+		cfg.Codecs = DefaultCodecs()
+	}
 
-	return &Decompressor{
+	d := &Decompressor{
 		maxSize: cfg.MaxSize,
+		byName:  make(map[string]Codec),
+	}
+	for _, codec := range cfg.Codecs {
+		d.Register(codec)
+	}
+
+	return d
+}
+
+// WithMaxSize returns a decompressor sharing this one's codecs with a
+// different decompressed size limit
+func (d *Decompressor) WithMaxSize(maxSize int64) *Decompressor {
+	if maxSize <= 0 || maxSize == d.maxSize {
+		return d
+	}
+	clone := *d
+	clone.maxSize = maxSize
+	return &clone
+}
+
+// Register adds a codec to the registry, replacing any codec with the same name
+func (d *Decompressor) Register(codec Codec) {
+	if _, exists := d.byName[codec.Name()]; exists {
+		for i, c := range d.codecs {
+			if c.Name() == codec.Name() {
+				d.codecs[i] = codec
+			}
+		}
+	} else {
+		d.codecs = append(d.codecs, codec)
+	}
+	d.byName[codec.Name()] = codec
+}
+
+// Lookup returns the codec registered under a format name or alias
+func (d *Decompressor) Lookup(format string) (Codec, bool) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if canonical, ok := formatAliases[format]; ok {
+		format = canonical
 	}
+	codec, ok := d.byName[format]
+	return codec, ok
 }
 
-// Decompress attempts to decompress gzipped content
-// Returns the decompressed data, or the original data if not gzipped
+// Detect returns the codec whose magic bytes match content, or nil
+func (d *Decompressor) Detect(content []byte) Codec {
+	for _, codec := range d.codecs {
+		if codec.Match(content) {
+			return codec
+		}
+	}
+	return nil
+}
+
+// Decompress attempts to decompress content in any detected format
+// Returns the decompressed data, or the original data if not compressed
 func (d *Decompressor) Decompress(content []byte) ([]byte, error) {
-	// Check if content is gzipped
-	if !d.isGzipped(content) {
-		return content, nil
+	data, _, err := d.DecompressFormat(content, "")
+	return data, err
+}
+
+// DecompressFormat decompresses content with the named codec, or with the
+// codec detected from magic bytes when format is empty. It returns the name
+// of the codec used, which is empty when content was not compressed.
+func (d *Decompressor) DecompressFormat(content []byte, format string) ([]byte, string, error) {
+	var codec Codec
+	if format != "" {
+		var ok bool
+		if codec, ok = d.Lookup(format); !ok {
+			return nil, "", domain.NewInvalidInputError("compression",
+				fmt.Sprintf("unsupported compression format: %s", format))
+		}
+	} else if codec = d.Detect(content); codec == nil {
+		return content, "", nil
 	}
 
-	// Decompress
-	decompressed, err := d.decompressGzip(content)
+	decompressed, err := d.decompress(codec, content)
 	if err != nil {
-		return nil, domain.NewDecompressionError(fmt.Sprintf("gzip decompression failed: %v", err))
+		if _, ok := err.(*domain.Error); ok {
+			return nil, codec.Name(), err
+		}
+		if format == "" && codec.Name() == FormatZlib {
+			// The two-byte zlib header also occurs in plain content (e.g.
+			// text starting with "x^"), so a detected stream that doesn't
+			// inflate is taken to be uncompressed rather than corrupt
+			return content, "", nil
+		}
+		return nil, codec.Name(), domain.NewDecompressionError(
+			fmt.Sprintf("%s decompression failed: %v", codec.Name(), err),
+		).WithDetail("format", codec.Name())
 	}
 
-	return decompressed, nil
+	return decompressed, codec.Name(), nil
+}
+
+// IsCompressed checks if content is compressed in a detectable format
+func (d *Decompressor) IsCompressed(content []byte) bool {
+	return d.Detect(content) != nil
 }
 
 // isGzipped checks if content is gzip-compressed
 func (d *Decompressor) isGzipped(content []byte) bool {
-	if len(content) < 2 {
-		return false
-	}
-	return content[0] == 0x1F && content[1] == 0x8B
+	return gzipCodec{}.Match(content)
 }
 
-// decompressGzip decompresses gzip data with size limit protection
-func (d *Decompressor) decompressGzip(content []byte) ([]byte, error) {
-	gr, err := gzip.NewReader(bytes.NewReader(content))
+// decompress runs a codec with size limit protection
+func (d *Decompressor) decompress(codec Codec, content []byte) ([]byte, error) {
+	r, err := codec.NewReader(bytes.NewReader(content), d.maxSize)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = gr.Close() }()
+	defer func() { _ = r.Close() }()
 
 	// Pre-allocate buffer (estimate 2x compressed size)
 	var out bytes.Buffer
@@ -75,11 +160,10 @@ func (d *Decompressor) decompressGzip(content []byte) ([]byte, error) {
 	}
 
 	// Copy with size limit
-	if _, err := io.Copy(lim, gr); err != nil && err != io.EOF {
-		if err == errSizeLimitExceeded {
-			return nil, domain.NewDecompressionError(
-				fmt.Sprintf("decompressed size exceeds limit of %d bytes", d.maxSize),
-			)
+	if _, err := io.Copy(lim, r); err != nil && err != io.EOF {
+		if err == errSizeLimitExceeded || err == zstd.ErrDecoderSizeExceeded {
+			return nil, domain.NewFileTooLargeError("decompressed", d.maxSize).
+				WithDetail("format", codec.Name())
 		}
 		return nil, err
 	}
@@ -1,7 +1,6 @@
 package storage
 
 import (
-	"bytes"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -10,11 +9,43 @@ import (
 )
 
 // Detector implements file type detection
-type Detector struct{}
+type Detector struct {
+	signatures []Signature                  // Checked in order; first match wins
+	extensions map[string]string            // File extensions by MIME type
+	extractors map[string]PropertyExtractor // Property extractors by MIME type
+}
 
 // NewDetector creates a new file detector
 func NewDetector() *Detector {
-	return &Detector{}
+	d := &Detector{
+		signatures: defaultSignatures(),
+		extensions: defaultExtensions(),
+		extractors: defaultExtractors(),
+	}
+
+	// The first signature for a MIME type provides its extension
+	for _, sig := range d.signatures {
+		if _, ok := d.extensions[sig.MIME]; !ok && sig.Extension != "" {
+			d.extensions[sig.MIME] = sig.Extension
+		}
+	}
+
+	return d
+}
+
+// RegisterSignature adds a signature that is checked before the built-in
+// ones. Its extension replaces any existing one for the MIME type.
+func (d *Detector) RegisterSignature(sig Signature) error {
+	if err := sig.Validate(); err != nil {
+		return err
+	}
+
+	d.signatures = append([]Signature{sig}, d.signatures...)
+	if sig.Extension != "" {
+		d.extensions[sig.MIME] = sig.Extension
+	}
+
+	return nil
 }
 
 // DetectType detects the file type from content and optional filename
@@ -43,6 +74,9 @@ func (d *Detector) DetectType(content []byte, filename string) (*domain.FileMeta
 	// Detect if compressed
 	metadata.Compressed = d.isGzipCompressed(content)
 
+	// Extract format-specific properties
+	metadata.Properties = d.ExtractProperties(content, metadata.ContentType)
+
 	return metadata, nil
 }
 
@@ -55,12 +89,9 @@ func (d *Detector) DetectMIME(content []byte) string {
 	// Use http.DetectContentType for basic detection
 	mimeType := http.DetectContentType(content)
 
-	// Enhanced detection for specific formats
-	if mimeType == "application/octet-stream" || mimeType == "text/plain; charset=utf-8" {
-		// Check for specific file signatures
-		if detected := d.detectBySignature(content); detected != "" {
-			return detected
-		}
+	// Refine it, or replace a generic result, from the signature table
+	if detected := d.detectBySignature(content, mimeType); detected != "" {
+		return detected
 	}
 
 	return mimeType
@@ -68,96 +99,26 @@ func (d *Detector) DetectMIME(content []byte) string {
 
 // DetectExtension detects file extension from content
 func (d *Detector) DetectExtension(content []byte) string {
-	mime := d.DetectMIME(content)
-
-	// Map common MIME types to extensions
-	extMap := map[string]string{
-		"image/jpeg":               "jpg",
-		"image/png":                "png",
-		"image/gif":                "gif",
-		"image/webp":               "webp",
-		"image/svg+xml":            "svg",
-		"image/bmp":                "bmp",
-		"video/mp4":                "mp4",
-		"video/webm":               "webm",
-		"video/mpeg":               "mpeg",
-		"audio/mpeg":               "mp3",
-		"audio/ogg":                "ogg",
-		"audio/wav":                "wav",
-		"application/pdf":          "pdf",
-		"application/zip":          "zip",
-		"application/x-gzip":       "gz",
-		"application/json":         "json",
-		"application/xml":          "xml",
-		"text/html":                "html",
-		"text/css":                 "css",
-		"text/javascript":          "js",
-		"text/plain":               "txt",
-		"application/octet-stream": "bin",
-	}
-
-	if ext, ok := extMap[mime]; ok {
+	if ext, ok := d.extensions[d.DetectMIME(content)]; ok {
 		return ext
 	}
 
 	return "bin"
 }
 
-// detectBySignature detects file type by magic bytes
-func (d *Detector) detectBySignature(content []byte) string {
-	if len(content) < 16 {
-		return ""
-	}
+// detectBySignature matches content against the signature table. Signatures
+// apply when generic detection found nothing specific, or when they refine
+// the generic result.
+func (d *Detector) detectBySignature(content []byte, generic string) string {
+	isGeneric := generic == "application/octet-stream" || generic == "text/plain; charset=utf-8"
 
-	// Check for common file signatures
-	signatures := []struct {
-		magic []byte
-		mime  string
-	}{
-		// Images
-		{[]byte{0xFF, 0xD8, 0xFF}, "image/jpeg"},
-		{[]byte{0x89, 0x50, 0x4E, 0x47}, "image/png"},
-		{[]byte("GIF87a"), "image/gif"},
-		{[]byte("GIF89a"), "image/gif"},
-		{[]byte("RIFF"), "image/webp"}, // Needs more specific check
-		{[]byte{0x42, 0x4D}, "image/bmp"},
-
-		// Videos
-		{[]byte{0x00, 0x00, 0x00, 0x18, 0x66, 0x74, 0x79, 0x70}, "video/mp4"},
-		{[]byte{0x1A, 0x45, 0xDF, 0xA3}, "video/webm"},
-
-		// Audio
-		{[]byte("ID3"), "audio/mpeg"},
-		{[]byte{0xFF, 0xFB}, "audio/mpeg"},
-		{[]byte("OggS"), "audio/ogg"},
-		{[]byte("RIFF"), "audio/wav"}, // Needs more specific check
-
-		// Documents
-		{[]byte("%PDF"), "application/pdf"},
-		{[]byte("PK\x03\x04"), "application/zip"},
-
-		// Archives
-		{[]byte{0x1F, 0x8B}, "application/x-gzip"},
-		{[]byte("Rar!"), "application/x-rar-compressed"},
-		{[]byte("7z\xBC\xAF\x27\x1C"), "application/x-7z-compressed"},
-	}
-
-	for _, sig := range signatures {
-		if bytes.HasPrefix(content, sig.magic) {
-			// Special handling for RIFF files (WAV, WEBP, AVI)
-			if bytes.HasPrefix(content, []byte("RIFF")) && len(content) > 12 {
-				if bytes.Contains(content[8:12], []byte("WAVE")) {
-					return "audio/wav"
-				}
-				if bytes.Contains(content[8:12], []byte("WEBP")) {
-					return "image/webp"
-				}
-				if bytes.Contains(content[8:12], []byte("AVI ")) {
-					return "video/x-msvideo"
-				}
-			}
-
-			return sig.mime
+	for i := range d.signatures {
+		sig := &d.signatures[i]
+		if !isGeneric && (sig.Parent == "" || sig.Parent != generic) {
+			continue
+		}
+		if sig.Matches(content) {
+			return sig.MIME
 		}
 	}
 
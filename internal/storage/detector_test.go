@@ -212,6 +212,11 @@ func TestDetectMIME_Documents(t *testing.T) {
 			content:  []byte("PK\x03\x04\x14\x00\x00\x00"),
 			expected: "application/zip",
 		},
+		{
+			name:     "tar archive",
+			content:  append(make([]byte, 257), []byte("ustar\x0000")...),
+			expected: "application/x-tar",
+		},
 	}
 
 	for _, tt := range tests {
@@ -463,7 +468,7 @@ func TestDetectBySignature_WEBP(t *testing.T) {
 	// WEBP file signature
 	content := []byte("RIFF\x00\x00\x00\x00WEBP\x00\x00\x00\x00")
 
-	mime := detector.detectBySignature(content)
+	mime := detector.detectBySignature(content, "application/octet-stream")
 	if mime != "image/webp" {
 		t.Errorf("detectBySignature(WEBP) = %q, want %q", mime, "image/webp")
 	}
@@ -474,7 +479,7 @@ func TestDetectBySignature_ShortContent(t *testing.T) {
 	// Content too short for signature detection
 	content := []byte{0xFF, 0xD8}
 
-	mime := detector.detectBySignature(content)
+	mime := detector.detectBySignature(content, "application/octet-stream")
 	if mime != "" {
 		t.Errorf("detectBySignature(short) = %q, want empty string", mime)
 	}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoding
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// ImageTransformer resizes and re-encodes JPEG, PNG and GIF images
+type ImageTransformer struct {
+	maxInputPixels int64 // Largest input image (width x height) that will be decoded
+}
+
+// ImageTransformerConfig holds configuration for the image transformer
+type ImageTransformerConfig struct {
+	MaxInputPixels int64 // Maximum input pixels (default: 40 megapixels)
+}
+
+// NewImageTransformer creates a new image transformer
+func NewImageTransformer(cfg ImageTransformerConfig) *ImageTransformer {
+	// Set defaults
+	if cfg.MaxInputPixels == 0 {
+		cfg.MaxInputPixels = 40_000_000 // 40MP default
+	}
+
+	return &ImageTransformer{
+		maxInputPixels: cfg.MaxInputPixels,
+	}
+}
+
+// IsTransformableImage reports whether a MIME type, as reported by Detector,
+// can be transformed
+func IsTransformableImage(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// WithMaxInputPixels returns a transformer with a different input limit
+func (t *ImageTransformer) WithMaxInputPixels(maxPixels int64) *ImageTransformer {
+	if maxPixels <= 0 || maxPixels == t.maxInputPixels {
+		return t
+	}
+	return &ImageTransformer{maxInputPixels: maxPixels}
+}
+
+// Transform resizes an image and encodes it in the requested format. It
+// returns the encoded image and its MIME type. The image header is checked
+// against the pixel limit before the image is decoded.
+func (t *ImageTransformer) Transform(content []byte, tr *domain.ImageTransform) ([]byte, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", domain.NewUnsupportedFormatError(fmt.Sprintf("cannot decode image: %v", err))
+	}
+
+	pixels := int64(cfg.Width) * int64(cfg.Height)
+	if pixels > t.maxInputPixels {
+		return nil, "", domain.NewFileTooLargeError("image_pixels", t.maxInputPixels).
+			WithDetail("width", cfg.Width).
+			WithDetail("height", cfg.Height)
+	}
+
+	// GIF decoding yields the first frame only
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", domain.NewUnsupportedFormatError(fmt.Sprintf("cannot decode image: %v", err))
+	}
+
+	dst := resize(src, tr)
+
+	outFormat := tr.Format
+	if outFormat == "" {
+		outFormat = domain.ImageFormatPNG
+		if format == "jpeg" {
+			outFormat = domain.ImageFormatJPEG
+		}
+	}
+
+	var out bytes.Buffer
+	switch outFormat {
+	case domain.ImageFormatJPEG:
+		quality := tr.Quality
+		if quality == 0 {
+			quality = domain.DefaultJPEGQuality
+		}
+		err = jpeg.Encode(&out, dst, &jpeg.Options{Quality: quality})
+	default:
+		err = png.Encode(&out, dst)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode %s: %w", outFormat, err)
+	}
+
+	return out.Bytes(), "image/" + outFormat, nil
+}
+
+// resize scales src according to the transform
+func resize(src image.Image, tr *domain.ImageTransform) image.Image {
+	b := src.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if srcW == 0 || srcH == 0 {
+		return src
+	}
+
+	switch tr.Fit {
+	case domain.FitFill:
+		return scale(src, b, tr.Width, tr.Height)
+
+	case domain.FitCover:
+		// Crop the source to the target aspect ratio, centered
+		crop := b
+		if srcW*tr.Height > srcH*tr.Width {
+			w := srcH * tr.Width / tr.Height
+			crop.Min.X += (srcW - w) / 2
+			crop.Max.X = crop.Min.X + w
+		} else {
+			h := srcW * tr.Height / tr.Width
+			crop.Min.Y += (srcH - h) / 2
+			crop.Max.Y = crop.Min.Y + h
+		}
+		return scale(src, crop, tr.Width, tr.Height)
+
+	default:
+		// Contain: fit inside the box, never upscaling
+		w, h := srcW, srcH
+		if tr.Width > 0 && w > tr.Width {
+			h = h * tr.Width / w
+			w = tr.Width
+		}
+		if tr.Height > 0 && h > tr.Height {
+			w = w * tr.Height / h
+			h = tr.Height
+		}
+		if w == srcW && h == srcH {
+			return src
+		}
+		return scale(src, b, max(w, 1), max(h, 1))
+	}
+}
+
+// scale draws the part of src inside rect into a new w x h image
+func scale(src image.Image, rect image.Rectangle, w, h int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, rect, draw.Over, nil)
+	return dst
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// encodeTestImage encodes a w x h image in the given format
+func encodeTestImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatalf("failed to encode %s: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageTransformer_Transform(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		transform       domain.ImageTransform
+		wantW, wantH    int
+		wantContentType string
+	}{
+		{"contain width", "png", domain.ImageTransform{Width: 50, Fit: domain.FitContain}, 50, 25, "image/png"},
+		{"contain box", "png", domain.ImageTransform{Width: 50, Height: 10, Fit: domain.FitContain}, 20, 10, "image/png"},
+		{"contain no upscale", "png", domain.ImageTransform{Width: 500, Fit: domain.FitContain}, 100, 50, "image/png"},
+		{"cover", "jpeg", domain.ImageTransform{Width: 40, Height: 40, Fit: domain.FitCover}, 40, 40, "image/jpeg"},
+		{"fill", "png", domain.ImageTransform{Width: 30, Height: 70, Fit: domain.FitFill}, 30, 70, "image/png"},
+		{"gif to png", "gif", domain.ImageTransform{Width: 10, Fit: domain.FitContain}, 10, 5, "image/png"},
+		{"png to jpeg", "png", domain.ImageTransform{Height: 20, Fit: domain.FitContain, Format: domain.ImageFormatJPEG, Quality: 60}, 40, 20, "image/jpeg"},
+	}
+
+	transformer := NewImageTransformer(ImageTransformerConfig{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, contentType, err := transformer.Transform(encodeTestImage(t, tt.input, 100, 50), &tt.transform)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("content type = %q, want %q", contentType, tt.wantContentType)
+			}
+
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("output is not a valid image: %v", err)
+			}
+			if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Errorf("size = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestImageTransformer_PixelLimit(t *testing.T) {
+	transformer := NewImageTransformer(ImageTransformerConfig{MaxInputPixels: 1000})
+
+	_, _, err := transformer.Transform(encodeTestImage(t, "png", 100, 50), &domain.ImageTransform{Width: 10})
+	if !errors.Is(err, domain.ErrFileTooLarge) {
+		t.Errorf("expected file too large error, got %v", err)
+	}
+}
+
+func TestImageTransformer_NotAnImage(t *testing.T) {
+	transformer := NewImageTransformer(ImageTransformerConfig{})
+
+	_, _, err := transformer.Transform([]byte("not an image"), &domain.ImageTransform{Width: 10})
+	if !errors.Is(err, domain.ErrUnsupportedFormat) {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
@@ -0,0 +1,601 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	_ "golang.org/x/image/bmp"  // register BMP decoding
+	_ "golang.org/x/image/webp" // register WebP decoding
+)
+
+// PropertyExtractor extracts format-specific properties from file content.
+// Extractors only parse headers and other bounded regions of the content.
+// They return nil if nothing could be extracted.
+type PropertyExtractor func(content []byte) map[string]interface{}
+
+const (
+	// propertyScanHead is how much of the start of a file extractors scan
+	propertyScanHead = 1024 * 1024
+
+	// propertyScanTail is how much of the end of a file extractors scan
+	propertyScanTail = 64 * 1024
+
+	// maxContainerElements bounds how many boxes/elements are walked
+	maxContainerElements = 1000
+)
+
+// defaultExtractors returns the built-in property extractors by MIME type.
+// A "type/*" key matches any subtype.
+func defaultExtractors() map[string]PropertyExtractor {
+	return map[string]PropertyExtractor{
+		"image/jpeg":             extractJPEGProperties,
+		"image/png":              extractImageProperties,
+		"image/gif":              extractImageProperties,
+		"image/webp":             extractImageProperties,
+		"image/bmp":              extractImageProperties,
+		"application/pdf":        extractPDFProperties,
+		"video/mp4":              extractMP4Properties,
+		"audio/mp4":              extractMP4Properties,
+		"video/webm":             extractWebMProperties,
+		"audio/webm":             extractWebMProperties,
+		"audio/ogg":              extractOggProperties,
+		"application/ogg":        extractOggProperties,
+		"text/*":                 extractTextProperties,
+		"application/json":       extractTextProperties,
+		"application/xml":        extractTextProperties,
+		"application/javascript": extractTextProperties,
+	}
+}
+
+// RegisterExtractor adds or replaces the property extractor for a MIME type
+// ("type/subtype" or "type/*")
+func (d *Detector) RegisterExtractor(contentType string, extractor PropertyExtractor) {
+	d.extractors[contentType] = extractor
+}
+
+// ExtractProperties returns format-specific properties for content of the
+// given MIME type, or nil if there are none
+func (d *Detector) ExtractProperties(content []byte, contentType string) map[string]interface{} {
+	if len(content) == 0 {
+		return nil
+	}
+
+	mimeType, _, _ := strings.Cut(contentType, ";")
+	mimeType = strings.TrimSpace(strings.ToLower(mimeType))
+
+	extractor, ok := d.extractors[mimeType]
+	if !ok {
+		major, _, _ := strings.Cut(mimeType, "/")
+		if extractor, ok = d.extractors[major+"/*"]; !ok {
+			return nil
+		}
+	}
+
+	props := extractor(content)
+	if len(props) == 0 {
+		return nil
+	}
+	return props
+}
+
+// extractImageProperties reads image dimensions from the image header
+func extractImageProperties(content []byte) map[string]interface{} {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"width":  cfg.Width,
+		"height": cfg.Height,
+	}
+}
+
+// extractJPEGProperties reads JPEG dimensions and EXIF orientation
+func extractJPEGProperties(content []byte) map[string]interface{} {
+	props := extractImageProperties(content)
+	if props == nil {
+		return nil
+	}
+	if orientation := jpegOrientation(content); orientation > 0 {
+		props["orientation"] = orientation
+	}
+	return props
+}
+
+// jpegOrientation returns the EXIF orientation (1-8) of a JPEG, or 0
+func jpegOrientation(content []byte) int {
+	data := content[:min(len(content), propertyScanHead)]
+
+	// Walk the marker segments up to the start of scan
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return 0
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return 0
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 0
+		}
+
+		segment := data[pos+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		pos = end
+	}
+
+	return 0
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of a TIFF
+// structure (as embedded in EXIF)
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 0
+		}
+	}
+
+	return 0
+}
+
+var (
+	pdfVersionPattern    = regexp.MustCompile(`^%PDF-(\d+\.\d+)`)
+	pdfLinearizedPattern = regexp.MustCompile(`/Linearized\b[^>]*?/N\s+(\d+)`)
+	pdfCountPattern      = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfPagesPattern      = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfTitlePattern      = regexp.MustCompile(`/Title\s*([(<])`)
+)
+
+// extractPDFProperties reads the PDF version, page count and title from the
+// start and end of the file, where the page tree root, linearization
+// dictionary and document info usually live. Objects inside compressed
+// object streams are not visible to this scan.
+func extractPDFProperties(content []byte) map[string]interface{} {
+	props := make(map[string]interface{})
+
+	head := content[:min(len(content), propertyScanHead)]
+	if m := pdfVersionPattern.FindSubmatch(head); m != nil {
+		props["pdf_version"] = string(m[1])
+	}
+
+	regions := [][]byte{head}
+	if len(content) > propertyScanHead {
+		regions = append(regions, content[max(len(content)-propertyScanTail, propertyScanHead):])
+	}
+
+	pages := 0
+	for _, region := range regions {
+		if m := pdfLinearizedPattern.FindSubmatch(region); m != nil {
+			if n, err := strconv.Atoi(string(m[1])); err == nil {
+				pages = n
+				break
+			}
+		}
+
+		// The page tree root holds the total count; intermediate nodes and
+		// outlines hold smaller ones
+		for _, loc := range pdfCountPattern.FindAllSubmatchIndex(region, -1) {
+			window := region[max(loc[0]-256, 0):min(loc[1]+256, len(region))]
+			if !pdfPagesPattern.Match(window) {
+				continue
+			}
+			if n, err := strconv.Atoi(string(region[loc[2]:loc[3]])); err == nil && n > pages {
+				pages = n
+			}
+		}
+	}
+	if pages > 0 {
+		props["pages"] = pages
+	}
+
+	for i := len(regions) - 1; i >= 0; i-- {
+		if title := pdfTitle(regions[i]); title != "" {
+			props["title"] = title
+			break
+		}
+	}
+
+	return props
+}
+
+// pdfTitle returns the first /Title string in data
+func pdfTitle(data []byte) string {
+	loc := pdfTitlePattern.FindSubmatchIndex(data)
+	if loc == nil {
+		return ""
+	}
+
+	var raw []byte
+	if data[loc[2]] == '(' {
+		raw = pdfLiteralString(data[loc[3]:])
+	} else {
+		end := bytes.IndexByte(data[loc[3]:], '>')
+		if end < 0 {
+			return ""
+		}
+		hex := bytes.Map(func(r rune) rune {
+			if strings.ContainsRune(" \t\r\n", r) {
+				return -1
+			}
+			return r
+		}, data[loc[3]:loc[3]+end])
+		if len(hex)%2 == 1 {
+			hex = append(hex, '0')
+		}
+		for i := 0; i+1 < len(hex); i += 2 {
+			b, err := strconv.ParseUint(string(hex[i:i+2]), 16, 8)
+			if err != nil {
+				return ""
+			}
+			raw = append(raw, byte(b))
+		}
+	}
+
+	return strings.TrimSpace(decodePDFText(raw))
+}
+
+// pdfLiteralString decodes a PDF literal string body (after the opening
+// parenthesis), handling nesting and common escapes
+func pdfLiteralString(data []byte) []byte {
+	var out []byte
+	depth := 0
+
+	for i := 0; i < len(data) && len(out) < 1024; i++ {
+		c := data[i]
+		switch {
+		case c == '\\' && i+1 < len(data):
+			i++
+			switch e := data[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case '\r', '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					// Octal escape of up to three digits
+					v := int(e - '0')
+					for j := 0; j < 2 && i+1 < len(data) && data[i+1] >= '0' && data[i+1] <= '7'; j++ {
+						i++
+						v = v*8 + int(data[i]-'0')
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		case c == '(':
+			depth++
+			out = append(out, c)
+		case c == ')':
+			if depth == 0 {
+				return out
+			}
+			depth--
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// decodePDFText decodes a PDF text string (UTF-16BE with BOM, or
+// PDFDocEncoding approximated as Latin-1)
+func decodePDFText(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, (len(raw)-2)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, binary.BigEndian.Uint16(raw[i:]))
+		}
+		return string(utf16.Decode(units))
+	}
+	if utf8.Valid(raw) {
+		return string(raw)
+	}
+
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// extractMP4Properties reads the duration from the movie header box. Only
+// box headers are read while locating it.
+func extractMP4Properties(content []byte) map[string]interface{} {
+	moov := findMP4Box(content, "moov")
+	if moov == nil {
+		return nil
+	}
+	mvhd := findMP4Box(moov, "mvhd")
+	if len(mvhd) < 20 {
+		return nil
+	}
+
+	var timescale uint32
+	var duration uint64
+	if mvhd[0] == 1 {
+		if len(mvhd) < 32 {
+			return nil
+		}
+		timescale = binary.BigEndian.Uint32(mvhd[20:])
+		duration = binary.BigEndian.Uint64(mvhd[24:])
+	} else {
+		timescale = binary.BigEndian.Uint32(mvhd[12:])
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:]))
+	}
+	if timescale == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"duration_seconds": roundSeconds(float64(duration) / float64(timescale)),
+	}
+}
+
+// findMP4Box returns the payload of the first box of the given type directly
+// inside data
+func findMP4Box(data []byte, boxType string) []byte {
+	for pos, n := 0, 0; pos+8 <= len(data) && n < maxContainerElements; n++ {
+		size := uint64(binary.BigEndian.Uint32(data[pos:]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data) - pos)
+		case 1:
+			if pos+16 > len(data) {
+				return nil
+			}
+			size = binary.BigEndian.Uint64(data[pos+8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)-pos) {
+			return nil
+		}
+
+		if string(data[pos+4:pos+8]) == boxType {
+			return data[pos+int(header) : pos+int(size)]
+		}
+		pos += int(size)
+	}
+	return nil
+}
+
+// Matroska/WebM element IDs
+const (
+	ebmlIDHeader        = 0x1A45DFA3
+	ebmlIDDocType       = 0x4282
+	ebmlIDSegment       = 0x18538067
+	ebmlIDInfo          = 0x1549A966
+	ebmlIDTimecodeScale = 0x2AD7B1
+	ebmlIDDuration      = 0x4489
+)
+
+// extractWebMProperties reads the duration from the segment info element
+func extractWebMProperties(content []byte) map[string]interface{} {
+	segment := findEBMLElement(content, ebmlIDSegment)
+	if segment == nil {
+		return nil
+	}
+	info := findEBMLElement(segment, ebmlIDInfo)
+	if info == nil {
+		return nil
+	}
+
+	durationData := findEBMLElement(info, ebmlIDDuration)
+	var duration float64
+	switch len(durationData) {
+	case 4:
+		duration = float64(math.Float32frombits(binary.BigEndian.Uint32(durationData)))
+	case 8:
+		duration = math.Float64frombits(binary.BigEndian.Uint64(durationData))
+	default:
+		return nil
+	}
+
+	scale := uint64(1000000) // Default: milliseconds
+	if data := findEBMLElement(info, ebmlIDTimecodeScale); len(data) > 0 && len(data) <= 8 {
+		scale = 0
+		for _, b := range data {
+			scale = scale<<8 | uint64(b)
+		}
+	}
+
+	return map[string]interface{}{
+		"duration_seconds": roundSeconds(duration * float64(scale) / 1e9),
+	}
+}
+
+// findEBMLElement returns the payload of the first element with the given ID
+// directly inside data. Elements of unknown size extend to the end of data.
+func findEBMLElement(data []byte, id uint64) []byte {
+	for pos, n := 0, 0; pos < len(data) && n < maxContainerElements; n++ {
+		elemID, idLen := readVint(data[pos:], true)
+		if idLen == 0 {
+			return nil
+		}
+		size, sizeLen := readVint(data[pos+idLen:], false)
+		if sizeLen == 0 {
+			return nil
+		}
+
+		start := pos + idLen + sizeLen
+		end := len(data)
+		if size != unknownEBMLSize(sizeLen) && size <= uint64(len(data)-start) {
+			end = start + int(size)
+		}
+
+		if elemID == id {
+			return data[start:end]
+		}
+		pos = end
+	}
+	return nil
+}
+
+// readVint reads an EBML variable-length integer, keeping the length marker
+// for element IDs. It returns the value and its length in bytes (0 if invalid).
+func readVint(data []byte, keepMarker bool) (uint64, int) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0
+	}
+
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || length > len(data) {
+		return 0, 0
+	}
+
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	for i := 1; i < length; i++ {
+		value = value<<8 | uint64(data[i])
+	}
+	return value, length
+}
+
+// unknownEBMLSize is the reserved "unknown size" value for a size of n bytes
+func unknownEBMLSize(n int) uint64 {
+	return 1<<(7*uint(n)) - 1
+}
+
+// extractOggProperties reads the codec from the first page and the duration
+// from the granule position of the last page
+func extractOggProperties(content []byte) map[string]interface{} {
+	if len(content) < 28 || !bytes.HasPrefix(content, []byte("OggS")) {
+		return nil
+	}
+
+	segments := int(content[26])
+	packetStart := 27 + segments
+	if packetStart > len(content) {
+		return nil
+	}
+	packet := content[packetStart:]
+
+	var codec string
+	var rate uint32
+	var preSkip uint64
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")) && len(packet) >= 16:
+		codec = "vorbis"
+		rate = binary.LittleEndian.Uint32(packet[12:])
+	case bytes.HasPrefix(packet, []byte("OpusHead")) && len(packet) >= 12:
+		codec = "opus"
+		rate = 48000 // Opus granule positions are always at 48kHz
+		preSkip = uint64(binary.LittleEndian.Uint16(packet[10:]))
+	default:
+		return nil
+	}
+
+	props := map[string]interface{}{"codec": codec}
+
+	// Find the last page header in the tail of the stream
+	tail := content[max(len(content)-propertyScanTail, 0):]
+	if idx := bytes.LastIndex(tail, []byte("OggS")); idx >= 0 && idx+14 <= len(tail) && rate > 0 {
+		granule := binary.LittleEndian.Uint64(tail[idx+6:])
+		if granule != math.MaxUint64 && granule > preSkip {
+			props["duration_seconds"] = roundSeconds(float64(granule-preSkip) / float64(rate))
+		}
+	}
+
+	return props
+}
+
+// extractTextProperties reports the line count and character set of text
+func extractTextProperties(content []byte) map[string]interface{} {
+	charset := textCharset(content)
+	if charset == "" {
+		return nil
+	}
+
+	lines := bytes.Count(content, []byte("\n"))
+	if content[len(content)-1] != '\n' {
+		lines++
+	}
+
+	return map[string]interface{}{
+		"lines":   lines,
+		"charset": charset,
+	}
+}
+
+// textCharset guesses the character set of text from its byte order mark
+// and encoding validity. It returns an empty string for binary content.
+func textCharset(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	}
+
+	if bytes.IndexByte(content, 0) >= 0 {
+		return ""
+	}
+	if !utf8.Valid(content) {
+		return "iso-8859-1"
+	}
+	for _, b := range content {
+		if b >= 0x80 {
+			return "utf-8"
+		}
+	}
+	return "us-ascii"
+}
+
+// roundSeconds rounds a duration to milliseconds
+func roundSeconds(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
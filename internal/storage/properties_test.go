@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func encodeTestPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// encodeTestJPEG encodes a JPEG with an EXIF APP1 segment carrying the
+// given orientation (big-endian TIFF)
+func encodeTestJPEG(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)      // One IFD entry
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112) // Orientation
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)      // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // Value padding, next IFD
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(payload)+2))
+	app1 = append(app1, payload...)
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func mp4Box(boxType string, payload []byte) []byte {
+	box := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+8))
+	box = append(box, boxType...)
+	return append(box, payload...)
+}
+
+func buildTestMP4(timescale, duration uint32) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], timescale)
+	binary.BigEndian.PutUint32(mvhd[16:], duration)
+
+	var out []byte
+	out = append(out, mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2"))...)
+	out = append(out, mp4Box("mdat", make([]byte, 64))...)
+	out = append(out, mp4Box("moov", mp4Box("mvhd", mvhd))...)
+	return out
+}
+
+func ebmlElement(id []byte, payload []byte) []byte {
+	// 8-byte size vint: marker byte followed by a 7-byte length
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(payload)))
+	size[0] = 0x01
+
+	out := append([]byte{}, id...)
+	out = append(out, size...)
+	return append(out, payload...)
+}
+
+func buildTestWebM(durationMS float64) []byte {
+	duration := binary.BigEndian.AppendUint64(nil, math.Float64bits(durationMS))
+	info := ebmlElement([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40}) // 1ms
+	info = append(info, ebmlElement([]byte{0x44, 0x89}, duration)...)
+
+	var out []byte
+	out = append(out, ebmlElement([]byte{0x1A, 0x45, 0xDF, 0xA3}, []byte{0x42, 0x82, 0x84, 'w', 'e', 'b', 'm'})...)
+	// Segment with unknown size, as written by live encoders
+	out = append(out, 0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	out = append(out, ebmlElement([]byte{0x15, 0x49, 0xA9, 0x66}, info)...)
+	return out
+}
+
+func oggPage(granule uint64, packet []byte) []byte {
+	page := []byte("OggS\x00\x00")
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = append(page, make([]byte, 12)...) // Serial, sequence, checksum
+	page = append(page, 1, byte(len(packet)))
+	return append(page, packet...)
+}
+
+func buildTestOgg(granule uint64) []byte {
+	ident := []byte("\x01vorbis\x00\x00\x00\x00\x01")
+	ident = binary.LittleEndian.AppendUint32(ident, 44100)
+	ident = append(ident, make([]byte, 14)...)
+
+	out := oggPage(0, ident)
+	out = append(out, oggPage(1000, make([]byte, 50))...)
+	return append(out, oggPage(granule, make([]byte, 50))...)
+}
+
+func TestExtractProperties(t *testing.T) {
+	detector := NewDetector()
+
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 3 >>\nendobj\n" +
+		"5 0 obj\n<< /Title (Annual \\(draft\\) Report) /Producer (test) >>\nendobj\n%%EOF\n")
+
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+		want        map[string]interface{}
+	}{
+		{
+			name:        "png dimensions",
+			content:     encodeTestPNG(t, 30, 20),
+			contentType: "image/png",
+			want:        map[string]interface{}{"width": 30, "height": 20},
+		},
+		{
+			name:        "jpeg with exif orientation",
+			content:     encodeTestJPEG(t, 16, 8, 6),
+			contentType: "image/jpeg",
+			want:        map[string]interface{}{"width": 16, "height": 8, "orientation": 6},
+		},
+		{
+			name:        "pdf pages and title",
+			content:     pdf,
+			contentType: "application/pdf",
+			want:        map[string]interface{}{"pdf_version": "1.7", "pages": 3, "title": "Annual (draft) Report"},
+		},
+		{
+			name:        "mp4 duration",
+			content:     buildTestMP4(1000, 12345),
+			contentType: "video/mp4",
+			want:        map[string]interface{}{"duration_seconds": 12.345},
+		},
+		{
+			name:        "webm duration",
+			content:     buildTestWebM(2500),
+			contentType: "video/webm",
+			want:        map[string]interface{}{"duration_seconds": 2.5},
+		},
+		{
+			name:        "ogg vorbis duration",
+			content:     buildTestOgg(441000),
+			contentType: "audio/ogg",
+			want:        map[string]interface{}{"codec": "vorbis", "duration_seconds": 10.0},
+		},
+		{
+			name:        "text lines and charset",
+			content:     []byte("one\ntwo\nthree"),
+			contentType: "text/plain; charset=utf-8",
+			want:        map[string]interface{}{"lines": 3, "charset": "us-ascii"},
+		},
+		{
+			name:        "utf-8 text",
+			content:     []byte("héllo\n"),
+			contentType: "text/plain",
+			want:        map[string]interface{}{"lines": 1, "charset": "utf-8"},
+		},
+		{
+			name:        "unknown type",
+			content:     []byte{0x00, 0x01, 0x02},
+			contentType: "application/octet-stream",
+			want:        nil,
+		},
+		{
+			name:        "truncated mp4",
+			content:     buildTestMP4(1000, 1)[:40],
+			contentType: "video/mp4",
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detector.ExtractProperties(tt.content, tt.contentType)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractProperties() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectType_Properties(t *testing.T) {
+	detector := NewDetector()
+	metadata, err := detector.DetectType(encodeTestPNG(t, 4, 2), "pixel.png")
+	if err != nil {
+		t.Fatalf("DetectType() error = %v", err)
+	}
+	if metadata.Properties["width"] != 4 || metadata.Properties["height"] != 2 {
+		t.Errorf("Properties = %v, want width 4, height 2", metadata.Properties)
+	}
+}
+
+func TestRegisterExtractor(t *testing.T) {
+	detector := NewDetector()
+	detector.RegisterExtractor("application/x-custom", func(content []byte) map[string]interface{} {
+		return map[string]interface{}{"size": len(content)}
+	})
+
+	got := detector.ExtractProperties([]byte("abc"), "application/x-custom")
+	if got["size"] != 3 {
+		t.Errorf("ExtractProperties() = %v, want size 3", got)
+	}
+}
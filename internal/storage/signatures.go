@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// SignaturePattern is a byte pattern expected at a fixed offset
+type SignaturePattern struct {
+	Offset int    // Byte offset from the start of the content
+	Magic  []byte // Expected bytes
+	Mask   []byte // Optional; ANDed with the content before comparing
+}
+
+// Signature identifies a file format by its magic bytes
+type Signature struct {
+	// MIME is the content type reported on a match
+	MIME string
+
+	// Extension is the file extension (without dot) for MIME
+	Extension string
+
+	// Patterns must all match
+	Patterns []SignaturePattern
+
+	// Parent restricts the signature to refining content that generic
+	// detection already identified as this type (e.g. an Office document
+	// inside "application/zip"). Signatures without a parent only apply
+	// when generic detection found nothing specific.
+	Parent string
+
+	// Match is an optional extra check, e.g. for container contents
+	Match func(content []byte) bool
+}
+
+// Validate validates a signature
+func (s *Signature) Validate() error {
+	if s.MIME == "" {
+		return fmt.Errorf("signature mime is required")
+	}
+	if len(s.Patterns) == 0 && s.Match == nil {
+		return fmt.Errorf("signature for %s has no patterns", s.MIME)
+	}
+	for _, p := range s.Patterns {
+		if p.Offset < 0 {
+			return fmt.Errorf("signature for %s has a negative offset", s.MIME)
+		}
+		if len(p.Magic) == 0 {
+			return fmt.Errorf("signature for %s has an empty pattern", s.MIME)
+		}
+		if p.Mask != nil && len(p.Mask) != len(p.Magic) {
+			return fmt.Errorf("signature for %s has a mask of a different length than its magic", s.MIME)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether content matches the signature
+func (s *Signature) Matches(content []byte) bool {
+	for _, p := range s.Patterns {
+		end := p.Offset + len(p.Magic)
+		if end > len(content) {
+			return false
+		}
+		window := content[p.Offset:end]
+		if p.Mask == nil {
+			if !bytes.Equal(window, p.Magic) {
+				return false
+			}
+			continue
+		}
+		for i, b := range window {
+			if b&p.Mask[i] != p.Magic[i]&p.Mask[i] {
+				return false
+			}
+		}
+	}
+	return s.Match == nil || s.Match(content)
+}
+
+// at builds a pattern without a mask
+func at(offset int, magic string) SignaturePattern {
+	return SignaturePattern{Offset: offset, Magic: []byte(magic)}
+}
+
+// ftypBrand matches an ISO base media file with the given major brand
+func ftypBrand(brand string) []SignaturePattern {
+	return []SignaturePattern{at(4, "ftyp"), at(8, brand)}
+}
+
+// defaultSignatures returns the built-in signatures. More specific
+// signatures come before the generic ones they refine.
+func defaultSignatures() []Signature {
+	const (
+		docx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		xlsx = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		pptx = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	)
+
+	return []Signature{
+		// Images
+		{MIME: "image/jpeg", Extension: "jpg", Patterns: []SignaturePattern{at(0, "\xFF\xD8\xFF")}},
+		{MIME: "image/png", Extension: "png", Patterns: []SignaturePattern{at(0, "\x89PNG")}},
+		{MIME: "image/gif", Extension: "gif", Patterns: []SignaturePattern{at(0, "GIF87a")}},
+		{MIME: "image/gif", Extension: "gif", Patterns: []SignaturePattern{at(0, "GIF89a")}},
+		{MIME: "image/webp", Extension: "webp", Patterns: []SignaturePattern{at(0, "RIFF"), at(8, "WEBP")}},
+		{MIME: "image/bmp", Extension: "bmp", Patterns: []SignaturePattern{at(0, "BM"), at(6, "\x00\x00\x00\x00")}},
+		{MIME: "image/avif", Extension: "avif", Patterns: ftypBrand("avif")},
+		{MIME: "image/heic", Extension: "heic", Patterns: ftypBrand("heic")},
+		{MIME: "image/heic", Extension: "heic", Patterns: ftypBrand("heix")},
+		{MIME: "image/heif", Extension: "heif", Patterns: ftypBrand("mif1")},
+
+		// ISO base media (MP4 family) by major brand; generic detection only
+		// recognizes files that list an "mp4" brand
+		{MIME: "audio/mp4", Extension: "m4a", Patterns: ftypBrand("M4A "), Parent: "video/mp4"},
+		{MIME: "video/x-m4v", Extension: "m4v", Patterns: ftypBrand("M4V "), Parent: "video/mp4"},
+		{MIME: "video/quicktime", Extension: "mov", Patterns: ftypBrand("qt  ")},
+		{MIME: "video/3gpp", Extension: "3gp", Patterns: ftypBrand("3gp")},
+		{MIME: "video/mp4", Extension: "mp4", Patterns: []SignaturePattern{at(4, "ftyp")}},
+
+		// Matroska and WebM share the EBML container and differ by DocType
+		{MIME: "video/x-matroska", Extension: "mkv", Patterns: []SignaturePattern{at(0, "\x1A\x45\xDF\xA3")},
+			Parent: "video/webm", Match: ebmlDocType("matroska")},
+		{MIME: "video/webm", Extension: "webm", Patterns: []SignaturePattern{at(0, "\x1A\x45\xDF\xA3")}},
+		{MIME: "video/mpeg", Extension: "mpeg", Patterns: []SignaturePattern{at(0, "\x00\x00\x01\xBA")}},
+		{MIME: "video/x-msvideo", Extension: "avi", Patterns: []SignaturePattern{at(0, "RIFF"), at(8, "AVI ")}},
+
+		// Audio
+		{MIME: "audio/mpeg", Extension: "mp3", Patterns: []SignaturePattern{at(0, "ID3")}},
+		{MIME: "audio/aac", Extension: "aac", Patterns: []SignaturePattern{
+			{Offset: 0, Magic: []byte{0xFF, 0xF0}, Mask: []byte{0xFF, 0xF6}},
+		}},
+		// MPEG audio frame sync (11 set bits)
+		{MIME: "audio/mpeg", Extension: "mp3", Patterns: []SignaturePattern{
+			{Offset: 0, Magic: []byte{0xFF, 0xE0}, Mask: []byte{0xFF, 0xE0}},
+		}},
+		{MIME: "audio/ogg", Extension: "ogg", Patterns: []SignaturePattern{at(0, "OggS")}},
+		{MIME: "audio/wav", Extension: "wav", Patterns: []SignaturePattern{at(0, "RIFF"), at(8, "WAVE")}},
+		{MIME: "audio/flac", Extension: "flac", Patterns: []SignaturePattern{at(0, "fLaC")}},
+
+		// Documents inside ZIP containers
+		{MIME: "application/epub+zip", Extension: "epub", Parent: "application/zip",
+			Patterns: []SignaturePattern{at(0, "PK\x03\x04"), at(30, "mimetypeapplication/epub+zip")}},
+		{MIME: docx, Extension: "docx", Parent: "application/zip",
+			Patterns: []SignaturePattern{at(0, "PK\x03\x04")}, Match: zipHasEntryPrefix("word/")},
+		{MIME: xlsx, Extension: "xlsx", Parent: "application/zip",
+			Patterns: []SignaturePattern{at(0, "PK\x03\x04")}, Match: zipHasEntryPrefix("xl/")},
+		{MIME: pptx, Extension: "pptx", Parent: "application/zip",
+			Patterns: []SignaturePattern{at(0, "PK\x03\x04")}, Match: zipHasEntryPrefix("ppt/")},
+
+		// Documents
+		{MIME: "application/pdf", Extension: "pdf", Patterns: []SignaturePattern{at(0, "%PDF")}},
+		{MIME: "application/zip", Extension: "zip", Patterns: []SignaturePattern{at(0, "PK\x03\x04")}},
+
+		// Archives
+		{MIME: "application/x-tar", Extension: "tar", Patterns: []SignaturePattern{at(257, "ustar")}},
+		{MIME: "application/x-gzip", Extension: "gz", Patterns: []SignaturePattern{at(0, "\x1F\x8B")}},
+		{MIME: "application/zstd", Extension: "zst", Patterns: []SignaturePattern{at(0, "\x28\xB5\x2F\xFD")}},
+		{MIME: "application/x-rar-compressed", Extension: "rar", Patterns: []SignaturePattern{at(0, "Rar!")}},
+		{MIME: "application/x-7z-compressed", Extension: "7z", Patterns: []SignaturePattern{at(0, "7z\xBC\xAF\x27\x1C")}},
+
+		// Binaries and 3D models
+		{MIME: "application/wasm", Extension: "wasm", Patterns: []SignaturePattern{at(0, "\x00asm")}},
+		{MIME: "model/gltf-binary", Extension: "glb", Patterns: []SignaturePattern{at(0, "glTF")}},
+	}
+}
+
+// defaultExtensions maps MIME types that have no signature, or that generic
+// detection reports under another name, to extensions
+func defaultExtensions() map[string]string {
+	return map[string]string{
+		"application/json":         "json",
+		"application/xml":          "xml",
+		"application/ogg":          "ogg",
+		"audio/wave":               "wav",
+		"video/avi":                "avi",
+		"text/html":                "html",
+		"text/css":                 "css",
+		"text/javascript":          "js",
+		"text/plain":               "txt",
+		"image/svg+xml":            "svg",
+		"application/octet-stream": "bin",
+	}
+}
+
+// ebmlDocType matches an EBML header declaring the given DocType
+func ebmlDocType(docType string) func([]byte) bool {
+	return func(content []byte) bool {
+		header := findEBMLElement(content, ebmlIDHeader)
+		if header == nil {
+			return false
+		}
+		return string(findEBMLElement(header, ebmlIDDocType)) == docType
+	}
+}
+
+// zipHasEntryPrefix matches a ZIP whose local file headers, within the
+// first part of the archive, include an entry name with the given prefix
+func zipHasEntryPrefix(prefix string) func([]byte) bool {
+	return func(content []byte) bool {
+		data := content[:min(len(content), propertyScanTail)]
+		for pos := 0; ; {
+			idx := bytes.Index(data[pos:], []byte("PK\x03\x04"))
+			if idx < 0 {
+				return false
+			}
+			pos += idx
+			if pos+30 > len(data) {
+				return false
+			}
+
+			nameLen := int(binary.LittleEndian.Uint16(data[pos+26:]))
+			if pos+30+nameLen > len(data) {
+				return false
+			}
+			if strings.HasPrefix(string(data[pos+30:pos+30+nameLen]), prefix) {
+				return true
+			}
+			pos += 30 + nameLen
+		}
+	}
+}
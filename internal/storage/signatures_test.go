@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func buildTestZip(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip Create() error = %v", err)
+		}
+		w.Write([]byte("<xml/>"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func ftypFile(major string, compatible ...string) []byte {
+	payload := []byte(major + "\x00\x00\x00\x00")
+	for _, brand := range compatible {
+		payload = append(payload, brand...)
+	}
+	return append(mp4Box("ftyp", payload), mp4Box("mdat", make([]byte, 16))...)
+}
+
+func ebmlFile(docType string) []byte {
+	header := ebmlElement([]byte{0x42, 0x82}, []byte(docType))
+	return append(ebmlElement([]byte{0x1A, 0x45, 0xDF, 0xA3}, header), make([]byte, 16)...)
+}
+
+func TestDetectMIME_Signatures(t *testing.T) {
+	detector := NewDetector()
+
+	tests := []struct {
+		name     string
+		content  []byte
+		wantMIME string
+		wantExt  string
+	}{
+		{
+			name:     "docx inside zip",
+			content:  buildTestZip(t, "[Content_Types].xml", "_rels/.rels", "word/document.xml"),
+			wantMIME: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			wantExt:  "docx",
+		},
+		{
+			name:     "xlsx inside zip",
+			content:  buildTestZip(t, "[Content_Types].xml", "xl/workbook.xml"),
+			wantMIME: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			wantExt:  "xlsx",
+		},
+		{
+			name:     "plain zip",
+			content:  buildTestZip(t, "readme.txt"),
+			wantMIME: "application/zip",
+			wantExt:  "zip",
+		},
+		{
+			name:     "mp4 with larger ftyp box",
+			content:  ftypFile("isom", "isom", "iso2", "avc1", "mp41"),
+			wantMIME: "video/mp4",
+			wantExt:  "mp4",
+		},
+		{
+			name:     "m4a refines mp4",
+			content:  ftypFile("M4A ", "M4A ", "mp42", "isom"),
+			wantMIME: "audio/mp4",
+			wantExt:  "m4a",
+		},
+		{
+			name:     "heic brand",
+			content:  ftypFile("heic", "mif1", "heic"),
+			wantMIME: "image/heic",
+			wantExt:  "heic",
+		},
+		{
+			name:     "matroska doctype",
+			content:  ebmlFile("matroska"),
+			wantMIME: "video/x-matroska",
+			wantExt:  "mkv",
+		},
+		{
+			name:     "webm doctype",
+			content:  ebmlFile("webm"),
+			wantMIME: "video/webm",
+			wantExt:  "webm",
+		},
+		{
+			name:     "webassembly",
+			content:  []byte("\x00asm\x01\x00\x00\x00"),
+			wantMIME: "application/wasm",
+			wantExt:  "wasm",
+		},
+		{
+			name:     "binary glTF",
+			content:  []byte("glTF\x02\x00\x00\x00\x0c\x00\x00\x00"),
+			wantMIME: "model/gltf-binary",
+			wantExt:  "glb",
+		},
+		{
+			name:     "mpeg audio frame sync via mask",
+			content:  []byte{0xFF, 0xF3, 0x44, 0xC4, 0x00, 0x00, 0x00, 0x00},
+			wantMIME: "audio/mpeg",
+			wantExt:  "mp3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detector.DetectMIME(tt.content); got != tt.wantMIME {
+				t.Errorf("DetectMIME() = %q, want %q", got, tt.wantMIME)
+			}
+			if got := detector.DetectExtension(tt.content); got != tt.wantExt {
+				t.Errorf("DetectExtension() = %q, want %q", got, tt.wantExt)
+			}
+		})
+	}
+}
+
+func TestDetectBySignature_RIFFSubtypes(t *testing.T) {
+	detector := NewDetector()
+
+	tests := []struct {
+		content []byte
+		want    string
+	}{
+		{[]byte("RIFF\x00\x00\x00\x00WAVEfmt \x00\x00\x00\x00"), "audio/wav"},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 \x00\x00\x00\x00"), "image/webp"},
+		{[]byte("RIFF\x00\x00\x00\x00AVI LIST\x00\x00\x00\x00"), "video/x-msvideo"},
+		{[]byte("RIFF\x00\x00\x00\x00XXXXXXXX\x00\x00\x00\x00"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.content[8:12]), func(t *testing.T) {
+			if got := detector.detectBySignature(tt.content, "application/octet-stream"); got != tt.want {
+				t.Errorf("detectBySignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterSignature(t *testing.T) {
+	detector := NewDetector()
+	err := detector.RegisterSignature(Signature{
+		MIME:      "application/x-custom",
+		Extension: "cst",
+		Patterns: []SignaturePattern{
+			{Offset: 4, Magic: []byte("CST!")},
+			{Offset: 8, Magic: []byte{0x10}, Mask: []byte{0xF0}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterSignature() error = %v", err)
+	}
+
+	content := []byte("\x00\x00\x00\x00CST!\x1F\x00\x00\x00")
+	if got := detector.DetectMIME(content); got != "application/x-custom" {
+		t.Errorf("DetectMIME() = %q, want %q", got, "application/x-custom")
+	}
+	if got := detector.DetectExtension(content); got != "cst" {
+		t.Errorf("DetectExtension() = %q, want %q", got, "cst")
+	}
+
+	// Masked nibble does not match
+	content[8] = 0x2F
+	if got := detector.DetectMIME(content); got == "application/x-custom" {
+		t.Errorf("DetectMIME() matched despite mask mismatch")
+	}
+}
+
+func TestRegisterSignature_Invalid(t *testing.T) {
+	detector := NewDetector()
+
+	invalid := []Signature{
+		{Patterns: []SignaturePattern{{Magic: []byte("x")}}},
+		{MIME: "application/x-custom"},
+		{MIME: "application/x-custom", Patterns: []SignaturePattern{{Offset: -1, Magic: []byte("x")}}},
+		{MIME: "application/x-custom", Patterns: []SignaturePattern{{Magic: []byte("xy"), Mask: []byte{0xFF}}}},
+	}
+
+	for _, sig := range invalid {
+		if err := detector.RegisterSignature(sig); err == nil {
+			t.Errorf("RegisterSignature(%+v) expected error", sig)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package vault
+
+import (
+	"crypto/subtle"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+)
+
+// Client is a caller allowed to use vault keys by alias
+type Client struct {
+	Name    string   // Shown in the audit log; matches the gateway API key of the same name
+	KeyHash string   // auth.HashKey of the client's own key (empty = only by its gateway API key)
+	Aliases []string // Aliases the client may use; "*" = all
+}
+
+// ACL decides which aliases each API key may use
+type ACL struct {
+	clients []Client
+}
+
+// NewACL creates an ACL from the configured clients
+func NewACL(clients []Client) *ACL {
+	return &ACL{clients: clients}
+}
+
+// ClientNamed returns the client with the given name
+func (a *ACL) ClientNamed(name string) (*Client, bool) {
+	for i := range a.clients {
+		if a.clients[i].Name == name {
+			return &a.clients[i], true
+		}
+	}
+	return nil, false
+}
+
+// Client returns the client an API key belongs to
+func (a *ACL) Client(apiKey string) (*Client, bool) {
+	if apiKey == "" {
+		return nil, false
+	}
+	hash := auth.HashKey(apiKey)
+	for i := range a.clients {
+		// Constant-time comparison to prevent timing attacks
+		if a.clients[i].KeyHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.clients[i].KeyHash)) == 1 {
+			return &a.clients[i], true
+		}
+	}
+	return nil, false
+}
+
+// Allows reports whether the client may use alias
+func (c *Client) Allows(alias string) bool {
+	for _, allowed := range c.Aliases {
+		if allowed == "*" || allowed == alias {
+			return true
+		}
+	}
+	return false
+}
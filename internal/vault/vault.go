@@ -0,0 +1,281 @@
+// Package vault keeps named viewing keys on the server so that callers can
+// decrypt files by alias instead of holding the raw keys.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+// aliasPattern restricts aliases to names that are safe in URLs and logs
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Config holds vault configuration
+type Config struct {
+	// Secret seals the stored viewing keys
+	Secret []byte
+
+	// File is where the sealed keys are kept
+	File string
+}
+
+// Key describes a stored viewing key. The key itself is never exposed.
+type Key struct {
+	Alias       string    `json:"alias"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// entry is the persisted form of a key
+type entry struct {
+	SealedEVK   string    `json:"evk"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// vaultFile is the persisted form of a Vault
+type vaultFile struct {
+	Keys map[string]entry `json:"keys"`
+}
+
+// Vault stores viewing keys under aliases, sealed with AES-256-GCM and
+// persisted to a JSON file after every change
+type Vault struct {
+	mu      sync.RWMutex
+	path    string
+	aead    cipher.AEAD
+	entries map[string]entry
+	now     func() time.Time
+}
+
+// Open creates a vault, loading existing keys from cfg.File
+func Open(cfg Config) (*Vault, error) {
+	if len(cfg.Secret) < 32 {
+		return nil, fmt.Errorf("vault secret must be at least 32 bytes")
+	}
+	if cfg.File == "" {
+		return nil, fmt.Errorf("vault file is required")
+	}
+
+	mac := hmac.New(sha256.New, cfg.Secret)
+	mac.Write([]byte("verus-gateway vault seal"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Vault{
+		path:    cfg.File,
+		aead:    aead,
+		entries: make(map[string]entry),
+		now:     time.Now,
+	}
+
+	data, err := os.ReadFile(cfg.File)
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %w", err)
+	}
+	for alias, e := range file.Keys {
+		// Catch a changed secret at startup rather than on first use
+		if _, err := v.open(e.SealedEVK, alias); err != nil {
+			return nil, fmt.Errorf("failed to unseal vault key %q: wrong secret?", alias)
+		}
+		v.entries[alias] = e
+	}
+
+	return v, nil
+}
+
+// List returns all stored keys sorted by alias
+func (v *Vault) List() []Key {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	keys := make([]Key, 0, len(v.entries))
+	for alias, e := range v.entries {
+		keys = append(keys, keyFrom(alias, e))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Alias < keys[j].Alias })
+	return keys
+}
+
+// Get describes a stored key
+func (v *Vault) Get(alias string) (*Key, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	e, ok := v.entries[alias]
+	if !ok {
+		return nil, notFound(alias)
+	}
+	key := keyFrom(alias, e)
+	return &key, nil
+}
+
+// Put stores a viewing key under alias, replacing any existing one. It
+// reports whether the alias is new.
+func (v *Vault) Put(alias, evk, description string) (*Key, bool, error) {
+	if !aliasPattern.MatchString(alias) {
+		return nil, false, domain.NewInvalidInputError("alias", "must be 1-64 letters, digits, dots, dashes or underscores")
+	}
+	if evk == "" {
+		return nil, false, domain.NewInvalidInputError("evk", "a viewing key is required")
+	}
+	if err := domain.ValidateEVK(evk); err != nil {
+		return nil, false, err
+	}
+
+	sealed, err := v.seal(evk, alias)
+	if err != nil {
+		return nil, false, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now().UTC()
+	old, exists := v.entries[alias]
+	e := entry{SealedEVK: sealed, Description: description, CreatedAt: now, UpdatedAt: now}
+	if exists {
+		e.CreatedAt = old.CreatedAt
+	}
+
+	v.entries[alias] = e
+	if err := v.save(); err != nil {
+		// Keep memory and disk in step
+		if exists {
+			v.entries[alias] = old
+		} else {
+			delete(v.entries, alias)
+		}
+		return nil, false, err
+	}
+
+	key := keyFrom(alias, e)
+	return &key, !exists, nil
+}
+
+// Delete removes a stored key
+func (v *Vault) Delete(alias string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	old, ok := v.entries[alias]
+	if !ok {
+		return notFound(alias)
+	}
+
+	delete(v.entries, alias)
+	if err := v.save(); err != nil {
+		v.entries[alias] = old
+		return err
+	}
+	return nil
+}
+
+// Resolve returns the viewing key stored under alias
+func (v *Vault) Resolve(alias string) (string, error) {
+	v.mu.RLock()
+	e, ok := v.entries[alias]
+	v.mu.RUnlock()
+
+	if !ok {
+		return "", notFound(alias)
+	}
+	return v.open(e.SealedEVK, alias)
+}
+
+// save writes the vault to disk. The caller must hold the write lock.
+func (v *Vault) save() error {
+	data, err := json.MarshalIndent(vaultFile{Keys: v.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so a crash never leaves a truncated file
+	tmp, err := os.CreateTemp(filepath.Dir(v.path), ".vault-*")
+	if err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), v.path); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+
+	return nil
+}
+
+// seal encrypts a viewing key, bound to its alias
+func (v *Vault) seal(evk, alias string) (string, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(v.aead.Seal(nonce, nonce, []byte(evk), []byte(alias))), nil
+}
+
+// open reverses seal
+func (v *Vault) open(sealed, alias string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < v.aead.NonceSize() {
+		return "", fmt.Errorf("vault key %q is corrupt", alias)
+	}
+	evk, err := v.aead.Open(nil, data[:v.aead.NonceSize()], data[v.aead.NonceSize():], []byte(alias))
+	if err != nil {
+		return "", fmt.Errorf("vault key %q is corrupt", alias)
+	}
+	return string(evk), nil
+}
+
+// keyFrom describes a persisted entry
+func keyFrom(alias string, e entry) Key {
+	return Key{
+		Alias:       alias,
+		Description: e.Description,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+// notFound is the error for an unknown alias
+func notFound(alias string) *domain.Error {
+	return domain.NewError("KEY_NOT_FOUND", "vault key not found", http.StatusNotFound, domain.ErrNotFound).
+		WithDetail("alias", alias)
+}
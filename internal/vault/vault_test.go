@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devdudeio/verus-gateway/internal/auth"
+	"github.com/devdudeio/verus-gateway/internal/domain"
+)
+
+const (
+	testSecret = "0123456789abcdef0123456789abcdef"
+	testEVK    = "zxviews1qtest12345678901234567890123456789012345678901234567890123456789012345678901234567890123456"
+)
+
+func openTestVault(t *testing.T, file string) *Vault {
+	t.Helper()
+	v, err := Open(Config{Secret: []byte(testSecret), File: file})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return v
+}
+
+func TestVault_PutResolveDelete(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "vault.json")
+	v := openTestVault(t, file)
+
+	key, created, err := v.Put("finance", testEVK, "Quarterly reports")
+	if err != nil || !created {
+		t.Fatalf("Put() = %v, %v, want a new key", created, err)
+	}
+	if key.Alias != "finance" || key.Description != "Quarterly reports" {
+		t.Errorf("Put() = %+v", key)
+	}
+
+	// Keys are sealed on disk
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), testEVK) {
+		t.Error("vault file contains the viewing key in plaintext")
+	}
+
+	// Replacing keeps the creation time
+	updated, created, err := v.Put("finance", testEVK+"2", "")
+	if err != nil || created || !updated.CreatedAt.Equal(key.CreatedAt) {
+		t.Errorf("Put() replace = %+v, %v, %v", updated, created, err)
+	}
+
+	// A restarted gateway resolves the stored key
+	restarted := openTestVault(t, file)
+	if evk, err := restarted.Resolve("finance"); err != nil || evk != testEVK+"2" {
+		t.Errorf("Resolve() after restart = %q, %v", evk, err)
+	}
+	if keys := restarted.List(); len(keys) != 1 || keys[0].Alias != "finance" {
+		t.Errorf("List() = %+v", keys)
+	}
+
+	if err := restarted.Delete("finance"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := restarted.Resolve("finance"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Resolve() after delete error = %v, want not found", err)
+	}
+	if err := restarted.Delete("finance"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Delete() twice error = %v, want not found", err)
+	}
+}
+
+func TestVault_PutValidation(t *testing.T) {
+	v := openTestVault(t, filepath.Join(t.TempDir(), "vault.json"))
+
+	tests := map[string][2]string{
+		"empty alias":    {"", testEVK},
+		"alias slash":    {"a/b", testEVK},
+		"alias too long": {strings.Repeat("a", 65), testEVK},
+		"missing evk":    {"finance", ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := v.Put(tt[0], tt[1], ""); !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("Put() error = %v, want invalid input", err)
+			}
+		})
+	}
+}
+
+func TestOpen_WrongSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "vault.json")
+	v := openTestVault(t, file)
+	if _, _, err := v.Put("finance", testEVK, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(Config{Secret: []byte("another secret of at least 32 bytes"), File: file}); err == nil {
+		t.Error("Open() with another secret succeeded, want error")
+	}
+	if _, err := Open(Config{Secret: []byte("short"), File: file}); err == nil {
+		t.Error("Open() with short secret succeeded, want error")
+	}
+}
+
+func TestACL(t *testing.T) {
+	acl := NewACL([]Client{
+		{Name: "reports", KeyHash: auth.HashKey("reports-key"), Aliases: []string{"finance", "hr"}},
+		{Name: "etl", KeyHash: auth.HashKey("etl-key"), Aliases: []string{"*"}},
+		{Name: "disabled", Aliases: []string{"*"}},
+	})
+
+	tests := []struct {
+		apiKey     string
+		alias      string
+		wantClient string
+		wantAllow  bool
+	}{
+		{"reports-key", "finance", "reports", true},
+		{"reports-key", "legal", "reports", false},
+		{"etl-key", "legal", "etl", true},
+		{"unknown-key", "finance", "", false},
+		{auth.HashKey("reports-key"), "finance", "", false},
+		{"", "finance", "", false},
+	}
+	for _, tt := range tests {
+		client, ok := acl.Client(tt.apiKey)
+		if !ok {
+			if tt.wantClient != "" {
+				t.Errorf("Client(%q) not found, want %s", tt.apiKey, tt.wantClient)
+			}
+			continue
+		}
+		if client.Name != tt.wantClient || client.Allows(tt.alias) != tt.wantAllow {
+			t.Errorf("Client(%q) = %s allows %q = %v, want %s %v", tt.apiKey, client.Name, tt.alias, client.Allows(tt.alias), tt.wantClient, tt.wantAllow)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,6 +23,9 @@ type Client struct {
 	maxRetries int
 	retryDelay time.Duration
 
+	// maxResponseSize caps how much of a response body is read (0 = unlimited)
+	maxResponseSize int64
+
 	// Metrics
 	requestCount  atomic.Uint64
 	errorCount    atomic.Uint64
@@ -37,8 +41,15 @@ type Config struct {
 	TLSInsecure bool
 	MaxRetries  int
 	RetryDelay  time.Duration
+
+	// MaxResponseSize is the largest response body accepted, in bytes.
+	// Larger responses fail with ErrResponseTooLarge (0 = unlimited).
+	MaxResponseSize int64
 }
 
+// ErrResponseTooLarge is returned when a response exceeds MaxResponseSize
+var ErrResponseTooLarge = errors.New("rpc response too large")
+
 // NewClient creates a new Verus RPC client
 func NewClient(cfg Config) *Client {
 	// Set defaults
@@ -69,9 +80,10 @@ func NewClient(cfg Config) *Client {
 			Transport: transport,
 			Timeout:   cfg.Timeout,
 		},
-		timeout:    cfg.Timeout,
-		maxRetries: cfg.MaxRetries,
-		retryDelay: cfg.RetryDelay,
+		timeout:         cfg.Timeout,
+		maxRetries:      cfg.MaxRetries,
+		retryDelay:      cfg.RetryDelay,
+		maxResponseSize: cfg.MaxResponseSize,
 	}
 }
 
@@ -128,6 +140,11 @@ func (c *Client) Call(ctx context.Context, method string, params ...interface{})
 			return nil, ctx.Err()
 		}
 
+		// Retrying won't make an oversized response smaller
+		if errors.Is(err, ErrResponseTooLarge) {
+			return nil, err
+		}
+
 		// Don't retry on client errors (4xx)
 		if rpcErr, ok := err.(*RPCError); ok {
 			if rpcErr.Code >= -32099 && rpcErr.Code <= -32000 {
@@ -186,13 +203,28 @@ func (c *Client) call(ctx context.Context, method string, params ...interface{})
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	// Read response, without buffering more than the size limit
+	if c.maxResponseSize > 0 && resp.ContentLength > c.maxResponseSize {
+		c.errorCount.Add(1)
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrResponseTooLarge, resp.ContentLength, c.maxResponseSize)
+	}
+
+	var reader io.Reader = resp.Body
+	if c.maxResponseSize > 0 {
+		reader = io.LimitReader(resp.Body, c.maxResponseSize+1)
+	}
+
+	body, err := io.ReadAll(reader)
 	if err != nil {
 		c.errorCount.Add(1)
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if c.maxResponseSize > 0 && int64(len(body)) > c.maxResponseSize {
+		c.errorCount.Add(1)
+		return nil, fmt.Errorf("%w: exceeds limit of %d bytes", ErrResponseTooLarge, c.maxResponseSize)
+	}
+
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
 		c.errorCount.Add(1)
@@ -289,6 +321,86 @@ type ChainInfo struct {
 	Testnet      bool   `json:"testnet"`      // Whether this is testnet
 }
 
+// GetRawTransaction calls the getrawtransaction RPC method in verbose mode
+func (c *Client) GetRawTransaction(ctx context.Context, txid string) (*RawTransaction, error) {
+	result, err := c.Call(ctx, "getrawtransaction", txid, 1)
+	if err != nil {
+		return nil, fmt.Errorf("getrawtransaction failed: %w", err)
+	}
+
+	var tx RawTransaction
+	if err := json.Unmarshal(result, &tx); err != nil {
+		return nil, fmt.Errorf("failed to parse getrawtransaction result: %w", err)
+	}
+
+	return &tx, nil
+}
+
+// GetBlockHeader calls the getblockheader RPC method in verbose mode
+func (c *Client) GetBlockHeader(ctx context.Context, hash string) (*BlockHeader, error) {
+	result, err := c.Call(ctx, "getblockheader", hash, true)
+	if err != nil {
+		return nil, fmt.Errorf("getblockheader failed: %w", err)
+	}
+
+	var header BlockHeader
+	if err := json.Unmarshal(result, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse getblockheader result: %w", err)
+	}
+
+	return &header, nil
+}
+
+// GetBlockCount calls the getblockcount RPC method
+func (c *Client) GetBlockCount(ctx context.Context) (int64, error) {
+	result, err := c.Call(ctx, "getblockcount")
+	if err != nil {
+		return 0, fmt.Errorf("getblockcount failed: %w", err)
+	}
+
+	var count int64
+	if err := json.Unmarshal(result, &count); err != nil {
+		return 0, fmt.Errorf("failed to parse getblockcount result: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetBlockHash calls the getblockhash RPC method, returning the hash of the
+// block at height on the best chain
+func (c *Client) GetBlockHash(ctx context.Context, height int64) (string, error) {
+	result, err := c.Call(ctx, "getblockhash", height)
+	if err != nil {
+		return "", fmt.Errorf("getblockhash failed: %w", err)
+	}
+
+	var hash string
+	if err := json.Unmarshal(result, &hash); err != nil {
+		return "", fmt.Errorf("failed to parse getblockhash result: %w", err)
+	}
+
+	return hash, nil
+}
+
+// RawTransaction represents a verbose getrawtransaction result
+type RawTransaction struct {
+	TXID          string `json:"txid"`
+	BlockHash     string `json:"blockhash"`     // Empty while the transaction is in the mempool
+	Height        int64  `json:"height"`        // Block height (if confirmed)
+	Confirmations int64  `json:"confirmations"` // 0 while in the mempool
+	Time          int64  `json:"time"`          // Unix time the transaction was received or mined
+	BlockTime     int64  `json:"blocktime"`     // Unix time of the containing block
+}
+
+// BlockHeader represents a verbose getblockheader result
+type BlockHeader struct {
+	Hash              string `json:"hash"`
+	Height            int64  `json:"height"`
+	Confirmations     int64  `json:"confirmations"` // -1 if the block is not on the best chain
+	Time              int64  `json:"time"`          // Unix block time
+	PreviousBlockHash string `json:"previousblockhash"`
+}
+
 // recordMetrics records call metrics
 func (c *Client) recordMetrics(duration time.Duration, err error) {
 	c.totalDuration.Add(duration.Microseconds())
@@ -3,8 +3,11 @@ package verusrpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -255,6 +258,72 @@ func TestClient_GetInfo(t *testing.T) {
 	}
 }
 
+func TestClient_BlockInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var result string
+		switch req.Method {
+		case "getrawtransaction":
+			if len(req.Params) != 2 || req.Params[0] != "txid123" {
+				t.Errorf("unexpected getrawtransaction params: %v", req.Params)
+			}
+			result = `{"txid":"txid123","blockhash":"00ab","height":100,"confirmations":6,"time":1700000000,"blocktime":1700000000}`
+		case "getblockheader":
+			result = `{"hash":"00ab","height":100,"confirmations":6,"time":1700000000,"previousblockhash":"00aa"}`
+		case "getblockcount":
+			result = `105`
+		case "getblockhash":
+			if len(req.Params) != 1 || req.Params[0] != float64(100) {
+				t.Errorf("unexpected getblockhash params: %v", req.Params)
+			}
+			result = `"00ab"`
+		default:
+			t.Errorf("unexpected method %s", req.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Response{JSONRPC: "2.0", ID: 1, Result: json.RawMessage(result)})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{URL: server.URL, User: "user", Password: "pass"})
+	ctx := context.Background()
+
+	tx, err := client.GetRawTransaction(ctx, "txid123")
+	if err != nil {
+		t.Fatalf("GetRawTransaction() error = %v", err)
+	}
+	if tx.BlockHash != "00ab" || tx.Confirmations != 6 {
+		t.Errorf("GetRawTransaction() = %+v", tx)
+	}
+
+	header, err := client.GetBlockHeader(ctx, tx.BlockHash)
+	if err != nil {
+		t.Fatalf("GetBlockHeader() error = %v", err)
+	}
+	if header.Height != 100 || header.Time != 1700000000 || header.PreviousBlockHash != "00aa" {
+		t.Errorf("GetBlockHeader() = %+v", header)
+	}
+
+	count, err := client.GetBlockCount(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockCount() error = %v", err)
+	}
+	if count != 105 {
+		t.Errorf("GetBlockCount() = %d, want 105", count)
+	}
+
+	hash, err := client.GetBlockHash(ctx, 100)
+	if err != nil {
+		t.Fatalf("GetBlockHash() error = %v", err)
+	}
+	if hash != "00ab" {
+		t.Errorf("GetBlockHash() = %q, want %q", hash, "00ab")
+	}
+}
+
 func TestClient_Stats(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := Response{
@@ -339,3 +408,49 @@ func TestClient_Retry(t *testing.T) {
 		t.Errorf("expected 'success', got '%s'", resultStr)
 	}
 }
+
+func TestClient_MaxResponseSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{name: "content length known"},
+		{name: "chunked response", chunked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				body := `{"jsonrpc":"2.0","id":1,"result":"` + strings.Repeat("ab", 1000) + `"}`
+				if !tt.chunked {
+					w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+				}
+				w.Write([]byte(body))
+				if tt.chunked {
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{
+				URL:             server.URL,
+				User:            "user",
+				Password:        "pass",
+				MaxRetries:      3,
+				RetryDelay:      10 * time.Millisecond,
+				MaxResponseSize: 1024,
+			})
+
+			_, err := client.Call(context.Background(), "testmethod")
+			if !errors.Is(err, ErrResponseTooLarge) {
+				t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+			}
+
+			if attempts != 1 {
+				t.Errorf("expected no retries for oversized response, got %d attempts", attempts)
+			}
+		})
+	}
+}